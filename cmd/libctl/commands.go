@@ -0,0 +1,349 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/services"
+)
+
+// usageError is a command line the commands cannot run.
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// app runs libctl commands against books as actor, printing to out.
+type app struct {
+	books services.BookService
+	actor models.Actor
+	out   io.Writer
+	json  bool
+}
+
+func (a *app) run(args []string) error {
+	if len(args) == 0 {
+		return usageError{"missing command"}
+	}
+	commands := map[string]func(args []string) error{
+		"get":    a.get,
+		"add":    a.add,
+		"update": a.update,
+		"delete": a.delete,
+		"search": a.search,
+		"borrow": a.borrow,
+		"return": a.returnBook,
+		"import": a.importBooks,
+		"export": a.exportBooks,
+		"report": a.report,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return usageError{fmt.Sprintf("unknown command %q", args[0])}
+	}
+	return command(args[1:])
+}
+
+// parse parses the flags of command, turning flag errors into usage errors.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError{fmt.Sprintf("%s: %v", fs.Name(), err)}
+	}
+	return nil
+}
+
+func newFlagSet(command string) *flag.FlagSet {
+	return flag.NewFlagSet(command, flag.ContinueOnError)
+}
+
+func requireID(command string, id int) error {
+	if id < 1 {
+		return usageError{command + ": -id is required"}
+	}
+	return nil
+}
+
+func (a *app) get(args []string) error {
+	fs := newFlagSet("get")
+	id := fs.Int("id", 0, "book id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := requireID("get", *id); err != nil {
+		return err
+	}
+	resp, err := a.books.GetBookByID(*id)
+	if err != nil {
+		return err
+	}
+	return a.printBook(resp)
+}
+
+func (a *app) add(args []string) error {
+	fs := newFlagSet("add")
+	book := models.BookRequest{}
+	fs.StringVar(&book.Title, "title", "", "title")
+	fs.StringVar(&book.Author, "author", "", "author")
+	fs.StringVar(&book.Category, "category", "", "category")
+	fs.Func("isbn", "ISBN-10 or ISBN-13", func(value string) error {
+		book.ISBN = &value
+		return nil
+	})
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := services.NewBookValidator().Struct(book); err != nil {
+		return usageError{"add: " + err.Error()}
+	}
+	resp, err := a.books.CreateBook(a.actor, book)
+	if err != nil {
+		return err
+	}
+	return a.printBook(resp)
+}
+
+// update changes only the fields given. Without -version it is conditional on
+// the version it read the book at, so a concurrent edit is not overwritten.
+func (a *app) update(args []string) error {
+	fs := newFlagSet("update")
+	id := fs.Int("id", 0, "book id")
+	version := fs.Int("version", 0, "fail unless the book is at this version")
+	title := fs.String("title", "", "title")
+	author := fs.String("author", "", "author")
+	category := fs.String("category", "", "category")
+	isbnCode := fs.String("isbn", "", `ISBN-10 or ISBN-13, "" to remove it`)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := requireID("update", *id); err != nil {
+		return err
+	}
+	current, err := a.books.GetBookByID(*id)
+	if err != nil {
+		return err
+	}
+	book := models.BookRequest{
+		Title:    current.Data.Title,
+		Author:   current.Data.Author,
+		Category: current.Data.Category,
+	}
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	if given["title"] {
+		book.Title = *title
+	}
+	if given["author"] {
+		book.Author = *author
+	}
+	if given["category"] {
+		book.Category = *category
+	}
+	if given["isbn"] {
+		book.ISBN = isbnCode
+	}
+	if err := services.NewBookValidator().Struct(book); err != nil {
+		return usageError{"update: " + err.Error()}
+	}
+	if *version == 0 {
+		*version = current.Data.Version
+	}
+	resp, err := a.books.UpdateBook(a.actor, *id, *version, book)
+	if err != nil {
+		return err
+	}
+	return a.printBook(resp)
+}
+
+func (a *app) delete(args []string) error {
+	fs := newFlagSet("delete")
+	id := fs.Int("id", 0, "book id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := requireID("delete", *id); err != nil {
+		return err
+	}
+	resp, err := a.books.DeleteBook(a.actor, *id)
+	if err != nil {
+		return err
+	}
+	return a.printBook(resp)
+}
+
+// searchFlags defines the search filters on fs; the returned function reads
+// them once fs is parsed.
+func searchFlags(fs *flag.FlagSet) func() (models.BookSearchRequest, error) {
+	search := models.BookSearchRequest{}
+	fs.StringVar(&search.Query, "q", "", "match title, author and category")
+	fs.StringVar(&search.Mode, "mode", "", "fuzzy to tolerate typos in -q")
+	fs.StringVar(&search.Title, "title", "", "title contains")
+	fs.StringVar(&search.Author, "author", "", "author contains")
+	fs.StringVar(&search.Category, "category", "", "category contains")
+	fs.Func("borrowed", "true or false", func(value string) error {
+		borrowed, err := strconv.ParseBool(value)
+		search.IsBorrowed = &borrowed
+		return err
+	})
+	fs.StringVar(&search.Sort, "sort", "", "sort field")
+	fs.StringVar(&search.Order, "order", "", "asc or desc")
+	fs.IntVar(&search.Page, "page", 0, "page number")
+	fs.IntVar(&search.PageSize, "page-size", 0, "books per page")
+	return func() (models.BookSearchRequest, error) {
+		if err := services.NewBookValidator().Struct(search); err != nil {
+			return search, usageError{fs.Name() + ": " + err.Error()}
+		}
+		return search, nil
+	}
+}
+
+func (a *app) search(args []string) error {
+	fs := newFlagSet("search")
+	readSearch := searchFlags(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	search, err := readSearch()
+	if err != nil {
+		return err
+	}
+	resp, err := a.books.SearchBooks(search)
+	if err != nil {
+		return err
+	}
+	return a.printBooks(resp)
+}
+
+func (a *app) borrow(args []string) error {
+	fs := newFlagSet("borrow")
+	id := fs.Int("id", 0, "book id")
+	memberID := fs.Int("member", 0, "member id")
+	copyID := fs.Int("copy", 0, "copy id, any available copy when 0")
+	version := fs.Int("version", 0, "fail unless the book is at this version")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := requireID("borrow", *id); err != nil {
+		return err
+	}
+	if *memberID < 1 {
+		return usageError{"borrow: -member is required"}
+	}
+	resp, err := a.books.BorrowBook(a.actor, *id, *version, *memberID, *copyID)
+	if err != nil {
+		return err
+	}
+	return a.printBook(resp)
+}
+
+func (a *app) returnBook(args []string) error {
+	fs := newFlagSet("return")
+	id := fs.Int("id", 0, "book id")
+	copyID := fs.Int("copy", 0, "copy id, needed when several copies are out")
+	version := fs.Int("version", 0, "fail unless the book is at this version")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := requireID("return", *id); err != nil {
+		return err
+	}
+	resp, err := a.books.ReturnBook(a.actor, *id, *version, *copyID)
+	if err != nil {
+		return err
+	}
+	return a.printBook(resp)
+}
+
+// importBooks reads FILE, or stdin for "-", in the format its extension
+// names unless -format is given.
+func (a *app) importBooks(args []string) error {
+	fs := newFlagSet("import")
+	format := fs.String("format", "", "csv, ndjson, marc21 or marcxml; by file extension when empty")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError{"import: one FILE is required"}
+	}
+	name := fs.Arg(0)
+	if *format == "" {
+		*format = services.ImportFormatOf(name)
+	}
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	rows, err := services.NewBookImportReader(r, *format)
+	if err != nil {
+		return err
+	}
+	resp, err := a.books.ImportBooks(a.actor, rows, *dryRun)
+	if err != nil {
+		return err
+	}
+	return a.printImport(resp)
+}
+
+// exportBooks streams every book matching the search flags to -out, stdout
+// when it is empty.
+func (a *app) exportBooks(args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", services.ExportFormatCSV, "csv, ndjson or marc21")
+	out := fs.String("out", "", "file to write, stdout when empty")
+	readSearch := searchFlags(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	search, err := readSearch()
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case services.ExportFormatCSV, services.ExportFormatNDJSON, services.ExportFormatMARC21:
+	default:
+		return usageError{"export: -format must be csv, ndjson or marc21"}
+	}
+	w := a.out
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return a.books.ExportBooks(search, *format, w)
+}
+
+func (a *app) report(args []string) error {
+	if len(args) != 1 {
+		return usageError{"report: one of most-borrowed or overdue is required"}
+	}
+	switch strings.ToLower(args[0]) {
+	case "most-borrowed":
+		resp, err := a.books.GetMostBorrowedBooks()
+		if err != nil {
+			return err
+		}
+		return a.printBooks(resp)
+	case "overdue":
+		resp, err := a.books.GetOverdueLoans()
+		if err != nil {
+			return err
+		}
+		return a.printLoans(resp)
+	}
+	return usageError{fmt.Sprintf("report: unknown report %q", args[0])}
+}
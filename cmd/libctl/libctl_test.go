@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/repositories/migrations"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestApp is libctl on a migrated sqlite file, with member 1 and the
+// copies of book 1 in place.
+func newTestApp(t *testing.T) (*app, *bytes.Buffer) {
+	t.Helper()
+	loggers.InitLogger(config.App{Env: "dev"})
+	DB, err := db.Open(config.Database{Driver: db.DriverSqlite, DSN: filepath.Join(t.TempDir(), "library.db")}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := DB.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	_, err = migrations.New(DB, migrations.All).Up()
+	require.NoError(t, err)
+	require.NoError(t, DB.Create(&models.MemberRepository{Name: "name test1", Email: "test1@example.com"}).Error)
+	require.NoError(t, DB.Create(&models.CopyRepository{BookID: 1, Barcode: "barcode test1"}).Error)
+
+	cfg := config.Config{Loan: config.Loan{PeriodDays: 14, HoldPickupDays: 3, MaxRenewals: 2}, Fine: config.Fine{BlockThreshold: 100}}
+	bookSvc := services.NewBookService(db.NewBookRepository(DB), db.NewMemberRepository(DB), db.NewCopyRepository(DB),
+		db.NewLoanRepository(DB), db.NewFineRepository(DB), db.NewHoldRepository(DB), cfg.Loan, cfg.Fine)
+	out := &bytes.Buffer{}
+	return &app{books: bookSvc, actor: models.Actor{Name: "libctl:test", Role: models.RoleAdmin}, out: out}, out
+}
+
+func TestLibctlCatalog(t *testing.T) {
+	cli, out := newTestApp(t)
+
+	require.NoError(t, cli.run([]string{"add", "-title", "title test1", "-author", "author test1", "-category", "category test1", "-isbn", "9780441013593"}))
+	assert.Equal(t, "create book successfully\n", out.String())
+
+	out.Reset()
+	require.NoError(t, cli.run([]string{"update", "-id", "1", "-category", "category test2"}))
+	out.Reset()
+	require.NoError(t, cli.run([]string{"get", "-id", "1"}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"1", "title", "test1", "author", "test1", "category", "test2", "9780441013593", "1/1", "0", "2"}, strings.Fields(lines[1]))
+
+	out.Reset()
+	cli.json = true
+	require.NoError(t, cli.run([]string{"search", "-q", "title", "-category", "test2"}))
+	list := models.BookListResponse{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &list))
+	assert.Equal(t, int64(1), list.Total)
+	assert.Equal(t, "category test2", list.Data[0].Category)
+
+	// an empty -isbn removes it
+	require.NoError(t, cli.run([]string{"update", "-id", "1", "-isbn", ""}))
+	out.Reset()
+	require.NoError(t, cli.run([]string{"get", "-id", "1"}))
+	book := models.BookResponse{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &book))
+	assert.Equal(t, "title test1", book.Data.Title)
+	assert.Equal(t, "", book.Data.ISBN13)
+	assert.Equal(t, 3, book.Data.Version)
+
+	err := cli.run([]string{"update", "-id", "1", "-version", "2", "-title", "title test3"})
+	var appErr errs.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 412, appErr.Code)
+
+	require.NoError(t, cli.run([]string{"delete", "-id", "1"}))
+	err = cli.run([]string{"get", "-id", "1"})
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, constant.BookErrorsMessageFindNotFound, appErr.Message)
+}
+
+func TestLibctlCirculation(t *testing.T) {
+	cli, out := newTestApp(t)
+	require.NoError(t, cli.run([]string{"add", "-title", "title test1", "-author", "author test1", "-category", "category test1"}))
+
+	require.NoError(t, cli.run([]string{"borrow", "-id", "1", "-member", "1"}))
+	err := cli.run([]string{"borrow", "-id", "1", "-member", "1"})
+	assert.Error(t, err)
+	require.NoError(t, cli.run([]string{"return", "-id", "1"}))
+
+	out.Reset()
+	require.NoError(t, cli.run([]string{"report", "most-borrowed"}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "1", strings.Fields(lines[1])[0])
+
+	out.Reset()
+	require.NoError(t, cli.run([]string{"report", "overdue"}))
+	assert.Equal(t, []string{"ID", "BOOK", "COPY", "MEMBER", "BORROWED", "AT", "DUE", "AT", "DAYS", "LATE"}, strings.Fields(out.String()))
+}
+
+func TestLibctlImportExport(t *testing.T) {
+	cli, out := newTestApp(t)
+	file := filepath.Join(t.TempDir(), "books.csv")
+	require.NoError(t, os.WriteFile(file, []byte("title,author,category,isbn\ntitle test1,author test1,category test1,\ntitle test2,,,\n"), 0o600))
+
+	require.NoError(t, cli.run([]string{"import", "-dry-run", file}))
+	assert.Contains(t, out.String(), "1 created, 0 skipped, 1 failed")
+
+	out.Reset()
+	cli.json = true
+	require.NoError(t, cli.run([]string{"import", file}))
+	report := models.BookImportResponse{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Failed)
+
+	out.Reset()
+	require.NoError(t, cli.run([]string{"export", "-format", "csv", "-title", "test1"}))
+	assert.Equal(t, "id,title,author,category,isbn,borrow_count,total_copies,available_copies\n1,title test1,author test1,category test1,,0,1,1\n", out.String())
+}
+
+func TestLibctlUsage(t *testing.T) {
+	cli, _ := newTestApp(t)
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{"no command", []string{}},
+		{"unknown command", []string{"frob"}},
+		{"missing id", []string{"get"}},
+		{"unknown flag", []string{"get", "-nope", "1"}},
+		{"invalid book", []string{"add", "-title", "title test1"}},
+		{"invalid isbn", []string{"add", "-title", "title test1", "-author", "author test1", "-category", "category test1", "-isbn", "123"}},
+		{"missing member", []string{"borrow", "-id", "1"}},
+		{"invalid search", []string{"search", "-order", "up"}},
+		{"missing file", []string{"import"}},
+		{"export format", []string{"export", "-format", "xml"}},
+		{"unknown report", []string{"report", "popular"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			err := cli.run(tC.args)
+			var usageErr usageError
+			assert.True(t, errors.As(err, &usageErr), "%v", err)
+		})
+	}
+}
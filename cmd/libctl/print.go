@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"test-exam-forviz/internal/models"
+	"text/tabwriter"
+)
+
+// printJSON writes resp as the HTTP API would return it.
+func (a *app) printJSON(resp interface{}) error {
+	encoder := json.NewEncoder(a.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(resp)
+}
+
+// printTable writes header and rows aligned in columns, one line per row.
+func (a *app) printTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, strings.ReplaceAll(cell, "\n", "; "))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+var bookHeader = []string{"ID", "TITLE", "AUTHOR", "CATEGORY", "ISBN", "AVAILABLE", "BORROWS", "VERSION"}
+
+func bookRow(book models.BookData) []string {
+	return []string{
+		strconv.Itoa(book.ID), book.Title, book.Author, book.Category, book.ISBN13,
+		fmt.Sprintf("%d/%d", book.AvailableCopies, book.TotalCopies),
+		strconv.Itoa(book.BorrowCount), strconv.Itoa(book.Version),
+	}
+}
+
+func (a *app) printBook(resp models.BookResponse) error {
+	if a.json {
+		return a.printJSON(resp)
+	}
+	// the changes answer with a message only
+	if resp.Data == nil {
+		_, err := fmt.Fprintln(a.out, resp.Message)
+		return err
+	}
+	return a.printTable(bookHeader, [][]string{bookRow(*resp.Data)})
+}
+
+func (a *app) printBooks(resp models.BookListResponse) error {
+	if a.json {
+		return a.printJSON(resp)
+	}
+	rows := [][]string{}
+	for _, book := range resp.Data {
+		rows = append(rows, bookRow(book))
+	}
+	if err := a.printTable(bookHeader, rows); err != nil {
+		return err
+	}
+	if resp.Page > 0 {
+		_, err := fmt.Fprintf(a.out, "page %d, %d of %d books\n", resp.Page, len(resp.Data), resp.Total)
+		return err
+	}
+	return nil
+}
+
+func (a *app) printLoans(resp models.LoanListResponse) error {
+	if a.json {
+		return a.printJSON(resp)
+	}
+	rows := [][]string{}
+	for _, loan := range resp.Data {
+		rows = append(rows, []string{
+			strconv.Itoa(loan.ID), strconv.Itoa(loan.BookID), strconv.Itoa(loan.CopyID), strconv.Itoa(loan.MemberID),
+			loan.BorrowedAt, loan.DueAt, strconv.Itoa(loan.DaysLate),
+		})
+	}
+	return a.printTable([]string{"ID", "BOOK", "COPY", "MEMBER", "BORROWED AT", "DUE AT", "DAYS LATE"}, rows)
+}
+
+func (a *app) printImport(resp models.BookImportResponse) error {
+	if a.json {
+		return a.printJSON(resp)
+	}
+	rows := [][]string{}
+	for _, row := range resp.Rows {
+		id := ""
+		if row.ID != 0 {
+			id = strconv.Itoa(row.ID)
+		}
+		rows = append(rows, []string{strconv.Itoa(row.Line), row.Status, id, row.Title, row.Error})
+	}
+	if err := a.printTable([]string{"LINE", "STATUS", "ID", "TITLE", "ERROR"}, rows); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(a.out, "%s: %d created, %d skipped, %d failed\n", resp.Message, resp.Created, resp.Skipped, resp.Failed)
+	return err
+}
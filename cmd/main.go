@@ -2,19 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"test-exam-forviz/config"
-	"test-exam-forviz/internal/models"
 	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/repositories/migrations"
 	"test-exam-forviz/internal/routers"
 	"test-exam-forviz/internal/services"
 	"test-exam-forviz/loggers"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -22,57 +26,129 @@ import (
 func main() {
 	cfg := config.InitConfig()
 	loggers.InitLogger(cfg.App)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := runMigrate(initDB(cfg.Database), os.Args[2:])
+		loggers.Sync()
+		os.Exit(code)
+	}
+	if strings.TrimSpace(cfg.Auth.SecretKey) == "" {
+		loggers.Fatal("auth.secretKey is required")
+	}
 
-	DB := initSqlite(cfg.Sqlite)
-	migrateDB(DB, models.BookRepository{})
+	DB := initDB(cfg.Database)
+	migrateDB(DB)
+	// the full-text index is sqlite only and optional, so it is not a migration
+	if cfg.Database.Driver == db.DriverSqlite {
+		migrateBookSearch(DB)
+	}
 	// repository
 	bookRepo := db.NewBookRepository(DB)
+	copyRepo := db.NewCopyRepository(DB)
+	memberRepo := db.NewMemberRepository(DB)
+	loanRepo := db.NewLoanRepository(DB)
+	fineRepo := db.NewFineRepository(DB)
+	holdRepo := db.NewHoldRepository(DB)
+	userRepo := db.NewUserRepository(DB)
+	apiKeyRepo := db.NewApiKeyRepository(DB)
+	auditRepo := db.NewAuditRepository(DB)
 
 	// service
-	bookSvc := services.NewBookService(bookRepo)
+	bookSvc := services.NewBookService(bookRepo, memberRepo, copyRepo, loanRepo, fineRepo, holdRepo, cfg.Loan, cfg.Fine)
+	copySvc := services.NewCopyService(copyRepo, bookRepo)
+	holdSvc := services.NewHoldService(holdRepo, bookRepo, memberRepo, cfg.Loan)
+	memberSvc := services.NewMemberService(memberRepo)
+	fineSvc := services.NewFineService(fineRepo, memberRepo)
+	authSvc := services.NewAuthService(userRepo, memberRepo, cfg.Auth)
+	apiKeySvc := services.NewApiKeyService(apiKeyRepo)
+	auditSvc := services.NewAuditService(auditRepo)
+	if err := authSvc.EnsureAdmin(); err != nil {
+		loggers.Fatal(fmt.Sprintf("seed admin error:%v", err.Error()), zap.Error(err))
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	go services.PurgeTrashEvery(ctx, bookSvc, cfg.Trash)
 
-	e := routers.InitRouter(bookSvc)
-	go run(e, cfg.App)
-	quit := make(chan os.Signal, 1)
-	<-quit
-	loggers.Info("receive signal: shutting down...\n")
-	if err := e.Shutdown(context.Background()); err != nil {
-		loggers.Fatal(err.Error())
+	e := routers.InitRouter(bookSvc, copySvc, holdSvc, memberSvc, fineSvc, authSvc, apiKeySvc, auditSvc)
+	if err := serve(ctx, stop, e, cfg.App, DB); err != nil {
+		loggers.Error("shutdown error", zap.Error(err))
+		loggers.Sync()
+		os.Exit(1)
 	}
+	loggers.Info("shutdown complete.")
+	loggers.Sync()
+}
 
+// signalContext is cancelled by the first SIGINT or SIGTERM; after stop a
+// second signal kills the process as usual.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 }
 
-func run(e *echo.Echo, cfg config.App) {
-	serverPort := fmt.Sprintf(":%v", cfg.Port)
-	err := e.Start(serverPort)
+// serve runs e until ctx is cancelled, then lets in-flight requests finish
+// for up to cfg.ShutdownTimeoutSeconds and closes the database pool. It calls
+// stop before draining, so a second signal kills a shutdown that hangs.
+func serve(ctx context.Context, stop context.CancelFunc, e *echo.Echo, cfg config.App, DB *gorm.DB) error {
+	started := make(chan error, 1)
+	go func() {
+		started <- e.Start(fmt.Sprintf(":%v", cfg.Port))
+	}()
+	select {
+	case err := <-started:
+		// the server never ran, so there is nothing to drain
+		return errors.Join(err, closeDB(DB))
+	case <-ctx.Done():
+	}
+	stop()
+	loggers.Info("receive signal: shutting down...", zap.Int("timeout_seconds", cfg.ShutdownTimeoutSeconds))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	err := e.Shutdown(shutdownCtx)
 	if err != nil {
-		loggers.Fatal("router shutdown....")
+		// the drain timed out; drop the connections still open
+		err = errors.Join(err, e.Close())
 	}
-
+	if startErr := <-started; !errors.Is(startErr, http.ErrServerClosed) {
+		err = errors.Join(err, startErr)
+	}
+	return errors.Join(err, closeDB(DB))
 }
 
-func initSqlite(configSqlite config.Sqlite) *gorm.DB {
-	sqlitePath := configSqlite.Name
-	if strings.TrimSpace(configSqlite.Path) != "" {
-		sqlitePath = fmt.Sprintf("%v/%v", configSqlite.Path, configSqlite.Name)
-
+func closeDB(DB *gorm.DB) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
 	}
-	db, err := gorm.Open(sqlite.Open(sqlitePath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	return sqlDB.Close()
+}
+
+func initDB(configDatabase config.Database) *gorm.DB {
+	DB, err := db.Open(configDatabase, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
 	if err != nil {
-		loggers.Fatal(fmt.Sprintf("cannot connect sqlite error=%v", err.Error()), zap.Error(err))
+		loggers.Fatal(fmt.Sprintf("cannot connect %v error=%v", configDatabase.Driver, err.Error()), zap.Error(err))
 	}
-	sql, _ := db.DB()
-	sql.SetMaxIdleConns(configSqlite.MaxIdleConns)
-	sql.SetMaxOpenConns(configSqlite.MaxOpenConns)
-	sql.SetConnMaxLifetime(configSqlite.MaxLifeTimeMinutes)
-	loggers.Info("connect DB successfully.")
-	return db
+	loggers.Info("connect DB successfully.", zap.String("driver", configDatabase.Driver))
+	return DB
 }
 
-func migrateDB(db *gorm.DB, bookTable models.BookRepository) {
-	err := db.AutoMigrate(bookTable)
+// migrateDB applies the pending schema migrations and refuses to start on a
+// database migrated by a newer build.
+func migrateDB(DB *gorm.DB) {
+	applied, err := migrations.New(DB, migrations.All).Up()
 	if err != nil {
-		loggers.Fatal(fmt.Sprintf("AutoMigrate error:%v", err.Error()), zap.Error(err))
+		loggers.Fatal(fmt.Sprintf("migrate DB error:%v", err.Error()), zap.Error(err))
+	}
+	for _, migration := range applied {
+		loggers.Info("migration applied.", zap.Int("version", migration.Version), zap.String("name", migration.Name))
 	}
 	loggers.Info("migrate DB successfully.")
 }
+
+// migrateBookSearch is not fatal: without FTS5 the q search uses LIKE matching.
+func migrateBookSearch(DB *gorm.DB) {
+	if err := db.MigrateBookSearch(DB); err != nil {
+		loggers.Error("full-text search unavailable, build with -tags sqlite_fts5", zap.Error(err))
+		return
+	}
+	loggers.Info("migrate book search successfully.")
+}
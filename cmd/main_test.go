@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"syscall"
+	"test-exam-forviz/config"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	DB, err := db.Open(config.Database{Driver: db.DriverSqlite, DSN: filepath.Join(t.TempDir(), "library.db")}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	return DB
+}
+
+// startServe runs serve in the background with a /slow route that answers
+// once release is closed, and returns its address and result.
+func startServe(t *testing.T, ctx context.Context, stop context.CancelFunc, cfg config.App, DB *gorm.DB, entered chan<- struct{}, release <-chan struct{}) (string, <-chan error) {
+	t.Helper()
+	loggers.InitLogger(config.App{Env: "dev"})
+	e := echo.New()
+	e.HideBanner, e.HidePort = true, true
+	e.GET("/slow", func(c echo.Context) error {
+		entered <- struct{}{}
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+	result := make(chan error, 1)
+	go func() { result <- serve(ctx, stop, e, cfg, DB) }()
+	require.Eventually(t, func() bool { return e.ListenerAddr() != nil }, 5*time.Second, 10*time.Millisecond)
+	return e.ListenerAddr().String(), result
+}
+
+func get(url string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("status %d", resp.StatusCode)
+			}
+		}
+		done <- err
+	}()
+	return done
+}
+
+func TestServeShutsDownOnSignal(t *testing.T) {
+	DB := openTestDB(t)
+	ctx, stop := signalContext()
+	defer stop()
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	addr, result := startServe(t, ctx, stop, config.App{ShutdownTimeoutSeconds: 5}, DB, entered, release)
+
+	request := get("http://" + addr + "/slow")
+	<-entered
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM did not cancel the context")
+	}
+
+	// new connections are refused while the in-flight request drains
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	select {
+	case err := <-result:
+		t.Fatalf("serve returned before the request finished: %v", err)
+	default:
+	}
+
+	close(release)
+	assert.NoError(t, <-request)
+	assert.NoError(t, <-result)
+	sqlDB, err := DB.DB()
+	require.NoError(t, err)
+	assert.Error(t, sqlDB.Ping(), "the pool is closed")
+}
+
+func TestServeDrainTimeout(t *testing.T) {
+	DB := openTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	addr, result := startServe(t, ctx, cancel, config.App{ShutdownTimeoutSeconds: 0}, DB, entered, release)
+
+	request := get("http://" + addr + "/slow")
+	<-entered
+	cancel()
+
+	select {
+	case err := <-result:
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not give up on the request")
+	}
+	assert.Error(t, <-request, "the request is cut off")
+	sqlDB, err := DB.DB()
+	require.NoError(t, err)
+	assert.Error(t, sqlDB.Ping(), "the pool is closed")
+}
+
+func TestServeStartError(t *testing.T) {
+	loggers.InitLogger(config.App{Env: "dev"})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	DB := openTestDB(t)
+	e := echo.New()
+	e.HideBanner, e.HidePort = true, true
+
+	err = serve(context.Background(), func() {}, e, config.App{Port: listener.Addr().(*net.TCPAddr).Port}, DB)
+	assert.Error(t, err)
+	sqlDB, dbErr := DB.DB()
+	require.NoError(t, dbErr)
+	assert.Error(t, sqlDB.Ping(), "the pool is closed")
+}
+
+func TestServeStopsSignalsBeforeDrain(t *testing.T) {
+	DB := openTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan struct{})
+	stop := func() { close(stopped) }
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	addr, result := startServe(t, ctx, stop, config.App{ShutdownTimeoutSeconds: 5}, DB, entered, release)
+
+	request := get("http://" + addr + "/slow")
+	<-entered
+	cancel()
+
+	// signals are handed back while the request is still in flight
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not stop the signal context before draining")
+	}
+	select {
+	case err := <-result:
+		t.Fatalf("serve returned before the request finished: %v", err)
+	default:
+	}
+
+	close(release)
+	assert.NoError(t, <-request)
+	assert.NoError(t, <-result)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"strings"
 	"sync"
@@ -10,9 +11,14 @@ import (
 )
 
 type Config struct {
-	App    App    `mapstructure:"app"`
-	Log    Log    `mapstructure:"log"`
-	Sqlite Sqlite `mapstructure:"sqlite"`
+	App      App      `mapstructure:"app"`
+	Log      Log      `mapstructure:"log"`
+	Database Database `mapstructure:"database"`
+	Sqlite   Sqlite   `mapstructure:"sqlite"`
+	Loan     Loan     `mapstructure:"loan"`
+	Fine     Fine     `mapstructure:"fine"`
+	Auth     Auth     `mapstructure:"auth"`
+	Trash    Trash    `mapstructure:"trash"`
 }
 
 type Log struct {
@@ -23,6 +29,20 @@ type App struct {
 	Version float64 `mapstructure:"version"`
 	Port    int     `mapstructure:"port"`
 	Env     string  `mapstructure:"env"`
+	// ShutdownTimeoutSeconds is how long in-flight requests may finish after
+	// SIGINT or SIGTERM before the server closes them.
+	ShutdownTimeoutSeconds int `mapstructure:"shutdownTimeoutSeconds"`
+}
+
+// Database picks the backend: Driver is sqlite, postgres or mysql and DSN
+// its connection string. A sqlite database left without a DSN or pool
+// settings takes them from the sqlite section.
+type Database struct {
+	Driver             string `mapstructure:"driver"`
+	DSN                string `mapstructure:"dsn"`
+	MaxIdleConns       int    `mapstructure:"maxIdleConns"`
+	MaxOpenConns       int    `mapstructure:"maxOpenConns"`
+	MaxLifeTimeMinutes int    `mapstructure:"maxLifeTimeMinutes"`
 }
 type Sqlite struct {
 	Name               string        `mapstructure:"dbname"`
@@ -32,6 +52,37 @@ type Sqlite struct {
 	MaxLifeTimeMinutes time.Duration `mapstructure:"maxLifeTimeMinutes"`
 }
 
+type Loan struct {
+	PeriodDays     int `mapstructure:"periodDays"`
+	HoldPickupDays int `mapstructure:"holdPickupDays"`
+	MaxRenewals    int `mapstructure:"maxRenewals"`
+}
+
+type Fine struct {
+	RatePerDay     float64 `mapstructure:"ratePerDay"`
+	GraceDays      int     `mapstructure:"graceDays"`
+	MaxAmount      float64 `mapstructure:"maxAmount"`
+	BlockThreshold float64 `mapstructure:"blockThreshold"`
+	// CategoryCaps overrides MaxAmount per book category; viper lowercases the keys.
+	CategoryCaps map[string]float64 `mapstructure:"categoryCaps"`
+}
+
+type Auth struct {
+	// SecretKey is the HMAC key signing access tokens.
+	SecretKey          string `mapstructure:"secretKey"`
+	TokenExpireMinutes int    `mapstructure:"tokenExpireMinutes"`
+	// AdminUsername and AdminPassword seed the first admin account when it is missing.
+	AdminUsername string `mapstructure:"adminUsername"`
+	AdminPassword string `mapstructure:"adminPassword"`
+}
+
+// Trash sets how long deleted books can be restored before the purge job,
+// running every PurgeIntervalMinutes, removes them for good.
+type Trash struct {
+	RetentionDays        int `mapstructure:"retentionDays"`
+	PurgeIntervalMinutes int `mapstructure:"purgeIntervalMinutes"`
+}
+
 var config Config
 var configOnce sync.Once
 
@@ -43,6 +94,19 @@ func InitConfig() Config {
 		viper.AutomaticEnv()            // อ่าน value จาก ENV variable
 		// แปลง _ underscore ใน env เป็น . dot notation ใน viper
 		viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		viper.SetDefault("app.shutdownTimeoutSeconds", 10)
+		viper.SetDefault("database.driver", "sqlite")
+		viper.SetDefault("loan.periodDays", 14)
+		viper.SetDefault("loan.holdPickupDays", 3)
+		viper.SetDefault("loan.maxRenewals", 2)
+		viper.SetDefault("fine.ratePerDay", 5)
+		viper.SetDefault("fine.graceDays", 0)
+		viper.SetDefault("fine.maxAmount", 0)
+		viper.SetDefault("fine.blockThreshold", 100)
+		viper.SetDefault("auth.tokenExpireMinutes", 60)
+		viper.SetDefault("auth.adminUsername", "admin")
+		viper.SetDefault("trash.retentionDays", 30)
+		viper.SetDefault("trash.purgeIntervalMinutes", 60)
 		if err := viper.ReadInConfig(); err != nil {
 			log.Fatalf("Error reading config file, %s", err)
 		}
@@ -51,7 +115,32 @@ func InitConfig() Config {
 		if err != nil {
 			log.Fatalf("unable to decode into struct, %v", err)
 		}
+		config.Database = sqliteDatabase(config.Database, config.Sqlite)
 
 	})
 	return config
 }
+
+// sqliteDatabase fills what a sqlite Database leaves empty from the sqlite
+// section, so configs written before the database section still work.
+func sqliteDatabase(database Database, sqlite Sqlite) Database {
+	if database.Driver != "sqlite" {
+		return database
+	}
+	if database.DSN == "" {
+		database.DSN = sqlite.Name
+		if strings.TrimSpace(sqlite.Path) != "" {
+			database.DSN = fmt.Sprintf("%v/%v", sqlite.Path, sqlite.Name)
+		}
+	}
+	if database.MaxIdleConns == 0 {
+		database.MaxIdleConns = sqlite.MaxIdleConns
+	}
+	if database.MaxOpenConns == 0 {
+		database.MaxOpenConns = sqlite.MaxOpenConns
+	}
+	if database.MaxLifeTimeMinutes == 0 {
+		database.MaxLifeTimeMinutes = int(sqlite.MaxLifeTimeMinutes)
+	}
+	return database
+}
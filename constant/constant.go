@@ -11,4 +11,91 @@ const (
 	BookGetSuccessMessage               = "success"
 	BookBorrowSuccessMessage            = "borrow book successfully"
 	BookReturnSuccessMessage            = "Return book successfully"
+	BookCopyRequiredErrorMessage        = "copy_id is required when several copies are borrowed"
+	BookReservedErrorMessage            = "book reserved for another member"
+	BookNotBorrowedErrorMessage         = "book not borrowed by member"
+	BookRenewLimitErrorMessage          = "loan renewal limit reached"
+	BookRenewOverdueErrorMessage        = "loan overdue, return the book instead"
+	BookRenewSuccessMessage             = "renew book successfully"
+	BookISBNInvalidErrorMessage         = "isbn must be a valid isbn-10 or isbn-13"
+	BookISBNExistsErrorMessage          = "book with this isbn already exists"
+	BookErrorsMessageFindISBNNotFound   = "find data book by isbn not found"
+	BookImportSuccessMessage            = "import books successfully"
+	BookImportDryRunSuccessMessage      = "import books checked, nothing written"
+	BookImportFormatErrorMessage        = "import format must be csv, ndjson, marc21 or marcxml"
+	BookImportHeaderErrorMessage        = "csv header must name title, author and category columns"
+	BookImportTooLargeErrorMessage      = "import file is too large"
+	BookExportFormatErrorMessage        = "export format must be csv, ndjson or marc21"
+	BookDeleteBorrowedErrorMessage      = "book has borrowed copies, return them before deleting"
+	BookISBNInTrashErrorMessage         = "book with this isbn is in the trash, restore it instead"
+	BookErrorsMessageTrashNotFound      = "find data book in trash not found"
+	BookRestoreSuccessMessage           = "restore book successfully"
+	BookVersionMismatchErrorMessage     = "book has changed since it was read, fetch it again"
+	BookVersionConflictErrorMessage     = "book was changed by another request, try again"
+)
+
+const (
+	AuditErrorMessageInternalServerError = "generic error"
+	AuditGetSuccessMessage               = "success"
+)
+
+const (
+	HoldErrorsMessageFindNotFound       = "find data hold not found"
+	HoldBookAvailableErrorMessage       = "book available, borrow it instead"
+	HoldExistsErrorMessage              = "member already holds this book"
+	HoldErrorMessageInternalServerError = "generic error"
+	HoldCreateSuccessMessage            = "place hold successfully"
+	HoldCancelSuccessMessage            = "cancel hold successfully"
+	HoldGetSuccessMessage               = "success"
+)
+
+const (
+	CopyErrorsMessageFindNotFound       = "find data copy by id not found"
+	CopyBarcodeExistsErrorMessage       = "copy with this barcode already exists"
+	CopyErrorMessageInternalServerError = "generic error"
+	CopyCreateSuccessMessage            = "create copy successfully"
+	CopyUpdateSuccessMessage            = "update copy successfully"
+	CopyGetSuccessMessage               = "success"
+)
+
+const (
+	MemberErrorsMessageFindNotFound       = "find data member by id not found"
+	MemberEmailExistsErrorMessage         = "member with this email already exists"
+	MemberSuspendedErrorMessage           = "member suspended"
+	MemberFineBlockedErrorMessage         = "member has unpaid fines over limit"
+	MemberErrorMessageInternalServerError = "generic error"
+	MemberCreateSuccessMessage            = "create member successfully"
+	MemberUpdateSuccessMessage            = "update member successfully"
+	MemberSuspendSuccessMessage           = "suspend member successfully"
+	MemberGetSuccessMessage               = "success"
+)
+
+const (
+	FineErrorsMessageFindNotFound       = "find data fine by id not found"
+	FinePaidErrorMessage                = "fine paid"
+	FineErrorMessageInternalServerError = "generic error"
+	FinePaySuccessMessage               = "pay fine successfully"
+	FineGetSuccessMessage               = "success"
+)
+
+const (
+	AuthInvalidCredentialsErrorMessage  = "invalid username or password"
+	AuthTokenRequiredErrorMessage       = "missing or malformed bearer token"
+	AuthTokenInvalidErrorMessage        = "invalid or expired token"
+	AuthForbiddenErrorMessage           = "permission denied"
+	AuthErrorMessageInternalServerError = "generic error"
+	AuthLoginSuccessMessage             = "login successfully"
+	UserExistsErrorMessage              = "username already exists"
+	UserMemberRequiredErrorMessage      = "member_id is required for member role"
+	UserCreateSuccessMessage            = "create user successfully"
+)
+
+const (
+	ApiKeyErrorsMessageFindNotFound       = "find data api key by id not found"
+	ApiKeyRevokedErrorMessage             = "api key revoked"
+	ApiKeyInvalidErrorMessage             = "invalid api key"
+	ApiKeyErrorMessageInternalServerError = "generic error"
+	ApiKeyCreateSuccessMessage            = "create api key successfully, store the key now as it is not shown again"
+	ApiKeyRevokeSuccessMessage            = "revoke api key successfully"
+	ApiKeyGetSuccessMessage               = "success"
 )
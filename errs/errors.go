@@ -22,6 +22,36 @@ func NewInternalServerError(message string) error {
 		Message: message,
 	}
 }
+func NewUnauthorizedError(message string) error {
+	return AppError{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+	}
+}
+func NewForbiddenError(message string) error {
+	return AppError{
+		Code:    http.StatusForbidden,
+		Message: message,
+	}
+}
+func NewConflictError(message string) error {
+	return AppError{
+		Code:    http.StatusConflict,
+		Message: message,
+	}
+}
+func NewPreconditionFailedError(message string) error {
+	return AppError{
+		Code:    http.StatusPreconditionFailed,
+		Message: message,
+	}
+}
+func NewRequestEntityTooLargeError(message string) error {
+	return AppError{
+		Code:    http.StatusRequestEntityTooLarge,
+		Message: message,
+	}
+}
 func NewBadRequest(message string) error {
 	return AppError{
 		Code:    http.StatusBadRequest,
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/services"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+type apiKeyHandlers struct {
+	service services.ApiKeyService
+}
+
+// CreateApiKeyHandler implements ApiKeyHandler.
+func (a apiKeyHandlers) CreateApiKeyHandler(c echo.Context) error {
+	apiKeyReq := new(models.ApiKeyRequest)
+	if err := c.Bind(apiKeyReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(apiKeyReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	apiKeyResp, err := a.service.CreateApiKey(*apiKeyReq)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusCreated, apiKeyResp, "")
+}
+
+// RevokeApiKeyHandler implements ApiKeyHandler.
+func (a apiKeyHandlers) RevokeApiKeyHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	apiKeyResp, err := a.service.RevokeApiKey(id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, apiKeyResp, "")
+}
+
+// GetApiKeysHandler implements ApiKeyHandler.
+func (a apiKeyHandlers) GetApiKeysHandler(c echo.Context) error {
+	apiKeyResp, err := a.service.GetApiKeys()
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, apiKeyResp, "")
+}
+
+func NewApiKeyHandlers(service services.ApiKeyService) ApiKeyHandler {
+	return apiKeyHandlers{service: service}
+}
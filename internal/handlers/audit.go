@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/services"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+type auditHandlers struct {
+	service services.AuditService
+}
+
+// GetAuditLogsHandler implements AuditHandler.
+// from and to are RFC 3339 times bounding when the changes were made.
+func (a auditHandlers) GetAuditLogsHandler(c echo.Context) error {
+	searchReq := models.AuditSearchRequest{}
+	var from, to time.Time
+	err := echo.QueryParamsBinder(c).
+		String("entity", &searchReq.Entity).
+		Int("id", &searchReq.EntityID).
+		Time("from", &from, time.RFC3339).
+		Time("to", &to, time.RFC3339).
+		Int("page", &searchReq.Page).
+		Int("page_size", &searchReq.PageSize).
+		BindError()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if c.QueryParam("from") != "" {
+		searchReq.From = &from
+	}
+	if c.QueryParam("to") != "" {
+		searchReq.To = &to
+	}
+	validate := validator.New()
+	if err := validate.Struct(searchReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	auditResp, err := a.service.GetAuditLogs(searchReq)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, auditResp, "")
+}
+
+func NewAuditHandlers(service services.AuditService) AuditHandler {
+	return auditHandlers{service: service}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/services"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+type authHandlers struct {
+	service services.AuthService
+}
+
+// LoginHandler implements AuthHandler.
+func (a authHandlers) LoginHandler(c echo.Context) error {
+	loginReq := new(models.LoginRequest)
+	if err := c.Bind(loginReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(loginReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	tokenResp, err := a.service.Login(loginReq.Username, loginReq.Password)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, tokenResp, "")
+}
+
+// CreateUserHandler implements AuthHandler.
+func (a authHandlers) CreateUserHandler(c echo.Context) error {
+	userReq := new(models.UserRequest)
+	if err := c.Bind(userReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(userReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userResp, err := a.service.CreateUser(*userReq)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusCreated, userResp, "")
+}
+
+func NewAuthHandlers(service services.AuthService) AuthHandler {
+	return authHandlers{service: service}
+}
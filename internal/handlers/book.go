@@ -4,13 +4,22 @@ import (
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
+	"test-exam-forviz/constant"
 	"test-exam-forviz/internal/models"
 	"test-exam-forviz/internal/services"
+	"test-exam-forviz/isbn"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
 type bookHandlers struct {
 	service services.BookService
 }
@@ -27,7 +36,22 @@ func (b bookHandlers) BorrowBookHandler(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
 	}
-	bookResp, err := b.service.BorrowBook(id)
+	borrowReq := new(models.BorrowRequest)
+	if err = c.Bind(borrowReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(borrowReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := authorizeMember(c, borrowReq.MemberID); err != nil {
+		return err
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	bookResp, err := b.service.BorrowBook(auditActor(c), id, version, borrowReq.MemberID, borrowReq.CopyID)
 	if err != nil {
 		return HandlerError(err)
 	}
@@ -40,11 +64,11 @@ func (b bookHandlers) CreateBookHandler(c echo.Context) error {
 	if err := c.Bind(bookReq); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	validate := validator.New()
+	validate := services.NewBookValidator()
 	if err := validate.Struct(bookReq); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	bookResp, err := b.service.CreateBook(*bookReq)
+	bookResp, err := b.service.CreateBook(auditActor(c), *bookReq)
 	if err != nil {
 		return HandlerError(err)
 	}
@@ -63,7 +87,47 @@ func (b bookHandlers) DeleteBookHandler(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
 	}
-	bookResp, err := b.service.DeleteBook(id)
+	bookResp, err := b.service.DeleteBook(auditActor(c), id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, bookResp, "")
+}
+
+// RestoreBookHandler implements BookHandler.
+func (b bookHandlers) RestoreBookHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	bookResp, err := b.service.RestoreBook(auditActor(c), id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, bookResp, "")
+}
+
+// GetTrashHandler implements BookHandler.
+func (b bookHandlers) GetTrashHandler(c echo.Context) error {
+	pageReq := models.PageRequest{}
+	err := echo.QueryParamsBinder(c).
+		Int("page", &pageReq.Page).
+		Int("page_size", &pageReq.PageSize).
+		BindError()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(pageReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	bookResp, err := b.service.GetTrash(pageReq.Page, pageReq.PageSize)
 	if err != nil {
 		return HandlerError(err)
 	}
@@ -86,6 +150,11 @@ func (b bookHandlers) GetBookByIDHandler(c echo.Context) error {
 	if err != nil {
 		return HandlerError(err)
 	}
+	etag := bookETag(bookResp.Data.Version)
+	c.Response().Header().Set(HeaderETag, etag)
+	if c.Request().Header.Get(HeaderIfNoneMatch) == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
 	return c.JSONPretty(http.StatusOK, bookResp, "")
 }
 
@@ -98,6 +167,67 @@ func (b bookHandlers) GetMostBorrowedBooksHandler(c echo.Context) error {
 	return c.JSONPretty(http.StatusOK, bookResp, "")
 }
 
+// GetBookLoansHandler implements BookHandler.
+func (b bookHandlers) GetBookLoansHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	loanResp, err := b.service.GetBookLoans(id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, loanResp, "")
+}
+
+// GetOverdueLoansHandler implements BookHandler.
+func (b bookHandlers) GetOverdueLoansHandler(c echo.Context) error {
+	loanResp, err := b.service.GetOverdueLoans()
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, loanResp, "")
+}
+
+// RenewBookHandler implements BookHandler.
+func (b bookHandlers) RenewBookHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	renewReq := new(models.RenewRequest)
+	if err = c.Bind(renewReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(renewReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := authorizeMember(c, renewReq.MemberID); err != nil {
+		return err
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	bookResp, err := b.service.RenewBook(auditActor(c), id, version, renewReq.MemberID, renewReq.CopyID)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, bookResp, "")
+}
+
 // ReturnBookHandler implements BookHandler.
 func (b bookHandlers) ReturnBookHandler(c echo.Context) error {
 	paramsId := c.Param("id")
@@ -110,7 +240,32 @@ func (b bookHandlers) ReturnBookHandler(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
 	}
-	bookResp, err := b.service.ReturnBook(id)
+	returnReq := new(models.ReturnRequest)
+	if err = c.Bind(returnReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(returnReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	bookResp, err := b.service.ReturnBook(auditActor(c), id, version, returnReq.CopyID)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, bookResp, "")
+}
+
+// GetBookByISBNHandler implements BookHandler.
+func (b bookHandlers) GetBookByISBNHandler(c echo.Context) error {
+	paramsIsbn := c.Param("isbn")
+	if !isbn.Valid(paramsIsbn) {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "isbn must be a valid isbn-10 or isbn-13"})
+	}
+	bookResp, err := b.service.GetBookByISBN(paramsIsbn)
 	if err != nil {
 		return HandlerError(err)
 	}
@@ -119,16 +274,90 @@ func (b bookHandlers) ReturnBookHandler(c echo.Context) error {
 
 // SearchBooksHandler implements BookHandler.
 func (b bookHandlers) SearchBooksHandler(c echo.Context) error {
-	title := c.QueryParam("title")
-	author := c.QueryParam("author")
-	category := c.QueryParam("category")
-	bookResp, err := b.service.SearchBooks(title, author, category)
+	searchReq, err := bindBookSearch(c)
+	if err != nil {
+		return err
+	}
+	bookResp, err := b.service.SearchBooks(searchReq)
 	if err != nil {
 		return HandlerError(err)
 	}
 	return c.JSONPretty(http.StatusOK, bookResp, "")
 }
 
+// exportContentTypes maps each export format to its content type and file extension.
+var exportContentTypes = map[string][2]string{
+	services.ExportFormatCSV:    {"text/csv; charset=utf-8", "csv"},
+	services.ExportFormatNDJSON: {"application/x-ndjson", "ndjson"},
+	services.ExportFormatMARC21: {"application/marc", "mrc"},
+}
+
+// ExportBooksHandler implements BookHandler.
+// It takes the filters of SearchBooksHandler and streams the whole result.
+func (b bookHandlers) ExportBooksHandler(c echo.Context) error {
+	searchReq, err := bindBookSearch(c)
+	if err != nil {
+		return err
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = services.ExportFormatCSV
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "format must be csv, ndjson or marc21"})
+	}
+	c.Response().Header().Set(echo.HeaderContentType, contentType[0])
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="books.`+contentType[1]+`"`)
+	err = b.service.ExportBooks(searchReq, format, c.Response())
+	if err != nil {
+		// once rows went out the status is sent; the client sees a cut-off file
+		if c.Response().Committed {
+			return nil
+		}
+		return HandlerError(err)
+	}
+	return nil
+}
+
+// bindBookSearch reads the book list filters from the query string.
+func bindBookSearch(c echo.Context) (models.BookSearchRequest, error) {
+	searchReq := models.BookSearchRequest{}
+	var isBorrowed bool
+	var minBorrowCount, maxBorrowCount int
+	err := echo.QueryParamsBinder(c).
+		String("q", &searchReq.Query).
+		String("mode", &searchReq.Mode).
+		String("title", &searchReq.Title).
+		String("author", &searchReq.Author).
+		String("category", &searchReq.Category).
+		Bool("is_borrowed", &isBorrowed).
+		Int("min_borrow_count", &minBorrowCount).
+		Int("max_borrow_count", &maxBorrowCount).
+		String("sort", &searchReq.Sort).
+		String("order", &searchReq.Order).
+		Int("page", &searchReq.Page).
+		Int("page_size", &searchReq.PageSize).
+		BindError()
+	if err != nil {
+		return searchReq, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if c.QueryParam("is_borrowed") != "" {
+		searchReq.IsBorrowed = &isBorrowed
+	}
+	if c.QueryParam("min_borrow_count") != "" {
+		searchReq.MinBorrowCount = &minBorrowCount
+	}
+	if c.QueryParam("max_borrow_count") != "" {
+		searchReq.MaxBorrowCount = &maxBorrowCount
+	}
+	validate := validator.New()
+	if err := validate.Struct(searchReq); err != nil {
+		return searchReq, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return searchReq, nil
+}
+
 // UpdateBookHandler implements BookHandler.
 func (b bookHandlers) UpdateBookHandler(c echo.Context) error {
 	paramsId := c.Param("id")
@@ -144,17 +373,41 @@ func (b bookHandlers) UpdateBookHandler(c echo.Context) error {
 	if err = c.Bind(bookReq); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	validate := validator.New()
+	validate := services.NewBookValidator()
 	if err := validate.Struct(bookReq); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	bookResp, err := b.service.UpdateBook(id, *bookReq)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	bookResp, err := b.service.UpdateBook(auditActor(c), id, version, *bookReq)
 	if err != nil {
 		return HandlerError(err)
 	}
 	return c.JSONPretty(http.StatusOK, bookResp, "")
 }
 
+// bookETag is the strong entity tag of a book at version.
+func bookETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the book version a request is conditional on from its
+// If-Match header; 0 means there is no condition. A tag that can never match,
+// like a weak or malformed one, fails the precondition.
+func ifMatchVersion(c echo.Context) (int, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(ifMatch, `"`), `"`))
+	if err != nil || version < 1 || !strings.HasPrefix(ifMatch, `"`) {
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, echo.Map{"message": constant.BookVersionMismatchErrorMessage})
+	}
+	return version, nil
+}
+
 func NewBookHandlers(service services.BookService) BookHandler {
 	return bookHandlers{service: service}
 }
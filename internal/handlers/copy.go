@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/services"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+type copyHandlers struct {
+	service services.CopyService
+}
+
+// CreateCopyHandler implements CopyHandler.
+func (h copyHandlers) CreateCopyHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	copyReq := new(models.CopyRequest)
+	if err = c.Bind(copyReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(copyReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	copyResp, err := h.service.CreateCopy(id, *copyReq)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusCreated, copyResp, "")
+}
+
+// UpdateCopyHandler implements CopyHandler.
+func (h copyHandlers) UpdateCopyHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	copyReq := new(models.CopyRequest)
+	if err = c.Bind(copyReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(copyReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	copyResp, err := h.service.UpdateCopy(id, *copyReq)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, copyResp, "")
+}
+
+// GetCopiesHandler implements CopyHandler.
+func (h copyHandlers) GetCopiesHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	copyResp, err := h.service.GetCopies(id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, copyResp, "")
+}
+
+func NewCopyHandlers(service services.CopyService) CopyHandler {
+	return copyHandlers{service: service}
+}
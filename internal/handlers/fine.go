@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"test-exam-forviz/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+type fineHandlers struct {
+	service services.FineService
+}
+
+// GetMemberFinesHandler implements FineHandler.
+func (f fineHandlers) GetMemberFinesHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	if err := authorizeMember(c, id); err != nil {
+		return err
+	}
+	fineResp, err := f.service.GetOutstandingFines(id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, fineResp, "")
+}
+
+// PayFineHandler implements FineHandler.
+func (f fineHandlers) PayFineHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	fineResp, err := f.service.PayFine(id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, fineResp, "")
+}
+
+func NewFineHandlers(service services.FineService) FineHandler {
+	return fineHandlers{service: service}
+}
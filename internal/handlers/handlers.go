@@ -11,11 +11,58 @@ type BookHandler interface {
 	CreateBookHandler(c echo.Context) error
 	UpdateBookHandler(c echo.Context) error
 	DeleteBookHandler(c echo.Context) error
+	RestoreBookHandler(c echo.Context) error
+	GetTrashHandler(c echo.Context) error
 	GetBookByIDHandler(c echo.Context) error
+	GetBookByISBNHandler(c echo.Context) error
 	SearchBooksHandler(c echo.Context) error
+	ImportBooksHandler(c echo.Context) error
+	ExportBooksHandler(c echo.Context) error
 	GetMostBorrowedBooksHandler(c echo.Context) error
+	GetBookLoansHandler(c echo.Context) error
+	GetOverdueLoansHandler(c echo.Context) error
 	BorrowBookHandler(c echo.Context) error
 	ReturnBookHandler(c echo.Context) error
+	RenewBookHandler(c echo.Context) error
+}
+
+type AuditHandler interface {
+	GetAuditLogsHandler(c echo.Context) error
+}
+
+type CopyHandler interface {
+	CreateCopyHandler(c echo.Context) error
+	UpdateCopyHandler(c echo.Context) error
+	GetCopiesHandler(c echo.Context) error
+}
+
+type HoldHandler interface {
+	PlaceHoldHandler(c echo.Context) error
+	CancelHoldHandler(c echo.Context) error
+	GetHoldsHandler(c echo.Context) error
+}
+
+type MemberHandler interface {
+	CreateMemberHandler(c echo.Context) error
+	UpdateMemberHandler(c echo.Context) error
+	SuspendMemberHandler(c echo.Context) error
+	GetMembersHandler(c echo.Context) error
+}
+
+type FineHandler interface {
+	GetMemberFinesHandler(c echo.Context) error
+	PayFineHandler(c echo.Context) error
+}
+
+type AuthHandler interface {
+	LoginHandler(c echo.Context) error
+	CreateUserHandler(c echo.Context) error
+}
+
+type ApiKeyHandler interface {
+	CreateApiKeyHandler(c echo.Context) error
+	RevokeApiKeyHandler(c echo.Context) error
+	GetApiKeysHandler(c echo.Context) error
 }
 
 func HandlerError(err error) *echo.HTTPError {
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/services"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+type holdHandlers struct {
+	service services.HoldService
+}
+
+// PlaceHoldHandler implements HoldHandler.
+func (h holdHandlers) PlaceHoldHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	holdReq := new(models.HoldRequest)
+	if err = c.Bind(holdReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(holdReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := authorizeMember(c, holdReq.MemberID); err != nil {
+		return err
+	}
+	holdResp, err := h.service.PlaceHold(id, holdReq.MemberID)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusCreated, holdResp, "")
+}
+
+// CancelHoldHandler implements HoldHandler.
+func (h holdHandlers) CancelHoldHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	paramsMemberId := c.QueryParam("member_id")
+	resultMemberId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsMemberId)
+	if !resultMemberId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "member_id must have digit only and start 1"})
+	}
+	memberId, err := strconv.Atoi(paramsMemberId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	if err := authorizeMember(c, memberId); err != nil {
+		return err
+	}
+	holdResp, err := h.service.CancelHold(id, memberId)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, holdResp, "")
+}
+
+// GetHoldsHandler implements HoldHandler.
+func (h holdHandlers) GetHoldsHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	holdResp, err := h.service.GetHolds(id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, holdResp, "")
+}
+
+func NewHoldHandlers(service services.HoldService) HoldHandler {
+	return holdHandlers{service: service}
+}
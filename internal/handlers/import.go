@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// importFormat picks the upload format from the format query parameter, else
+// the file name, else the request content type.
+func importFormat(format, filename, contentType string) string {
+	if format != "" {
+		return format
+	}
+	if format := services.ImportFormatOf(filename); format != "" {
+		return format
+	}
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return services.ImportFormatCSV
+	case strings.HasPrefix(contentType, "application/x-ndjson"), strings.HasPrefix(contentType, "application/jsonl"):
+		return services.ImportFormatNDJSON
+	case strings.HasPrefix(contentType, "application/marcxml+xml"), strings.HasPrefix(contentType, echo.MIMEApplicationXML), strings.HasPrefix(contentType, echo.MIMETextXML):
+		return services.ImportFormatMARCXML
+	case strings.HasPrefix(contentType, "application/marc"):
+		return services.ImportFormatMARC21
+	}
+	return ""
+}
+
+// importMaxBytes bounds the request body of an import, the file and any
+// multipart framing around it.
+const importMaxBytes = 32 << 20
+
+// ImportBooksHandler implements BookHandler.
+// The file is the request body, or the "file" field of a multipart form.
+func (b bookHandlers) ImportBooksHandler(c echo.Context) error {
+	var dryRun bool
+	err := echo.QueryParamsBinder(c).Bool("dry_run", &dryRun).BindError()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if c.Request().ContentLength > importMaxBytes {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, constant.BookImportTooLargeErrorMessage)
+	}
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, importMaxBytes)
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	body := io.Reader(c.Request().Body)
+	filename := ""
+	if strings.HasPrefix(contentType, echo.MIMEMultipartForm) {
+		fileHeader, err := c.FormFile("file")
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, constant.BookImportTooLargeErrorMessage)
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		defer file.Close()
+		body, filename = file, fileHeader.Filename
+	}
+	rows, err := services.NewBookImportReader(body, importFormat(c.QueryParam("format"), filename, contentType))
+	if err != nil {
+		return HandlerError(err)
+	}
+	bookResp, err := b.service.ImportBooks(auditActor(c), rows, dryRun)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, bookResp, "")
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/services"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+)
+
+type memberHandlers struct {
+	service services.MemberService
+}
+
+// CreateMemberHandler implements MemberHandler.
+func (m memberHandlers) CreateMemberHandler(c echo.Context) error {
+	memberReq := new(models.MemberRequest)
+	if err := c.Bind(memberReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(memberReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	memberResp, err := m.service.CreateMember(*memberReq)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusCreated, memberResp, "")
+}
+
+// UpdateMemberHandler implements MemberHandler.
+func (m memberHandlers) UpdateMemberHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	memberReq := new(models.MemberRequest)
+	if err = c.Bind(memberReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	validate := validator.New()
+	if err := validate.Struct(memberReq); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	memberResp, err := m.service.UpdateMember(id, *memberReq)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, memberResp, "")
+}
+
+// SuspendMemberHandler implements MemberHandler.
+func (m memberHandlers) SuspendMemberHandler(c echo.Context) error {
+	paramsId := c.Param("id")
+	resultId := regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(paramsId)
+	if !resultId {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": "id must have digit only and start 1"})
+	}
+	id, err := strconv.Atoi(paramsId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	memberResp, err := m.service.SuspendMember(id)
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, memberResp, "")
+}
+
+// GetMembersHandler implements MemberHandler.
+func (m memberHandlers) GetMembersHandler(c echo.Context) error {
+	memberResp, err := m.service.GetMembers()
+	if err != nil {
+		return HandlerError(err)
+	}
+	return c.JSONPretty(http.StatusOK, memberResp, "")
+}
+
+func NewMemberHandlers(service services.MemberService) MemberHandler {
+	return memberHandlers{service: service}
+}
@@ -1,14 +1,211 @@
 package models
 
-import "time"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// BookRepository is the bibliographic title; the physical items live in CopyRepository.
 type BookRepository struct {
 	ID          int       `gorm:"primaryKey;autoIncrement"`
 	Title       string    `gorm:"index;not null"`
 	Author      string    `gorm:"index;not null"`
 	Category    string    `gorm:"index;not null"`
-	IsBorrowed  bool      `gorm:"default:false"`
+	ISBN        *string   `gorm:"uniqueIndex"` // bare ISBN-13, nil when unknown
 	BorrowCount int       `gorm:"borrow_count;default:0"`
+	Version     int       `gorm:"not null;default:1"` // bumped by every edit, borrow and return, and by copy and hold changes
 	UpdateAt    time.Time `gorm:"autoCreateTime"`
 	CreateAt    time.Time `gorm:"autoUpdateTime"`
+	// DeletedAt is set while the book is in the trash; gorm hides it from queries.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	// read-only aggregates over the title's copies, filled by the repository queries
+	TotalCopies     int `gorm:"->;-:migration"`
+	AvailableCopies int `gorm:"->;-:migration"`
+}
+
+// BookFilter narrows FindAll. Nil pointers and empty strings do not filter.
+// Query matches title, author and category together, best matches first.
+// Each group of Fragments needs one of its fragments somewhere in the title,
+// author or category.
+type BookFilter struct {
+	Query          string
+	Fragments      [][]string
+	Title          string
+	Author         string
+	Category       string
+	IsBorrowed     *bool
+	MinBorrowCount *int
+	MaxBorrowCount *int
+	SortName       string
+	SortType       string
+	Offset         int
+	Limit          int
+}
+
+// statuses of a row in a book import report
+const (
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// BookFacetRepository is one count of BookRepository.FindFacets: the books
+// matching a filter that share Value for Facet.
+type BookFacetRepository struct {
+	Facet string
+	Value string
+	Count int64
+}
+
+const (
+	BookFacetCategory     = "category"
+	BookFacetAuthor       = "author"
+	BookFacetAvailability = "availability"
+	// values of the availability facet
+	BookBorrowed  = "borrowed"
+	BookAvailable = "available"
+)
+
+type CopyRepository struct {
+	ID            int       `gorm:"primaryKey;autoIncrement"`
+	BookID        int       `gorm:"index;not null"`
+	Barcode       string    `gorm:"uniqueIndex;not null"`
+	Condition     string    `gorm:"not null;default:'good'"`
+	ShelfLocation string    `gorm:"default:''"`
+	IsBorrowed    bool      `gorm:"index;default:false"`
+	BorrowerID    int       `gorm:"index;default:0"`
+	ReservedFor   int       `gorm:"index;default:0"`
+	UpdateAt      time.Time `gorm:"autoUpdateTime"`
+	CreateAt      time.Time `gorm:"autoCreateTime"`
+}
+
+type MemberRepository struct {
+	ID          int       `gorm:"primaryKey;autoIncrement"`
+	Name        string    `gorm:"index;not null"`
+	Email       string    `gorm:"uniqueIndex;not null"`
+	Phone       string    `gorm:"default:''"`
+	IsSuspended bool      `gorm:"default:false"`
+	UpdateAt    time.Time `gorm:"autoUpdateTime"`
+	CreateAt    time.Time `gorm:"autoCreateTime"`
+}
+
+type LoanRepository struct {
+	ID         int        `gorm:"primaryKey;autoIncrement"`
+	BookID     int        `gorm:"index;not null"`
+	CopyID     int        `gorm:"index;not null;default:0"`
+	MemberID   int        `gorm:"index;not null"`
+	BorrowedAt time.Time  `gorm:"not null"`
+	DueAt      time.Time  `gorm:"index;not null"`
+	ReturnedAt *time.Time `gorm:"index"`
+	RenewCount int        `gorm:"not null;default:0"`
+	RenewedAt  *time.Time
+}
+
+type FineRepository struct {
+	ID       int        `gorm:"primaryKey;autoIncrement"`
+	LoanID   int        `gorm:"uniqueIndex;not null"`
+	MemberID int        `gorm:"index;not null"`
+	BookID   int        `gorm:"index;not null"`
+	Amount   float64    `gorm:"not null"`
+	DaysLate int        `gorm:"not null"`
+	PaidAt   *time.Time `gorm:"index"`
+	CreateAt time.Time  `gorm:"autoCreateTime"`
+}
+
+const (
+	HoldStatusWaiting   = "waiting"
+	HoldStatusReady     = "ready"
+	HoldStatusFulfilled = "fulfilled"
+	HoldStatusCancelled = "cancelled"
+	HoldStatusExpired   = "expired"
+)
+
+// HoldRepository is a place in a title's FIFO hold queue. A ready hold has a
+// copy set aside for the member until ExpireAt.
+type HoldRepository struct {
+	ID       int    `gorm:"primaryKey;autoIncrement"`
+	BookID   int    `gorm:"index;not null"`
+	MemberID int    `gorm:"index;not null"`
+	Status   string `gorm:"index;not null"`
+	CopyID   int    `gorm:"default:0"`
+	ReadyAt  *time.Time
+	ExpireAt *time.Time `gorm:"index"`
+	UpdateAt time.Time  `gorm:"autoUpdateTime"`
+	CreateAt time.Time  `gorm:"autoCreateTime"`
+}
+
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RoleMember    = "member"
+	// API keys carry their scope as role; the admin scope is RoleAdmin.
+	RoleCirculation = "circulation"
+	RoleReadOnly    = "read-only"
+)
+
+// UserRepository is a login account. Accounts with RoleMember act for MemberID.
+type UserRepository struct {
+	ID           int       `gorm:"primaryKey;autoIncrement"`
+	Username     string    `gorm:"uniqueIndex;not null"`
+	PasswordHash string    `gorm:"not null"`
+	Role         string    `gorm:"not null"`
+	MemberID     int       `gorm:"index;default:0"`
+	UpdateAt     time.Time `gorm:"autoUpdateTime"`
+	CreateAt     time.Time `gorm:"autoCreateTime"`
+}
+
+// ApiKeyRepository is a machine client credential. Only the SHA-256 of the
+// key is stored; Prefix identifies it in listings.
+type ApiKeyRepository struct {
+	ID        int        `gorm:"primaryKey;autoIncrement"`
+	Name      string     `gorm:"not null"`
+	Prefix    string     `gorm:"not null"`
+	KeyHash   string     `gorm:"uniqueIndex;not null"`
+	Scope     string     `gorm:"not null"`
+	RevokedAt *time.Time `gorm:"index"`
+	UpdateAt  time.Time  `gorm:"autoUpdateTime"`
+	CreateAt  time.Time  `gorm:"autoCreateTime"`
+}
+
+const (
+	AuditEntityBook = "book"
+
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionImport  = "import"
+	AuditActionBorrow  = "borrow"
+	AuditActionReturn  = "return"
+	AuditActionRenew   = "renew"
+)
+
+// AuditRepository is one recorded change of an entity. Before and After are
+// JSON objects of the fields the change touched; "null" when the entity did
+// not exist before or after it.
+type AuditRepository struct {
+	ID        int       `gorm:"primaryKey;autoIncrement"`
+	Entity    string    `gorm:"index:idx_audit_entity;not null"`
+	EntityID  int       `gorm:"index:idx_audit_entity;not null"`
+	Action    string    `gorm:"not null"`
+	Actor     string    `gorm:"not null"`
+	ActorID   int       `gorm:"default:0"`
+	ActorRole string    `gorm:"default:''"`
+	RequestID string    `gorm:"index;default:''"`
+	Before    string    `gorm:"type:text;not null"`
+	After     string    `gorm:"type:text;not null"`
+	CreateAt  time.Time `gorm:"index;autoCreateTime"`
+}
+
+// AuditFilter narrows the audit log. Zero values do not filter; From is
+// inclusive and To exclusive.
+type AuditFilter struct {
+	Entity   string
+	EntityID int
+	From     *time.Time
+	To       *time.Time
+	Offset   int
+	Limit    int
 }
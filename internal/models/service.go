@@ -1,25 +1,311 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type BookResponse struct {
 	Message string    `json:"message"`
 	Data    *BookData `json:"data,omitempty"`
 }
+
+// BookListResponse is one page of books. Truncated is set when a fuzzy search
+// had more candidates than it scores, so Total may miss some matches.
 type BookListResponse struct {
-	Message string     `json:"message"`
-	Data    []BookData `json:"data"`
+	Message   string      `json:"message"`
+	Total     int64       `json:"total"`
+	Truncated bool        `json:"truncated,omitempty"`
+	Page      int         `json:"page,omitempty"`
+	PageSize  int         `json:"page_size,omitempty"`
+	Facets    *BookFacets `json:"facets,omitempty"`
+	Data      []BookData  `json:"data"`
+}
+type BookFacets struct {
+	Category  []FacetCount `json:"category"`
+	Author    []FacetCount `json:"author"`
+	Borrowed  int64        `json:"borrowed"`
+	Available int64        `json:"available"`
+}
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
 }
 type BookData struct {
+	ID              int     `json:"id"`
+	Title           string  `json:"title"`
+	Author          string  `json:"author"`
+	Category        string  `json:"category"`
+	ISBN13          string  `json:"isbn13,omitempty"`
+	ISBN10          string  `json:"isbn10,omitempty"`
+	IsBorrowed      bool    `json:"is_borrowed"`
+	BorrowCount     int     `json:"borrow_count"`
+	TotalCopies     int     `json:"total_copies"`
+	AvailableCopies int     `json:"available_copies"`
+	Score           float64 `json:"score,omitempty"`
+	Version         int     `json:"version"`
+	UpdateAt        string  `json:"update_at"`
+	CreateAt        string  `json:"create_at"`
+	DeletedAt       string  `json:"deleted_at,omitempty"`
+}
+
+// BookRequest creates or updates a book. A nil ISBN leaves an updated book's
+// ISBN as it is; an empty one removes it.
+type BookRequest struct {
+	Title    string  `json:"title" validate:"required"`
+	Author   string  `json:"author" validate:"required"`
+	Category string  `json:"category" validate:"required"`
+	ISBN     *string `json:"isbn" validate:"omitempty,isbn_checksum"`
+}
+
+// BookImportRow is one parsed row of an import file; for MARC files Line is
+// the record number. Error is set when the row could not be read or failed
+// BookRequest validation. Unsupported names the MARC fields left unmapped.
+type BookImportRow struct {
+	Line        int
+	Book        BookRequest
+	Error       string
+	Unsupported []string
+}
+type BookImportResponse struct {
+	Message string `json:"message"`
+	DryRun  bool   `json:"dry_run"`
+	Created int    `json:"created"`
+	Skipped int    `json:"skipped"`
+	Failed  int    `json:"failed"`
+	// Unsupported counts the rows leaving each MARC field unmapped.
+	Unsupported map[string]int     `json:"unsupported_fields,omitempty"`
+	Rows        []BookImportResult `json:"rows"`
+}
+type BookImportResult struct {
+	Line        int      `json:"line"`
+	Status      string   `json:"status"`
+	ID          int      `json:"id,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Unsupported []string `json:"unsupported_fields,omitempty"`
+}
+
+type BookSearchRequest struct {
+	Query          string
+	Mode           string `validate:"omitempty,oneof=fuzzy"`
+	Title          string
+	Author         string
+	Category       string
+	IsBorrowed     *bool
+	MinBorrowCount *int   `validate:"omitempty,min=0"`
+	MaxBorrowCount *int   `validate:"omitempty,min=0"`
+	Sort           string `validate:"omitempty,oneof=id title author category borrow_count available_copies create_at update_at"`
+	Order          string `validate:"omitempty,oneof=asc desc"`
+	Page           int    `validate:"omitempty,min=1"`
+	PageSize       int    `validate:"omitempty,min=1,max=100"`
+}
+type PageRequest struct {
+	Page     int `validate:"omitempty,min=1"`
+	PageSize int `validate:"omitempty,min=1,max=100"`
+}
+type BorrowRequest struct {
+	MemberID int `json:"member_id" validate:"required,min=1"`
+	CopyID   int `json:"copy_id" validate:"omitempty,min=1"`
+}
+type ReturnRequest struct {
+	CopyID int `json:"copy_id" validate:"omitempty,min=1"`
+}
+type RenewRequest struct {
+	MemberID int `json:"member_id" validate:"required,min=1"`
+	CopyID   int `json:"copy_id" validate:"omitempty,min=1"`
+}
+
+type CopyResponse struct {
+	Message string    `json:"message"`
+	Data    *CopyData `json:"data,omitempty"`
+}
+type CopyListResponse struct {
+	Message string     `json:"message"`
+	Data    []CopyData `json:"data"`
+}
+type CopyData struct {
+	ID            int    `json:"id"`
+	BookID        int    `json:"book_id"`
+	Barcode       string `json:"barcode"`
+	Condition     string `json:"condition"`
+	ShelfLocation string `json:"shelf_location"`
+	IsBorrowed    bool   `json:"is_borrowed"`
+	BorrowerID    int    `json:"borrower_id,omitempty"`
+	ReservedFor   int    `json:"reserved_for,omitempty"`
+	UpdateAt      string `json:"update_at"`
+	CreateAt      string `json:"create_at"`
+}
+type CopyRequest struct {
+	Barcode       string `json:"barcode" validate:"required"`
+	Condition     string `json:"condition" validate:"required,oneof=new good fair poor damaged"`
+	ShelfLocation string `json:"shelf_location"`
+}
+
+type LoanListResponse struct {
+	Message string     `json:"message"`
+	Data    []LoanData `json:"data"`
+}
+type LoanData struct {
+	ID         int    `json:"id"`
+	BookID     int    `json:"book_id"`
+	CopyID     int    `json:"copy_id"`
+	MemberID   int    `json:"member_id"`
+	BorrowedAt string `json:"borrowed_at"`
+	DueAt      string `json:"due_at"`
+	ReturnedAt string `json:"returned_at,omitempty"`
+	RenewCount int    `json:"renew_count"`
+	RenewedAt  string `json:"renewed_at,omitempty"`
+	DaysLate   int    `json:"days_late,omitempty"`
+}
+
+type FineResponse struct {
+	Message string    `json:"message"`
+	Data    *FineData `json:"data,omitempty"`
+}
+type FineListResponse struct {
+	Message string     `json:"message"`
+	Total   float64    `json:"total"`
+	Data    []FineData `json:"data"`
+}
+type FineData struct {
+	ID       int     `json:"id"`
+	LoanID   int     `json:"loan_id"`
+	MemberID int     `json:"member_id"`
+	BookID   int     `json:"book_id"`
+	Amount   float64 `json:"amount"`
+	DaysLate int     `json:"days_late"`
+	PaidAt   string  `json:"paid_at,omitempty"`
+	CreateAt string  `json:"create_at"`
+}
+
+type HoldRequest struct {
+	MemberID int `json:"member_id" validate:"required,min=1"`
+}
+type HoldResponse struct {
+	Message string    `json:"message"`
+	Data    *HoldData `json:"data,omitempty"`
+}
+type HoldListResponse struct {
+	Message string     `json:"message"`
+	Data    []HoldData `json:"data"`
+}
+type HoldData struct {
+	ID       int    `json:"id"`
+	BookID   int    `json:"book_id"`
+	MemberID int    `json:"member_id"`
+	Position int    `json:"position"`
+	Status   string `json:"status"`
+	CopyID   int    `json:"copy_id,omitempty"`
+	ExpireAt string `json:"expire_at,omitempty"`
+	CreateAt string `json:"create_at"`
+}
+
+type MemberResponse struct {
+	Message string      `json:"message"`
+	Data    *MemberData `json:"data,omitempty"`
+}
+type MemberListResponse struct {
+	Message string       `json:"message"`
+	Data    []MemberData `json:"data"`
+}
+type MemberData struct {
 	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Author      string `json:"author"`
-	Category    string `json:"category"`
-	IsBorrowed  bool   `json:"is_borrowed"`
-	BorrowCount int    `json:"borrow_count"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	IsSuspended bool   `json:"is_suspended"`
 	UpdateAt    string `json:"update_at"`
 	CreateAt    string `json:"create_at"`
 }
-type BookRequest struct {
-	Title    string `json:"title" validate:"required"`
-	Author   string `json:"author" validate:"required"`
-	Category string `json:"category" validate:"required"`
+type MemberRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+	Phone string `json:"phone"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+type TokenResponse struct {
+	Message string     `json:"message"`
+	Data    *TokenData `json:"data,omitempty"`
+}
+type TokenData struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpireAt    string `json:"expire_at"`
+}
+type UserResponse struct {
+	Message string `json:"message"`
+}
+type UserRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+	Role     string `json:"role" validate:"required,oneof=admin librarian member"`
+	MemberID int    `json:"member_id" validate:"omitempty,min=1"`
+}
+
+type ApiKeyResponse struct {
+	Message string      `json:"message"`
+	Data    *ApiKeyData `json:"data,omitempty"`
+}
+type ApiKeyListResponse struct {
+	Message string       `json:"message"`
+	Data    []ApiKeyData `json:"data"`
+}
+type ApiKeyData struct {
+	ID        int    `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`
+	Scope     string `json:"scope"`
+	Key       string `json:"key,omitempty"`
+	RevokedAt string `json:"revoked_at,omitempty"`
+	CreateAt  string `json:"create_at,omitempty"`
+}
+type ApiKeyRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Scope string `json:"scope" validate:"required,oneof=read-only circulation admin"`
+}
+
+// ActorSystem names changes made by the server itself, like the trash purge.
+const ActorSystem = "system"
+
+// Actor is who asks for a change, recorded in the audit log: the username or
+// "apikey:<name>" with their user id and role, and the request's X-Request-ID.
+type Actor struct {
+	Name      string
+	UserID    int
+	Role      string
+	RequestID string
+}
+
+type AuditSearchRequest struct {
+	Entity   string `validate:"required_with=EntityID,omitempty,oneof=book"`
+	EntityID int    `validate:"omitempty,min=1"`
+	From     *time.Time
+	To       *time.Time
+	Page     int `validate:"omitempty,min=1"`
+	PageSize int `validate:"omitempty,min=1,max=100"`
+}
+type AuditListResponse struct {
+	Message  string      `json:"message"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Data     []AuditData `json:"data"`
+}
+type AuditData struct {
+	ID        int             `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	ActorID   int             `json:"actor_id,omitempty"`
+	ActorRole string          `json:"actor_role,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreateAt  string          `json:"create_at"`
 }
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// Create implements ApiKeyRepository.
+func (a apiKeyRepository) Create(apiKey models.ApiKeyRepository) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Create(&apiKey)
+		if db.Error != nil {
+			return db.Error
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindAll implements ApiKeyRepository.
+func (a apiKeyRepository) FindAll() ([]models.ApiKeyRepository, error) {
+	apiKeyList := []models.ApiKeyRepository{}
+	db := a.db.Order("id asc").Find(&apiKeyList)
+	if db.Error != nil {
+		return apiKeyList, db.Error
+	}
+	return apiKeyList, nil
+}
+
+// FindByID implements ApiKeyRepository.
+func (a apiKeyRepository) FindByID(id int) (models.ApiKeyRepository, error) {
+	apiKeyRepoResp := models.ApiKeyRepository{}
+	db := a.db.Where("id = ?", id).First(&apiKeyRepoResp)
+	if db.Error != nil {
+		return apiKeyRepoResp, db.Error
+	}
+	return apiKeyRepoResp, nil
+}
+
+// FindByHash implements ApiKeyRepository.
+func (a apiKeyRepository) FindByHash(keyHash string) (models.ApiKeyRepository, error) {
+	apiKeyRepoResp := models.ApiKeyRepository{}
+	db := a.db.Where("key_hash = ?", keyHash).First(&apiKeyRepoResp)
+	if db.Error != nil {
+		return apiKeyRepoResp, db.Error
+	}
+	return apiKeyRepoResp, nil
+}
+
+// Revoke implements ApiKeyRepository.
+func (a apiKeyRepository) Revoke(id int, revokedAt time.Time) error {
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.ApiKeyRepository{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", revokedAt)
+		if db.Error != nil {
+			return db.Error
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func NewApiKeyRepository(db *gorm.DB) ApiKeyRepository {
+	return apiKeyRepository{db: db}
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockApiKeyRepository struct {
+	mock.Mock
+}
+
+func (mockApiKeyRepo *mockApiKeyRepository) Create(apiKey models.ApiKeyRepository) error {
+	args := mockApiKeyRepo.Called()
+	return args.Error(0)
+}
+func (mockApiKeyRepo *mockApiKeyRepository) FindAll() ([]models.ApiKeyRepository, error) {
+	args := mockApiKeyRepo.Called()
+	return args.Get(0).([]models.ApiKeyRepository), args.Error(1)
+}
+func (mockApiKeyRepo *mockApiKeyRepository) FindByID(id int) (models.ApiKeyRepository, error) {
+	args := mockApiKeyRepo.Called()
+	return args.Get(0).(models.ApiKeyRepository), args.Error(1)
+}
+func (mockApiKeyRepo *mockApiKeyRepository) FindByHash(keyHash string) (models.ApiKeyRepository, error) {
+	args := mockApiKeyRepo.Called()
+	return args.Get(0).(models.ApiKeyRepository), args.Error(1)
+}
+func (mockApiKeyRepo *mockApiKeyRepository) Revoke(id int, revokedAt time.Time) error {
+	args := mockApiKeyRepo.Called()
+	return args.Error(0)
+}
+func NewApiKeyRepositoryMock() *mockApiKeyRepository {
+	return &mockApiKeyRepository{}
+}
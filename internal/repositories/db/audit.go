@@ -0,0 +1,67 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// Create implements AuditRepository.
+func (a auditRepository) Create(entry models.AuditRepository) error {
+	db := a.db.Create(&entry)
+	if db.Error != nil {
+		return db.Error
+	}
+	return nil
+}
+
+// FindAll implements AuditRepository.
+// Entries come newest first; the total counts every match, not only the page.
+func (a auditRepository) FindAll(filter models.AuditFilter) ([]models.AuditRepository, int64, error) {
+	auditList := []models.AuditRepository{}
+	query := a.db.Model(&models.AuditRepository{})
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.From != nil {
+		query = query.Where("create_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("create_at < ?", *filter.To)
+	}
+	query = query.Session(&gorm.Session{})
+	var total int64
+	db := query.Count(&total)
+	if db.Error != nil {
+		return auditList, 0, db.Error
+	}
+	query = query.Order("create_at desc, id desc")
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	db = query.Find(&auditList)
+	if db.Error != nil {
+		return auditList, 0, db.Error
+	}
+	return auditList, total, nil
+}
+
+// recordAudit writes the entry audit builds for book with tx; a nil audit
+// writes none.
+func recordAudit(tx *gorm.DB, audit AuditEntry, book models.BookRepository) error {
+	if audit == nil {
+		return nil
+	}
+	return auditRepository{db: tx}.Create(audit(book))
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return auditRepository{db: db}
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockAuditRepository struct {
+	mock.Mock
+}
+
+func (mockAuditRepo *mockAuditRepository) Create(entry models.AuditRepository) error {
+	args := mockAuditRepo.Called()
+	return args.Error(0)
+}
+func (mockAuditRepo *mockAuditRepository) FindAll(filter models.AuditFilter) ([]models.AuditRepository, int64, error) {
+	args := mockAuditRepo.Called()
+	return args.Get(0).([]models.AuditRepository), args.Get(1).(int64), args.Error(2)
+}
+func NewAuditRepositoryMock() *mockAuditRepository {
+	return &mockAuditRepository{}
+}
@@ -0,0 +1,92 @@
+package db_test
+
+import (
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAuditRepositoryFindAll(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewAuditRepository(DB)
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, entityID := range []int{1, 2, 1} {
+			require.NoError(t, DB.Create(&models.AuditRepository{
+				Entity:   models.AuditEntityBook,
+				EntityID: entityID,
+				Action:   models.AuditActionUpdate,
+				Actor:    "librarian1",
+				Before:   `{"title":"title test1"}`,
+				After:    `{"title":"title test2"}`,
+				CreateAt: start.Add(time.Duration(i) * time.Hour),
+			}).Error)
+		}
+		from, to := start.Add(time.Hour), start.Add(3*time.Hour)
+
+		testCases := []struct {
+			name        string
+			filter      models.AuditFilter
+			expectIDs   []int
+			expectTotal int64
+		}{
+			{"all newest first", models.AuditFilter{}, []int{3, 2, 1}, 3},
+			{"entity", models.AuditFilter{Entity: models.AuditEntityBook, EntityID: 1}, []int{3, 1}, 2},
+			{"time range", models.AuditFilter{From: &from, To: &to}, []int{3, 2}, 2},
+			{"page", models.AuditFilter{Offset: 1, Limit: 1}, []int{2}, 3},
+		}
+		for _, tC := range testCases {
+			t.Run(tC.name, func(t *testing.T) {
+				entries, total, err := repo.FindAll(tC.filter)
+				require.NoError(t, err)
+				ids := []int{}
+				for _, entry := range entries {
+					ids = append(ids, entry.ID)
+				}
+				assert.Equal(t, tC.expectIDs, ids)
+				assert.Equal(t, tC.expectTotal, total)
+			})
+		}
+	})
+}
+
+func TestAuditRecordedWithChange(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		audit := func(book models.BookRepository) models.AuditRepository {
+			return models.AuditRepository{Entity: models.AuditEntityBook, EntityID: book.ID, Action: models.AuditActionCreate, Actor: "librarian1", Before: "null", After: "{}"}
+		}
+		book := newBook("title test1", "author test1", "category test1")
+		require.NoError(t, repo.Create(&book, audit))
+		entries, _, err := db.NewAuditRepository(DB).FindAll(models.AuditFilter{})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, book.ID, entries[0].EntityID)
+
+		// an entry that cannot be written takes the change back with it
+		bookCopy := models.CopyRepository{BookID: book.ID, Barcode: "barcode test1"}
+		require.NoError(t, DB.Create(&bookCopy).Error)
+		taken := func(book models.BookRepository) models.AuditRepository {
+			entry := audit(book)
+			entry.ID = entries[0].ID
+			return entry
+		}
+		now := time.Now()
+		err = repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopy.ID, MemberID: 1, BorrowedAt: now, DueAt: now}, 0, 0, taken)
+		assert.Error(t, err)
+		require.NoError(t, DB.First(&bookCopy, bookCopy.ID).Error)
+		assert.False(t, bookCopy.IsBorrowed)
+		var loans int64
+		require.NoError(t, DB.Model(&models.LoanRepository{}).Count(&loans).Error)
+		assert.Equal(t, int64(0), loans)
+		stored := models.BookRepository{}
+		require.NoError(t, DB.First(&stored, book.ID).Error)
+		assert.Equal(t, 0, stored.BorrowCount)
+	})
+}
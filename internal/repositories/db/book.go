@@ -1,24 +1,72 @@
 package db
 
 import (
-	"fmt"
+	"strings"
 	"test-exam-forviz/internal/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// copyCountColumns fills the read-only copy aggregates of models.BookRepository.
+const copyCountColumns = "(SELECT COUNT(*) FROM copy_repositories WHERE copy_repositories.book_id = book_repositories.id) AS total_copies, " +
+	"(SELECT COUNT(*) FROM copy_repositories WHERE copy_repositories.book_id = book_repositories.id AND NOT copy_repositories.is_borrowed AND copy_repositories.reserved_for = 0) AS available_copies"
+
+const hasCopiesCondition = "EXISTS (SELECT 1 FROM copy_repositories WHERE copy_repositories.book_id = book_repositories.id)"
+const hasAvailableCopyCondition = "EXISTS (SELECT 1 FROM copy_repositories WHERE copy_repositories.book_id = book_repositories.id AND NOT copy_repositories.is_borrowed AND copy_repositories.reserved_for = 0)"
+
+// bookSortColumns maps the sort names accepted by FindAll to their columns.
+var bookSortColumns = map[string]string{
+	"id":               "book_repositories.id",
+	"title":            "book_repositories.title",
+	"author":           "book_repositories.author",
+	"category":         "book_repositories.category",
+	"borrow_count":     "book_repositories.borrow_count",
+	"available_copies": "available_copies",
+	"create_at":        "book_repositories.create_at",
+	"update_at":        "book_repositories.update_at",
+}
+
 type bookRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	fullText bool
 }
 
 // BorrowBook implements BookRepository.
-func (b bookRepository) BorrowBook(id, count int) error {
+// The copy is only lent while it is free or set aside for loan.MemberID,
+// otherwise ErrCopyUnavailable. A holdID other than 0 marks the member's hold
+// on the title as fulfilled. A version other than 0 must still be the book's.
+func (b bookRepository) BorrowBook(loan models.LoanRepository, version, holdID int, audit AuditEntry) error {
 	err := b.db.Transaction(func(tx *gorm.DB) error {
-		db := tx.Model(&models.BookRepository{}).Where("id=?", id).Update("is_borrowed", true).Update("borrow_count", count)
+		db := tx.Model(&models.CopyRepository{}).
+			Where("id = ? AND book_id = ? AND is_borrowed = ? AND reserved_for IN (0, ?)", loan.CopyID, loan.BookID, false, loan.MemberID).
+			Updates(map[string]interface{}{
+				"is_borrowed":  true,
+				"borrower_id":  loan.MemberID,
+				"reserved_for": 0,
+			})
 		if db.Error != nil {
 			return db.Error
 		}
-		return nil
+		if db.RowsAffected == 0 {
+			return ErrCopyUnavailable
+		}
+		if holdID != 0 {
+			db = tx.Model(&models.HoldRepository{}).Where("id = ?", holdID).Update("status", models.HoldStatusFulfilled)
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+		err := bumpBookVersion(tx, loan.BookID, version, map[string]interface{}{"borrow_count": gorm.Expr("borrow_count + 1")})
+		if err != nil {
+			return err
+		}
+		db = tx.Create(&loan)
+		if db.Error != nil {
+			return db.Error
+		}
+		return recordAudit(tx, audit, models.BookRepository{ID: loan.BookID})
 	})
 
 	if err != nil {
@@ -28,12 +76,35 @@ func (b bookRepository) BorrowBook(id, count int) error {
 }
 
 // Create implements BookRepository.
-func (b bookRepository) Create(book models.BookRepository) error {
+// book gets its ID filled in.
+func (b bookRepository) Create(book *models.BookRepository, audit AuditEntry) error {
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Create(book)
+		if db.Error != nil {
+			return db.Error
+		}
+		return recordAudit(tx, audit, *book)
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateBatch implements BookRepository.
+// The books are written in one transaction and get their IDs filled in.
+func (b bookRepository) CreateBatch(books []models.BookRepository, audit AuditEntry) error {
 	err := b.db.Transaction(func(tx *gorm.DB) error {
-		db := tx.Create(&book)
+		db := tx.Create(&books)
 		if db.Error != nil {
 			return db.Error
 		}
+		for _, book := range books {
+			if err := recordAudit(tx, audit, book); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
@@ -44,13 +115,27 @@ func (b bookRepository) Create(book models.BookRepository) error {
 }
 
 // Delete implements BookRepository.
-func (b bookRepository) Delete(id int) error {
+// The book is only moved to the trash; its copies stay for a restore. A book
+// with a copy on loan is not, ErrBookBorrowed; the check is part of the
+// delete, so a borrow committing meanwhile cannot slip in between.
+func (b bookRepository) Delete(id int, audit AuditEntry) error {
 	err := b.db.Transaction(func(tx *gorm.DB) error {
-		db := tx.Where("id = ?", id).Delete(&models.BookRepository{})
+		borrowed := tx.Model(&models.CopyRepository{}).Select("1").Where("book_id = ? AND is_borrowed = ?", id, true)
+		db := tx.Where("id = ? AND NOT EXISTS (?)", id, borrowed).Delete(&models.BookRepository{})
 		if db.Error != nil {
 			return db.Error
 		}
-		return nil
+		if db.RowsAffected == 0 {
+			var found int64
+			if err := tx.Model(&models.BookRepository{}).Where("id = ?", id).Count(&found).Error; err != nil {
+				return err
+			}
+			if found > 0 {
+				return ErrBookBorrowed
+			}
+			return gorm.ErrRecordNotFound
+		}
+		return recordAudit(tx, audit, models.BookRepository{ID: id})
 	})
 
 	if err != nil {
@@ -59,34 +144,220 @@ func (b bookRepository) Delete(id int) error {
 	return nil
 }
 
+// Restore implements BookRepository.
+func (b bookRepository) Restore(id int, audit AuditEntry) error {
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Unscoped().Model(&models.BookRepository{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordAudit(tx, audit, models.BookRepository{ID: id})
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Purge implements BookRepository.
+// Books moved to the trash before before are removed with their copies, holds,
+// returned loans and paid fines; the ids of the books removed are returned.
+// A book with a loan still out or a fine still unpaid stays in the trash until
+// they are settled, so no member record points at a missing book.
+func (b bookRepository) Purge(before time.Time, audit AuditEntry) ([]int, error) {
+	ids := []int{}
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Unscoped().Model(&models.BookRepository{}).
+			Where("deleted_at < ?", before).
+			Where("id NOT IN (?)", tx.Model(&models.LoanRepository{}).Select("book_id").Where("returned_at IS NULL")).
+			Where("id NOT IN (?)", tx.Model(&models.FineRepository{}).Select("book_id").Where("paid_at IS NULL")).
+			Pluck("id", &ids)
+		if db.Error != nil {
+			return db.Error
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for _, model := range []interface{}{&models.FineRepository{}, &models.LoanRepository{}, &models.HoldRepository{}, &models.CopyRepository{}} {
+			db = tx.Where("book_id IN ?", ids).Delete(model)
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+		db = tx.Unscoped().Where("id IN ?", ids).Delete(&models.BookRepository{})
+		if db.Error != nil {
+			return db.Error
+		}
+		for _, id := range ids {
+			if err := recordAudit(tx, audit, models.BookRepository{ID: id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return []int{}, err
+	}
+	return ids, nil
+}
+
 // FindAll implements BookRepository.
-func (b bookRepository) FindAll(title, author, category, sortName, sortType string) ([]models.BookRepository, error) {
+// The total counts every match, not only the page in Offset and Limit. With
+// Fragments the books holding the most of them come first, ahead of any sort,
+// so a Limit keeps the likeliest matches.
+func (b bookRepository) FindAll(filter models.BookFilter) ([]models.BookRepository, int64, error) {
+	bookList := []models.BookRepository{}
+	query, ranked := b.filterBooks(filter)
+	query = query.Session(&gorm.Session{})
+	var total int64
+	db := query.Count(&total)
+	if db.Error != nil {
+		return bookList, 0, db.Error
+	}
+	if len(filter.Fragments) > 0 {
+		hits, args := fragmentHits(filter.Fragments)
+		query = query.Select("book_repositories.*, "+copyCountColumns+", "+hits+" AS fragment_hits", args...).
+			Order("fragment_hits DESC")
+	} else {
+		query = query.Select("book_repositories.*, " + copyCountColumns)
+	}
+	// only whitelisted columns reach ORDER BY; id breaks ties so pages do not overlap
+	if column, ok := bookSortColumns[filter.SortName]; ok {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: column, Raw: true}, Desc: filter.SortType == "desc"})
+	} else if ranked {
+		// bm25 is lower for better matches
+		query = query.Order("bm25(book_search)")
+	}
+	query = query.Order("book_repositories.id asc")
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	db = query.Find(&bookList)
+	if db.Error != nil {
+		return bookList, 0, db.Error
+	}
+	return bookList, total, nil
+}
+
+// fragmentCondition holds when a book's title, author or category contains
+// the fragment given three times.
+const fragmentCondition = "LOWER(book_repositories.title) LIKE LOWER(?) OR LOWER(book_repositories.author) LIKE LOWER(?) OR LOWER(book_repositories.category) LIKE LOWER(?)"
+
+// fragmentHits counts how many of fragments a book contains.
+func fragmentHits(fragments [][]string) (string, []interface{}) {
+	terms, args := []string{"0"}, []interface{}{}
+	for _, group := range fragments {
+		for _, fragment := range group {
+			like := "%" + fragment + "%"
+			terms = append(terms, "CASE WHEN "+fragmentCondition+" THEN 1 ELSE 0 END")
+			args = append(args, like, like, like)
+		}
+	}
+	return "(" + strings.Join(terms, " + ") + ")", args
+}
+
+// FindFacets implements BookRepository.
+// One query counts the books matching filter per category, per author and
+// per borrowed state; Offset, Limit and sorting are ignored.
+func (b bookRepository) FindFacets(filter models.BookFilter) ([]models.BookFacetRepository, error) {
+	facetList := []models.BookFacetRepository{}
+	query, _ := b.filterBooks(filter)
+	// the labels are constants written into the SQL, so no server has to guess
+	// the type of a placeholder in the select lists of a CTE and a UNION
+	matches := query.Select("book_repositories.category, book_repositories.author, " +
+		"CASE WHEN " + hasCopiesCondition + " AND NOT " + hasAvailableCopyCondition + " THEN '" + models.BookBorrowed + "' ELSE '" + models.BookAvailable + "' END AS availability")
+	db := b.db.Raw(`WITH matches AS (?)
+		SELECT '`+models.BookFacetCategory+`' AS facet, category AS value, COUNT(*) AS count FROM matches GROUP BY category
+		UNION ALL SELECT '`+models.BookFacetAuthor+`', author, COUNT(*) FROM matches GROUP BY author
+		UNION ALL SELECT '`+models.BookFacetAvailability+`', availability, COUNT(*) FROM matches GROUP BY availability
+		ORDER BY facet, count DESC, value`,
+		matches).Scan(&facetList)
+	if db.Error != nil {
+		return facetList, db.Error
+	}
+	return facetList, nil
+}
+
+// FindInBatches implements BookRepository.
+// fn gets the matching books batchSize at a time in id order, so the whole
+// catalog is never in memory and no read stays open between batches.
+func (b bookRepository) FindInBatches(filter models.BookFilter, batchSize int, fn func(books []models.BookRepository) error) error {
 	bookList := []models.BookRepository{}
-	query := b.db
-	if title != "" {
-		query = query.Where("title LIKE ?", "%"+title+"%")
+	query, _ := b.filterBooks(filter)
+	db := query.Select("book_repositories.*, "+copyCountColumns).FindInBatches(&bookList, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(bookList)
+	})
+	if db.Error != nil {
+		return db.Error
+	}
+	return nil
+}
 
+// filterBooks narrows book_repositories to the books matching filter.
+// ranked reports whether the full-text index can order the matches.
+func (b bookRepository) filterBooks(filter models.BookFilter) (*gorm.DB, bool) {
+	query := b.db.Model(&models.BookRepository{})
+	ranked := false
+	if strings.TrimSpace(filter.Query) != "" {
+		query, ranked = b.whereBookQuery(query, filter.Query)
 	}
-	if author != "" {
-		query = query.Where("author LIKE %?%", "%"+author+"%")
+	// LOWER on both sides: LIKE ignores case on sqlite and mysql, not on postgres
+	if filter.Title != "" {
+		query = query.Where("LOWER(book_repositories.title) LIKE LOWER(?)", "%"+filter.Title+"%")
 
 	}
-	if category != "" {
-		query = query.Where("category LIKE %?%", "%"+category+"%")
+	if filter.Author != "" {
+		query = query.Where("LOWER(book_repositories.author) LIKE LOWER(?)", "%"+filter.Author+"%")
 
 	}
-	if sortName != "" && sortType != "" {
+	if filter.Category != "" {
+		query = query.Where("LOWER(book_repositories.category) LIKE LOWER(?)", "%"+filter.Category+"%")
 
-		switch sortType {
-		case "asc":
-			query = query.Order(fmt.Sprintf("%s asc", sortName))
-			break
-		case "desc":
-			query = query.Order(fmt.Sprintf("%s desc", sortName))
-			break
+	}
+	for _, fragments := range filter.Fragments {
+		conditions, args := []string{}, []interface{}{}
+		for _, fragment := range fragments {
+			like := "%" + fragment + "%"
+			conditions = append(conditions, fragmentCondition)
+			args = append(args, like, like, like)
+		}
+		if len(conditions) > 0 {
+			query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+		}
+	}
+	if filter.IsBorrowed != nil {
+		if *filter.IsBorrowed {
+			query = query.Where(hasCopiesCondition + " AND NOT " + hasAvailableCopyCondition)
+		} else {
+			query = query.Where("(NOT " + hasCopiesCondition + " OR " + hasAvailableCopyCondition + ")")
 		}
 	}
-	db := query.Find(&bookList)
+	if filter.MinBorrowCount != nil {
+		query = query.Where("book_repositories.borrow_count >= ?", *filter.MinBorrowCount)
+	}
+	if filter.MaxBorrowCount != nil {
+		query = query.Where("book_repositories.borrow_count <= ?", *filter.MaxBorrowCount)
+	}
+	return query, ranked
+}
+
+// FindMostBorrowed implements BookRepository.
+// borrow_count is derived from the loan history rather than the counter column.
+func (b bookRepository) FindMostBorrowed() ([]models.BookRepository, error) {
+	bookList := []models.BookRepository{}
+	db := b.db.Model(&models.BookRepository{}).
+		Select("book_repositories.id, book_repositories.title, book_repositories.author, book_repositories.category, " +
+			"book_repositories.version, book_repositories.update_at, book_repositories.create_at, " + copyCountColumns + ", " +
+			"COUNT(loan_repositories.id) AS borrow_count").
+		Joins("LEFT JOIN loan_repositories ON loan_repositories.book_id = book_repositories.id").
+		Group("book_repositories.id").
+		Order("borrow_count desc").
+		Find(&bookList)
 	if db.Error != nil {
 		return bookList, db.Error
 	}
@@ -96,21 +367,99 @@ func (b bookRepository) FindAll(title, author, category, sortName, sortType stri
 // FindByID implements BookRepository.
 func (b bookRepository) FindByID(id int) (models.BookRepository, error) {
 	bookRepoResp := models.BookRepository{}
-	db := b.db.Where("id = ?", id).First(&bookRepoResp)
+	db := b.db.Model(&models.BookRepository{}).Select("book_repositories.*, "+copyCountColumns).Where("id = ?", id).First(&bookRepoResp)
 	if db.Error != nil {
 		return bookRepoResp, db.Error
 	}
 	return bookRepoResp, nil
 }
 
+// FindDeleted implements BookRepository.
+// Only Offset and Limit of filter apply; the latest deleted come first.
+func (b bookRepository) FindDeleted(filter models.BookFilter) ([]models.BookRepository, int64, error) {
+	bookList := []models.BookRepository{}
+	query := b.db.Unscoped().Model(&models.BookRepository{}).Where("book_repositories.deleted_at IS NOT NULL").Session(&gorm.Session{})
+	var total int64
+	db := query.Count(&total)
+	if db.Error != nil {
+		return bookList, 0, db.Error
+	}
+	query = query.Select("book_repositories.*, " + copyCountColumns).Order("book_repositories.deleted_at desc, book_repositories.id desc")
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	db = query.Find(&bookList)
+	if db.Error != nil {
+		return bookList, 0, db.Error
+	}
+	return bookList, total, nil
+}
+
+// FindByISBN implements BookRepository.
+// Books in the trash are found too, since their ISBNs stay taken.
+func (b bookRepository) FindByISBN(isbn string) (models.BookRepository, error) {
+	bookRepoResp := models.BookRepository{}
+	db := b.db.Unscoped().Model(&models.BookRepository{}).Select("book_repositories.*, "+copyCountColumns).Where("isbn = ?", isbn).First(&bookRepoResp)
+	if db.Error != nil {
+		return bookRepoResp, db.Error
+	}
+	return bookRepoResp, nil
+}
+
+// FindExistingISBNs implements BookRepository.
+// It returns the isbns some book, in the trash or not, already has.
+func (b bookRepository) FindExistingISBNs(isbns []string) ([]string, error) {
+	existing := []string{}
+	if len(isbns) == 0 {
+		return existing, nil
+	}
+	db := b.db.Unscoped().Model(&models.BookRepository{}).Where("isbn IN ?", isbns).Pluck("isbn", &existing)
+	if db.Error != nil {
+		return existing, db.Error
+	}
+	return existing, nil
+}
+
 // ReturnBook implements BookRepository.
-func (b bookRepository) ReturnBook(id int) error {
+// The copy must be lent, otherwise ErrCopyNotBorrowed, and then goes to the
+// next member waiting in the title's hold queue. A version other than 0 must
+// still be the book's.
+func (b bookRepository) ReturnBook(copyID, version int, returnedAt time.Time, fine *models.FineRepository, holdExpireAt time.Time, audit AuditEntry) error {
 	err := b.db.Transaction(func(tx *gorm.DB) error {
-		db := tx.Model(&models.BookRepository{}).Where("id=?", id).Update("is_borrowed", false)
+		db := tx.Model(&models.CopyRepository{}).Where("id = ? AND is_borrowed = ?", copyID, true).Updates(map[string]interface{}{
+			"is_borrowed": false,
+			"borrower_id": 0,
+		})
 		if db.Error != nil {
 			return db.Error
 		}
-		return nil
+		if db.RowsAffected == 0 {
+			return ErrCopyNotBorrowed
+		}
+		db = tx.Model(&models.LoanRepository{}).Where("copy_id = ? AND returned_at IS NULL", copyID).Update("returned_at", returnedAt)
+		if db.Error != nil {
+			return db.Error
+		}
+		if fine != nil {
+			db = tx.Create(fine)
+			if db.Error != nil {
+				return db.Error
+			}
+		}
+		bookCopy := models.CopyRepository{}
+		db = tx.Where("id = ?", copyID).First(&bookCopy)
+		if db.Error != nil {
+			return db.Error
+		}
+		err := bumpBookVersion(tx, bookCopy.BookID, version, map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		err = advanceHoldQueue(tx, bookCopy.BookID, returnedAt, holdExpireAt)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, audit, models.BookRepository{ID: bookCopy.BookID})
 	})
 
 	if err != nil {
@@ -120,12 +469,21 @@ func (b bookRepository) ReturnBook(id int) error {
 }
 
 // Update implements BookRepository.
-func (b bookRepository) Update(req models.BookRepository) error {
+// Only the catalog fields change, and only while the book is still at
+// req.Version; a nil ISBN removes the current one.
+func (b bookRepository) Update(req models.BookRepository, audit AuditEntry) error {
 	err := b.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("id", req.ID).Updates(&req).Error; err != nil {
+		updates := map[string]interface{}{
+			"title":    req.Title,
+			"author":   req.Author,
+			"category": req.Category,
+			"isbn":     req.ISBN,
+		}
+		err := bumpBookVersion(tx, req.ID, req.Version, updates)
+		if err != nil {
 			return err
 		}
-		return nil
+		return recordAudit(tx, audit, req)
 	})
 	if err != nil {
 		return err
@@ -133,6 +491,30 @@ func (b bookRepository) Update(req models.BookRepository) error {
 	return nil
 }
 
+// bumpBookVersion applies updates to book id and moves it to the next
+// version, provided it is still at version; version 0 skips the check.
+func bumpBookVersion(tx *gorm.DB, id, version int, updates map[string]interface{}) error {
+	updates["version"] = gorm.Expr("version + 1")
+	query := tx.Model(&models.BookRepository{}).Where("id = ?", id)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	db := query.Updates(updates)
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// touchBook moves book id to its next version after a write to its copies or
+// hold queue changed the availability it reports, so its ETag changes too.
+func touchBook(tx *gorm.DB, id int) error {
+	return tx.Unscoped().Model(&models.BookRepository{}).Where("id = ?", id).Update("version", gorm.Expr("version + 1")).Error
+}
+
 func NewBookRepository(db *gorm.DB) BookRepository {
-	return bookRepository{db: db}
+	return bookRepository{db: db, fullText: db.Migrator().HasTable(bookSearchTable)}
 }
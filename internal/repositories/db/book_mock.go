@@ -2,6 +2,7 @@ package db
 
 import (
 	"test-exam-forviz/internal/models"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,31 +11,70 @@ type mockBookRepository struct {
 	mock.Mock
 }
 
-func (mockBookRepo *mockBookRepository) Create(book models.BookRepository) error {
+func (mockBookRepo *mockBookRepository) Create(book *models.BookRepository, audit AuditEntry) error {
 	args := mockBookRepo.Called()
 	return args.Error(0)
 }
-func (mockBookRepo *mockBookRepository) Update(book models.BookRepository) error {
+func (mockBookRepo *mockBookRepository) CreateBatch(books []models.BookRepository, audit AuditEntry) error {
 	args := mockBookRepo.Called()
 	return args.Error(0)
 }
-func (mockBookRepo *mockBookRepository) Delete(id int) error {
+func (mockBookRepo *mockBookRepository) Update(book models.BookRepository, audit AuditEntry) error {
 	args := mockBookRepo.Called()
 	return args.Error(0)
 }
+func (mockBookRepo *mockBookRepository) Delete(id int, audit AuditEntry) error {
+	args := mockBookRepo.Called()
+	return args.Error(0)
+}
+func (mockBookRepo *mockBookRepository) Restore(id int, audit AuditEntry) error {
+	args := mockBookRepo.Called()
+	return args.Error(0)
+}
+func (mockBookRepo *mockBookRepository) Purge(before time.Time, audit AuditEntry) ([]int, error) {
+	args := mockBookRepo.Called()
+	return args.Get(0).([]int), args.Error(1)
+}
 func (mockBookRepo *mockBookRepository) FindByID(id int) (models.BookRepository, error) {
 	args := mockBookRepo.Called()
 	return args.Get(0).(models.BookRepository), args.Error(1)
 }
-func (mockBookRepo *mockBookRepository) FindAll(title, author, category, sortName, sortType string) ([]models.BookRepository, error) {
+func (mockBookRepo *mockBookRepository) FindByISBN(isbn string) (models.BookRepository, error) {
+	args := mockBookRepo.Called()
+	return args.Get(0).(models.BookRepository), args.Error(1)
+}
+func (mockBookRepo *mockBookRepository) FindExistingISBNs(isbns []string) ([]string, error) {
+	args := mockBookRepo.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+func (mockBookRepo *mockBookRepository) FindAll(filter models.BookFilter) ([]models.BookRepository, int64, error) {
+	args := mockBookRepo.Called()
+	return args.Get(0).([]models.BookRepository), args.Get(1).(int64), args.Error(2)
+}
+func (mockBookRepo *mockBookRepository) FindDeleted(filter models.BookFilter) ([]models.BookRepository, int64, error) {
+	args := mockBookRepo.Called()
+	return args.Get(0).([]models.BookRepository), args.Get(1).(int64), args.Error(2)
+}
+func (mockBookRepo *mockBookRepository) FindFacets(filter models.BookFilter) ([]models.BookFacetRepository, error) {
+	args := mockBookRepo.Called()
+	return args.Get(0).([]models.BookFacetRepository), args.Error(1)
+}
+func (mockBookRepo *mockBookRepository) FindInBatches(filter models.BookFilter, batchSize int, fn func(books []models.BookRepository) error) error {
+	args := mockBookRepo.Called()
+	if err := args.Error(1); err != nil {
+		return err
+	}
+	return fn(args.Get(0).([]models.BookRepository))
+}
+func (mockBookRepo *mockBookRepository) FindMostBorrowed() ([]models.BookRepository, error) {
 	args := mockBookRepo.Called()
 	return args.Get(0).([]models.BookRepository), args.Error(1)
 }
-func (mockBookRepo *mockBookRepository) BorrowBook(id, count int) error {
+func (mockBookRepo *mockBookRepository) BorrowBook(loan models.LoanRepository, version, holdID int, audit AuditEntry) error {
 	args := mockBookRepo.Called()
 	return args.Error(0)
 }
-func (mockBookRepo *mockBookRepository) ReturnBook(id int) error {
+func (mockBookRepo *mockBookRepository) ReturnBook(copyID, version int, returnedAt time.Time, fine *models.FineRepository, holdExpireAt time.Time, audit AuditEntry) error {
 	args := mockBookRepo.Called()
 	return args.Error(0)
 }
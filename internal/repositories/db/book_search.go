@@ -0,0 +1,65 @@
+package db
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// bookSearchTable is an FTS5 index over the title, author and category of
+// book_repositories. Triggers keep it in sync, so only the DDL lives here.
+const bookSearchTable = "book_search"
+
+var bookSearchDDL = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS book_search USING fts5(title, author, category,
+		content='book_repositories', content_rowid='id', tokenize='unicode61 remove_diacritics 2')`,
+	`CREATE TRIGGER IF NOT EXISTS book_search_ai AFTER INSERT ON book_repositories BEGIN
+		INSERT INTO book_search(rowid, title, author, category) VALUES (new.id, new.title, new.author, new.category);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS book_search_ad AFTER DELETE ON book_repositories BEGIN
+		INSERT INTO book_search(book_search, rowid, title, author, category) VALUES ('delete', old.id, old.title, old.author, old.category);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS book_search_au AFTER UPDATE OF title, author, category ON book_repositories BEGIN
+		INSERT INTO book_search(book_search, rowid, title, author, category) VALUES ('delete', old.id, old.title, old.author, old.category);
+		INSERT INTO book_search(rowid, title, author, category) VALUES (new.id, new.title, new.author, new.category);
+	END`,
+}
+
+// MigrateBookSearch creates the full-text index and its triggers, then
+// rebuilds the index so books written before it existed are searchable.
+// It fails when sqlite was built without FTS5 (the sqlite_fts5 build tag);
+// FindAll then falls back to LIKE matching.
+func MigrateBookSearch(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, ddl := range bookSearchDDL {
+			if err := tx.Exec(ddl).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("INSERT INTO book_search(book_search) VALUES ('rebuild')").Error
+	})
+}
+
+// bookSearchMatch turns a free-text query into an FTS5 expression where
+// every word must match as a prefix of some title, author or category word.
+func bookSearchMatch(q string) string {
+	terms := []string{}
+	for _, word := range strings.Fields(q) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// whereBookQuery narrows query to books matching every word of q.
+// ranked reports whether bm25 can order the results.
+func (b bookRepository) whereBookQuery(query *gorm.DB, q string) (result *gorm.DB, ranked bool) {
+	if b.fullText {
+		return query.Joins("JOIN book_search ON book_search.rowid = book_repositories.id").
+			Where("book_search MATCH ?", bookSearchMatch(q)), true
+	}
+	for _, word := range strings.Fields(q) {
+		like := "%" + word + "%"
+		query = query.Where("(LOWER(book_repositories.title) LIKE LOWER(?) OR LOWER(book_repositories.author) LIKE LOWER(?) OR LOWER(book_repositories.category) LIKE LOWER(?))", like, like, like)
+	}
+	return query, false
+}
@@ -0,0 +1,104 @@
+//go:build sqlite_fts5
+
+package db_test
+
+import (
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// eachFullText runs fn on the sqlite backend with the full-text index in
+// place; the other backends have no FTS5.
+func eachFullText(t *testing.T, fn func(t *testing.T, DB *gorm.DB, repo db.BookRepository)) {
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		if DB.Dialector.Name() != db.DriverSqlite {
+			t.Skip("FTS5 is sqlite only")
+		}
+		require.NoError(t, db.MigrateBookSearch(DB))
+		fn(t, DB, db.NewBookRepository(DB))
+	})
+}
+
+// indexed returns the ids the full-text index itself matches for q, trashed
+// books included.
+func indexed(t *testing.T, DB *gorm.DB, q string) []int {
+	t.Helper()
+	ids := []int{}
+	require.NoError(t, DB.Raw("SELECT rowid FROM book_search WHERE book_search MATCH ? ORDER BY rowid", q).Scan(&ids).Error)
+	return ids
+}
+
+func TestBookSearchTriggers(t *testing.T) {
+
+	eachFullText(t, func(t *testing.T, DB *gorm.DB, repo db.BookRepository) {
+		// written before the index existed, picked up by the rebuild
+		before, _ := createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Science Fiction"), 0)
+		require.NoError(t, DB.Exec("DROP TABLE book_search").Error)
+		require.NoError(t, db.MigrateBookSearch(DB))
+		assert.Equal(t, []int{before.ID}, indexed(t, DB, "dune"))
+
+		book := newBook("The Hobbit", "J.R.R. Tolkien", "Fantasy")
+		require.NoError(t, repo.Create(&book, nil))
+		assert.Equal(t, []int{book.ID}, indexed(t, DB, "hobbit"))
+
+		require.NoError(t, repo.Update(models.BookRepository{ID: book.ID, Title: "The Silmarillion", Author: "J.R.R. Tolkien", Category: "Fantasy"}, nil))
+		assert.Empty(t, indexed(t, DB, "hobbit"))
+		assert.Equal(t, []int{book.ID}, indexed(t, DB, "silmarillion"))
+
+		// a trashed book stays indexed for a restore but is not found
+		require.NoError(t, repo.Delete(book.ID, nil))
+		assert.Equal(t, []int{book.ID}, indexed(t, DB, "silmarillion"))
+		books, total, err := repo.FindAll(models.BookFilter{Query: "silmarillion"})
+		require.NoError(t, err)
+		assert.Empty(t, books)
+		assert.Equal(t, int64(0), total)
+		require.NoError(t, repo.Restore(book.ID, nil))
+		books, _, err = repo.FindAll(models.BookFilter{Query: "silmarillion"})
+		require.NoError(t, err)
+		assert.Equal(t, []int{book.ID}, bookIDs(books))
+
+		require.NoError(t, repo.Delete(book.ID, nil))
+		_, err = repo.Purge(time.Now().Add(time.Hour), nil)
+		require.NoError(t, err)
+		assert.Empty(t, indexed(t, DB, "silmarillion"))
+		assert.Empty(t, indexed(t, DB, "tolkien"))
+	})
+}
+
+func TestBookSearchRanking(t *testing.T) {
+
+	eachFullText(t, func(t *testing.T, DB *gorm.DB, repo db.BookRepository) {
+		children, _ := createBookWithCopies(t, DB, newBook("Children of Dune", "Frank Herbert", "Science Fiction"), 0)
+		dune, _ := createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Dune Saga"), 0)
+		hobbit, _ := createBookWithCopies(t, DB, newBook("The Hobbit", "J.R.R. Tolkien", "Fantasy"), 0)
+		jane, _ := createBookWithCopies(t, DB, newBook("Jane Eyre", "Charlotte Brontë", "Classics"), 0)
+
+		testCases := []struct {
+			name      string
+			filter    models.BookFilter
+			expectIDs []int
+		}{
+			{"best match first", models.BookFilter{Query: "dune"}, []int{dune.ID, children.ID}},
+			{"sort overrides rank", models.BookFilter{Query: "dune", SortName: "id", SortType: "asc"}, []int{children.ID, dune.ID}},
+			{"prefix of every word", models.BookFilter{Query: "tolk hob"}, []int{hobbit.ID}},
+			{"prefix across columns", models.BookFilter{Query: "her scien"}, []int{children.ID}},
+			{"not a word prefix", models.BookFilter{Query: "olkien"}, []int{}},
+			{"diacritics ignored", models.BookFilter{Query: "bronte"}, []int{jane.ID}},
+			{"quotes are literal", models.BookFilter{Query: `"dune`}, []int{dune.ID, children.ID}},
+		}
+		for _, tC := range testCases {
+			t.Run(tC.name, func(t *testing.T) {
+				books, total, err := repo.FindAll(tC.filter)
+				require.NoError(t, err)
+				assert.Equal(t, tC.expectIDs, bookIDs(books))
+				assert.Equal(t, int64(len(tC.expectIDs)), total)
+			})
+		}
+	})
+}
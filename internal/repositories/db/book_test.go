@@ -0,0 +1,490 @@
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const concurrentRequests = 20
+
+func createBookWithCopies(t *testing.T, DB *gorm.DB, book models.BookRepository, copies int) (models.BookRepository, []models.CopyRepository) {
+	t.Helper()
+	book.Version = 1
+	require.NoError(t, DB.Create(&book).Error)
+	bookCopies := []models.CopyRepository{}
+	for i := 0; i < copies; i++ {
+		bookCopies = append(bookCopies, models.CopyRepository{BookID: book.ID, Barcode: fmt.Sprintf("B-%04d-%04d", book.ID, i+1), Condition: "new"})
+	}
+	if copies > 0 {
+		require.NoError(t, DB.Create(&bookCopies).Error)
+	}
+	return book, bookCopies
+}
+
+func newBook(title, author, category string) models.BookRepository {
+	return models.BookRepository{Title: title, Author: author, Category: category}
+}
+
+// race runs fn concurrentRequests times at once and returns each error.
+func race(fn func(i int) error) []error {
+	results := make([]error, concurrentRequests)
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrentRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i] = fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return results
+}
+
+func countErrors(t *testing.T, results []error, conflict error) (succeeded, conflicted int) {
+	t.Helper()
+	for _, err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, conflict):
+			conflicted++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	return succeeded, conflicted
+}
+
+func bookIDs(books []models.BookRepository) []int {
+	ids := []int{}
+	for _, book := range books {
+		ids = append(ids, book.ID)
+	}
+	return ids
+}
+
+func TestBorrowBookConcurrentSameCopy(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 1)
+
+		results := race(func(i int) error {
+			now := time.Now()
+			return repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[0].ID, MemberID: i + 1, BorrowedAt: now, DueAt: now}, 0, 0, nil)
+		})
+
+		succeeded, conflicted := countErrors(t, results, db.ErrCopyUnavailable)
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, concurrentRequests-1, conflicted)
+		var loans int64
+		require.NoError(t, DB.Model(&models.LoanRepository{}).Count(&loans).Error)
+		assert.Equal(t, int64(1), loans)
+		got, err := repo.FindByID(book.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got.BorrowCount)
+		assert.Equal(t, 2, got.Version)
+		assert.Equal(t, 0, got.AvailableCopies)
+	})
+}
+
+func TestBorrowBookConcurrentCopies(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), concurrentRequests)
+
+		results := race(func(i int) error {
+			now := time.Now()
+			return repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[i].ID, MemberID: i + 1, BorrowedAt: now, DueAt: now}, 0, 0, nil)
+		})
+
+		succeeded, _ := countErrors(t, results, db.ErrCopyUnavailable)
+		assert.Equal(t, concurrentRequests, succeeded)
+		got, err := repo.FindByID(book.ID)
+		require.NoError(t, err)
+		assert.Equal(t, concurrentRequests, got.BorrowCount)
+		assert.Equal(t, 1+concurrentRequests, got.Version)
+	})
+}
+
+func TestBorrowBookConcurrentVersion(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), concurrentRequests)
+
+		// every request read version 1, so only the first write may go through
+		results := race(func(i int) error {
+			now := time.Now()
+			return repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[i].ID, MemberID: i + 1, BorrowedAt: now, DueAt: now}, 1, 0, nil)
+		})
+
+		succeeded, conflicted := countErrors(t, results, db.ErrVersionConflict)
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, concurrentRequests-1, conflicted)
+		got, err := repo.FindByID(book.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got.BorrowCount)
+		assert.Equal(t, concurrentRequests-1, got.AvailableCopies)
+	})
+}
+
+func TestDeleteBookConcurrentBorrow(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), concurrentRequests)
+
+		// even requests delete the book, odd ones borrow a copy of it
+		results := race(func(i int) error {
+			if i%2 == 0 {
+				return repo.Delete(book.ID, nil)
+			}
+			now := time.Now()
+			return repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[i].ID, MemberID: i + 1, BorrowedAt: now, DueAt: now}, 0, 0, nil)
+		})
+
+		deleted, borrowed := 0, 0
+		for i, err := range results {
+			switch {
+			case err == nil && i%2 == 0:
+				deleted++
+			case err == nil:
+				borrowed++
+			case errors.Is(err, db.ErrBookBorrowed), errors.Is(err, db.ErrVersionConflict), errors.Is(err, gorm.ErrRecordNotFound):
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		// either the book went to the trash with every copy on the shelf, or
+		// it stays with its loans
+		assert.Equal(t, 1, deleted+min(borrowed, 1), "deleted %d, borrowed %d", deleted, borrowed)
+		var lent int64
+		require.NoError(t, DB.Model(&models.CopyRepository{}).Where("is_borrowed = ?", true).Count(&lent).Error)
+		assert.Equal(t, int64(borrowed), lent)
+	})
+}
+
+func TestDeleteBookBorrowed(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 1)
+		now := time.Now()
+		require.NoError(t, repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[0].ID, MemberID: 1, BorrowedAt: now, DueAt: now}, 0, 0, nil))
+
+		assert.ErrorIs(t, repo.Delete(book.ID, nil), db.ErrBookBorrowed)
+		_, err := repo.FindByID(book.ID)
+		require.NoError(t, err)
+		assert.ErrorIs(t, repo.Delete(book.ID+1, nil), gorm.ErrRecordNotFound)
+
+		require.NoError(t, repo.ReturnBook(bookCopies[0].ID, 0, now, nil, now, nil))
+		require.NoError(t, repo.Delete(book.ID, nil))
+	})
+}
+
+func TestReturnBookConcurrentSameCopy(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 1)
+		now := time.Now()
+		loan := models.LoanRepository{BookID: book.ID, CopyID: bookCopies[0].ID, MemberID: 1, BorrowedAt: now.AddDate(0, 0, -20), DueAt: now.AddDate(0, 0, -6)}
+		require.NoError(t, repo.BorrowBook(loan, 0, 0, nil))
+
+		results := race(func(i int) error {
+			fine := &models.FineRepository{LoanID: 1, MemberID: 1, BookID: book.ID, Amount: 30, DaysLate: 6}
+			return repo.ReturnBook(bookCopies[0].ID, 0, now, fine, now, nil)
+		})
+
+		succeeded, conflicted := countErrors(t, results, db.ErrCopyNotBorrowed)
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, concurrentRequests-1, conflicted)
+		var fines int64
+		require.NoError(t, DB.Model(&models.FineRepository{}).Count(&fines).Error)
+		assert.Equal(t, int64(1), fines)
+		got, err := repo.FindByID(book.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got.AvailableCopies)
+		assert.Equal(t, 3, got.Version)
+	})
+}
+
+func TestReturnBookAdvancesHoldQueue(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 1)
+		now := time.Now()
+		require.NoError(t, repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[0].ID, MemberID: 1, BorrowedAt: now, DueAt: now}, 0, 0, nil))
+		hold := models.HoldRepository{BookID: book.ID, MemberID: 2, Status: models.HoldStatusWaiting}
+		require.NoError(t, DB.Create(&hold).Error)
+
+		require.NoError(t, repo.ReturnBook(bookCopies[0].ID, 2, now, nil, now.Add(time.Hour), nil))
+
+		require.NoError(t, DB.First(&hold, hold.ID).Error)
+		assert.Equal(t, models.HoldStatusReady, hold.Status)
+		assert.Equal(t, bookCopies[0].ID, hold.CopyID)
+		bookCopy := models.CopyRepository{}
+		require.NoError(t, DB.First(&bookCopy, bookCopies[0].ID).Error)
+		assert.Equal(t, 2, bookCopy.ReservedFor)
+		// the copy is now only for member 2
+		err := repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopy.ID, MemberID: 3, BorrowedAt: now, DueAt: now}, 0, 0, nil)
+		assert.ErrorIs(t, err, db.ErrCopyUnavailable)
+		err = repo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopy.ID, MemberID: 2, BorrowedAt: now, DueAt: now}, 0, hold.ID, nil)
+		assert.NoError(t, err)
+	})
+}
+
+func TestBookVersionFollowsCopiesAndHolds(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		copyRepo, holdRepo := db.NewCopyRepository(DB), db.NewHoldRepository(DB)
+		book, _ := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 0)
+		version := func() int {
+			t.Helper()
+			stored := models.BookRepository{}
+			require.NoError(t, DB.First(&stored, book.ID).Error)
+			return stored.Version
+		}
+		now := time.Now()
+
+		hold := models.HoldRepository{BookID: book.ID, MemberID: 2, Status: models.HoldStatusWaiting}
+		require.NoError(t, holdRepo.Create(hold))
+		require.NoError(t, holdRepo.AdvanceQueue(book.ID, now, now.Add(time.Hour)))
+		assert.Equal(t, 1, version(), "nothing to set aside")
+
+		require.NoError(t, copyRepo.Create(models.CopyRepository{BookID: book.ID, Barcode: "barcode test1", Condition: "new"}))
+		assert.Equal(t, 2, version())
+		bookCopy, err := copyRepo.FindAvailableByBookID(book.ID)
+		require.NoError(t, err)
+		bookCopy.Condition = "worn"
+		require.NoError(t, copyRepo.Update(bookCopy))
+		assert.Equal(t, 3, version())
+
+		require.NoError(t, holdRepo.AdvanceQueue(book.ID, now, now.Add(time.Hour)))
+		assert.Equal(t, 4, version(), "the copy is set aside")
+		ready, err := holdRepo.FindActiveByMember(book.ID, 2)
+		require.NoError(t, err)
+		require.NoError(t, holdRepo.Cancel(ready, now, now.Add(time.Hour)))
+		assert.Equal(t, 5, version(), "the copy is released")
+	})
+}
+
+func TestBookRepositoryUpdate(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		book, _ := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 0)
+		isbn13 := "9780306406157"
+
+		err := repo.Update(models.BookRepository{ID: book.ID, Title: "title test2", Author: "author test2", Category: "category test2", ISBN: &isbn13, Version: 1}, nil)
+		require.NoError(t, err)
+		err = repo.Update(models.BookRepository{ID: book.ID, Title: "title test3", Author: "author test3", Category: "category test3", Version: 1}, nil)
+		assert.ErrorIs(t, err, db.ErrVersionConflict)
+
+		got, err := repo.FindByISBN(isbn13)
+		require.NoError(t, err)
+		assert.Equal(t, "title test2", got.Title)
+		assert.Equal(t, 2, got.Version)
+
+		// a nil ISBN removes it
+		err = repo.Update(models.BookRepository{ID: book.ID, Title: "title test2", Author: "author test2", Category: "category test2", Version: 2}, nil)
+		require.NoError(t, err)
+		got, err = repo.FindByID(book.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.ISBN)
+		_, err = repo.FindByISBN(isbn13)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestBookRepositoryFindAll(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		tolkien, _ := createBookWithCopies(t, DB, newBook("The Hobbit", "J.R.R. Tolkien", "Fantasy"), 1)
+		rings, ringsCopies := createBookWithCopies(t, DB, newBook("The Lord of the Rings", "J.R.R. Tolkien", "Fantasy"), 1)
+		dune, _ := createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Science Fiction"), 2)
+		now := time.Now()
+		require.NoError(t, repo.BorrowBook(models.LoanRepository{BookID: rings.ID, CopyID: ringsCopies[0].ID, MemberID: 1, BorrowedAt: now, DueAt: now}, 0, 0, nil))
+		borrowed := true
+		minBorrowCount := 1
+
+		testCases := []struct {
+			name        string
+			filter      models.BookFilter
+			expectIDs   []int
+			expectTotal int64
+		}{
+			{"query ignores case", models.BookFilter{Query: "TOLKIEN hobbit"}, []int{tolkien.ID}, 1},
+			{"title ignores case", models.BookFilter{Title: "the"}, []int{tolkien.ID, rings.ID}, 2},
+			{"author", models.BookFilter{Author: "herbert"}, []int{dune.ID}, 1},
+			{"category", models.BookFilter{Category: "fantasy"}, []int{tolkien.ID, rings.ID}, 2},
+			{"any fragment of every group", models.BookFilter{Fragments: [][]string{{"hob", "dun"}, {"tolk"}}}, []int{tolkien.ID}, 1},
+			{"fragments ignore case", models.BookFilter{Fragments: [][]string{{"HERB", "xyz"}}}, []int{dune.ID}, 1},
+			{"borrowed", models.BookFilter{IsBorrowed: &borrowed}, []int{rings.ID}, 1},
+			{"min borrow count", models.BookFilter{MinBorrowCount: &minBorrowCount}, []int{rings.ID}, 1},
+			{"sort and page", models.BookFilter{SortName: "title", SortType: "desc", Offset: 1, Limit: 1}, []int{tolkien.ID}, 3},
+			{"sort by available copies", models.BookFilter{SortName: "available_copies", SortType: "desc"}, []int{dune.ID, tolkien.ID, rings.ID}, 3},
+		}
+		for _, tC := range testCases {
+			t.Run(tC.name, func(t *testing.T) {
+				books, total, err := repo.FindAll(tC.filter)
+				require.NoError(t, err)
+				assert.Equal(t, tC.expectIDs, bookIDs(books))
+				assert.Equal(t, tC.expectTotal, total)
+			})
+		}
+	})
+}
+
+// books sharing more query fragments come first, so a cap on the fuzzy
+// candidates drops the weakest ones rather than the newest
+func TestBookRepositoryFindAllRanksFragments(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		hotels := []models.BookRepository{}
+		for i := 0; i < 1200; i++ {
+			hotels = append(hotels, newBook(fmt.Sprintf("The History of Hotels %d", i+1), "author test", "category test"))
+		}
+		require.NoError(t, DB.CreateInBatches(&hotels, 200).Error)
+		hobbit, _ := createBookWithCopies(t, DB, newBook("The Hobbit", "J.R.R. Tolkien", "Fantasy"), 0)
+
+		books, total, err := repo.FindAll(models.BookFilter{Fragments: [][]string{{"the"}, {"ho", "ob", "bb", "bi", "it"}}, Limit: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1201), total)
+		require.Len(t, books, 1000)
+		assert.Equal(t, hobbit.ID, books[0].ID)
+	})
+}
+
+func TestBookRepositoryFindFacets(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		createBookWithCopies(t, DB, newBook("The Hobbit", "J.R.R. Tolkien", "Fantasy"), 1)
+		rings, ringsCopies := createBookWithCopies(t, DB, newBook("The Lord of the Rings", "J.R.R. Tolkien", "Fantasy"), 1)
+		createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Science Fiction"), 1)
+		now := time.Now()
+		require.NoError(t, repo.BorrowBook(models.LoanRepository{BookID: rings.ID, CopyID: ringsCopies[0].ID, MemberID: 1, BorrowedAt: now, DueAt: now}, 0, 0, nil))
+
+		facets, err := repo.FindFacets(models.BookFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []models.BookFacetRepository{
+			{Facet: models.BookFacetAuthor, Value: "J.R.R. Tolkien", Count: 2},
+			{Facet: models.BookFacetAuthor, Value: "Frank Herbert", Count: 1},
+			{Facet: models.BookFacetAvailability, Value: models.BookAvailable, Count: 2},
+			{Facet: models.BookFacetAvailability, Value: models.BookBorrowed, Count: 1},
+			{Facet: models.BookFacetCategory, Value: "Fantasy", Count: 2},
+			{Facet: models.BookFacetCategory, Value: "Science Fiction", Count: 1},
+		}, facets)
+	})
+}
+
+func TestBookRepositoryFindMostBorrowed(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		hobbit, hobbitCopies := createBookWithCopies(t, DB, newBook("The Hobbit", "J.R.R. Tolkien", "Fantasy"), 2)
+		dune, _ := createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Science Fiction"), 1)
+		now := time.Now()
+		for i, bookCopy := range hobbitCopies {
+			require.NoError(t, repo.BorrowBook(models.LoanRepository{BookID: hobbit.ID, CopyID: bookCopy.ID, MemberID: i + 1, BorrowedAt: now, DueAt: now}, 0, 0, nil))
+		}
+
+		books, err := repo.FindMostBorrowed()
+		require.NoError(t, err)
+		assert.Equal(t, []int{hobbit.ID, dune.ID}, bookIDs(books))
+		assert.Equal(t, 2, books[0].BorrowCount)
+		assert.Equal(t, 0, books[0].AvailableCopies)
+	})
+}
+
+func TestBookRepositoryTrash(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		isbn13 := "9780306406157"
+		book := newBook("The Hobbit", "J.R.R. Tolkien", "Fantasy")
+		book.ISBN = &isbn13
+		hobbit, _ := createBookWithCopies(t, DB, book, 1)
+		dune, _ := createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Science Fiction"), 1)
+
+		require.NoError(t, repo.Delete(hobbit.ID, nil))
+		require.NoError(t, repo.Delete(dune.ID, nil))
+		assert.ErrorIs(t, repo.Delete(dune.ID, nil), gorm.ErrRecordNotFound)
+		_, err := repo.FindByID(hobbit.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		existing, err := repo.FindExistingISBNs([]string{isbn13, "9780262033848"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{isbn13}, existing)
+
+		require.NoError(t, repo.Restore(hobbit.ID, nil))
+		assert.ErrorIs(t, repo.Restore(hobbit.ID, nil), gorm.ErrRecordNotFound)
+		trash, total, err := repo.FindDeleted(models.BookFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []int{dune.ID}, bookIDs(trash))
+		assert.Equal(t, int64(1), total)
+
+		purged, err := repo.Purge(time.Now().Add(time.Minute), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int{dune.ID}, purged)
+		var copies int64
+		require.NoError(t, DB.Model(&models.CopyRepository{}).Count(&copies).Error)
+		assert.Equal(t, int64(1), copies)
+		_, total, err = repo.FindDeleted(models.BookFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+	})
+}
+
+func TestBookRepositoryPurgeHistory(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewBookRepository(DB)
+		now := time.Now()
+		returned, unpaid, settled := now.Add(-time.Hour), now.Add(-2*time.Hour), now.Add(-3*time.Hour)
+		// a returned loan with a paid fine and a hold: purged with the book
+		done, _ := createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Science Fiction"), 1)
+		doneLoan := models.LoanRepository{BookID: done.ID, MemberID: 1, BorrowedAt: settled, DueAt: settled, ReturnedAt: &returned}
+		require.NoError(t, DB.Create(&doneLoan).Error)
+		require.NoError(t, DB.Create(&models.FineRepository{LoanID: doneLoan.ID, MemberID: 1, BookID: done.ID, Amount: 1, DaysLate: 1, PaidAt: &returned}).Error)
+		require.NoError(t, DB.Create(&models.HoldRepository{BookID: done.ID, MemberID: 2, Status: models.HoldStatusWaiting}).Error)
+		// a fine still owed: kept in the trash
+		owed, _ := createBookWithCopies(t, DB, newBook("Emma", "Jane Austen", "Classics"), 1)
+		owedLoan := models.LoanRepository{BookID: owed.ID, MemberID: 1, BorrowedAt: unpaid, DueAt: unpaid, ReturnedAt: &returned}
+		require.NoError(t, DB.Create(&owedLoan).Error)
+		require.NoError(t, DB.Create(&models.FineRepository{LoanID: owedLoan.ID, MemberID: 1, BookID: owed.ID, Amount: 1, DaysLate: 1}).Error)
+		// a loan still out: kept in the trash
+		out, _ := createBookWithCopies(t, DB, newBook("Ulysses", "James Joyce", "Classics"), 1)
+		require.NoError(t, DB.Create(&models.LoanRepository{BookID: out.ID, MemberID: 3, BorrowedAt: now, DueAt: now}).Error)
+		require.NoError(t, DB.Where("id IN ?", []int{done.ID, owed.ID, out.ID}).Delete(&models.BookRepository{}).Error)
+
+		purged, err := repo.Purge(now.Add(time.Minute), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int{done.ID}, purged)
+		for _, model := range []interface{}{&models.CopyRepository{}, &models.LoanRepository{}, &models.FineRepository{}, &models.HoldRepository{}} {
+			var left int64
+			require.NoError(t, DB.Model(model).Where("book_id = ?", done.ID).Count(&left).Error)
+			assert.Equal(t, int64(0), left, "%T", model)
+		}
+		trash, _, err := repo.FindDeleted(models.BookFilter{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{owed.ID, out.ID}, bookIDs(trash))
+	})
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type copyRepository struct {
+	db *gorm.DB
+}
+
+// Create implements CopyRepository.
+func (c copyRepository) Create(bookCopy models.CopyRepository) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Create(&bookCopy)
+		if db.Error != nil {
+			return db.Error
+		}
+		return touchBook(tx, bookCopy.BookID)
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Update implements CopyRepository.
+// Only the descriptive fields are written, empty ones included; the lending
+// state belongs to borrow, return and the hold queue.
+func (c copyRepository) Update(req models.CopyRepository) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.CopyRepository{}).Where("id = ?", req.ID).Updates(map[string]interface{}{
+			"barcode":        req.Barcode,
+			"condition":      req.Condition,
+			"shelf_location": req.ShelfLocation,
+		})
+		if db.Error != nil {
+			return db.Error
+		}
+		return touchBook(tx, req.BookID)
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindByID implements CopyRepository.
+func (c copyRepository) FindByID(id int) (models.CopyRepository, error) {
+	copyRepoResp := models.CopyRepository{}
+	db := c.db.Where("id = ?", id).First(&copyRepoResp)
+	if db.Error != nil {
+		return copyRepoResp, db.Error
+	}
+	return copyRepoResp, nil
+}
+
+// FindByBookID implements CopyRepository.
+func (c copyRepository) FindByBookID(bookID int) ([]models.CopyRepository, error) {
+	copyList := []models.CopyRepository{}
+	db := c.db.Where("book_id = ?", bookID).Order("id asc").Find(&copyList)
+	if db.Error != nil {
+		return copyList, db.Error
+	}
+	return copyList, nil
+}
+
+// FindAvailableByBookID implements CopyRepository.
+func (c copyRepository) FindAvailableByBookID(bookID int) (models.CopyRepository, error) {
+	copyRepoResp := models.CopyRepository{}
+	db := c.db.Where("book_id = ? AND is_borrowed = ? AND reserved_for = 0", bookID, false).Order("id asc").First(&copyRepoResp)
+	if db.Error != nil {
+		return copyRepoResp, db.Error
+	}
+	return copyRepoResp, nil
+}
+
+// FindBorrowedByBookID implements CopyRepository.
+func (c copyRepository) FindBorrowedByBookID(bookID int) ([]models.CopyRepository, error) {
+	copyList := []models.CopyRepository{}
+	db := c.db.Where("book_id = ? AND is_borrowed = ?", bookID, true).Order("id asc").Find(&copyList)
+	if db.Error != nil {
+		return copyList, db.Error
+	}
+	return copyList, nil
+}
+
+func NewCopyRepository(db *gorm.DB) CopyRepository {
+	return copyRepository{db: db}
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockCopyRepository struct {
+	mock.Mock
+}
+
+func (mockCopyRepo *mockCopyRepository) Create(bookCopy models.CopyRepository) error {
+	args := mockCopyRepo.Called()
+	return args.Error(0)
+}
+func (mockCopyRepo *mockCopyRepository) Update(bookCopy models.CopyRepository) error {
+	args := mockCopyRepo.Called()
+	return args.Error(0)
+}
+func (mockCopyRepo *mockCopyRepository) FindByID(id int) (models.CopyRepository, error) {
+	args := mockCopyRepo.Called()
+	return args.Get(0).(models.CopyRepository), args.Error(1)
+}
+func (mockCopyRepo *mockCopyRepository) FindByBookID(bookID int) ([]models.CopyRepository, error) {
+	args := mockCopyRepo.Called()
+	return args.Get(0).([]models.CopyRepository), args.Error(1)
+}
+func (mockCopyRepo *mockCopyRepository) FindAvailableByBookID(bookID int) (models.CopyRepository, error) {
+	args := mockCopyRepo.Called()
+	return args.Get(0).(models.CopyRepository), args.Error(1)
+}
+func (mockCopyRepo *mockCopyRepository) FindBorrowedByBookID(bookID int) ([]models.CopyRepository, error) {
+	args := mockCopyRepo.Called()
+	return args.Get(0).([]models.CopyRepository), args.Error(1)
+}
+func NewCopyRepositoryMock() *mockCopyRepository {
+	return &mockCopyRepository{}
+}
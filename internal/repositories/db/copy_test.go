@@ -0,0 +1,33 @@
+package db_test
+
+import (
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCopyRepositoryUpdate(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewCopyRepository(DB)
+		book, copies := createBookWithCopies(t, DB, newBook("Dune", "Frank Herbert", "Science Fiction"), 2)
+		require.NoError(t, DB.Model(&models.CopyRepository{}).Where("id = ?", copies[0].ID).Update("shelf_location", "A1").Error)
+
+		// an empty shelf location is written, not skipped
+		require.NoError(t, repo.Update(models.CopyRepository{ID: copies[0].ID, BookID: book.ID, Barcode: "D-0001", Condition: "fair"}))
+		updated, err := repo.FindByID(copies[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "D-0001", updated.Barcode)
+		assert.Equal(t, "fair", updated.Condition)
+		assert.Equal(t, "", updated.ShelfLocation)
+
+		err = repo.Update(models.CopyRepository{ID: copies[1].ID, BookID: book.ID, Barcode: "D-0001", Condition: "good"})
+		assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+		err = repo.Create(models.CopyRepository{BookID: book.ID, Barcode: "D-0001", Condition: "new"})
+		assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+	})
+}
@@ -1,15 +1,112 @@
 package db
 
 import (
+	"errors"
 	"test-exam-forviz/internal/models"
+	"time"
 )
 
+// SqliteOptions make concurrent sqlite writers take the write lock when their
+// transaction begins and wait for it, instead of failing with "database is
+// locked" halfway through.
+const SqliteOptions = "_busy_timeout=5000&_txlock=immediate"
+
+// The conflicts reported by the conditional book writes when another request
+// changed the same rows first.
+var (
+	// ErrVersionConflict means the book is no longer at the version given.
+	ErrVersionConflict = errors.New("book version conflict")
+	// ErrCopyUnavailable means the copy to borrow is already lent or set
+	// aside for another member.
+	ErrCopyUnavailable = errors.New("copy unavailable")
+	// ErrCopyNotBorrowed means the copy to return is not lent.
+	ErrCopyNotBorrowed = errors.New("copy not borrowed")
+	// ErrBookBorrowed means the book to delete has a copy on loan.
+	ErrBookBorrowed = errors.New("book borrowed")
+	// ErrRenewLimit means the loan to renew has been renewed too often.
+	ErrRenewLimit = errors.New("renew limit reached")
+	// ErrFinePaid means the fine to pay is paid already.
+	ErrFinePaid = errors.New("fine paid")
+)
+
+// AuditEntry builds the audit entry of a write to book. The write calls it
+// inside its transaction once book has its id, so the change and its entry
+// are committed together or not at all; a nil AuditEntry records nothing.
+type AuditEntry func(book models.BookRepository) models.AuditRepository
+
 type BookRepository interface {
-	Create(book models.BookRepository) error
-	Update(book models.BookRepository) error
-	Delete(id int) error
+	Create(book *models.BookRepository, audit AuditEntry) error
+	CreateBatch(books []models.BookRepository, audit AuditEntry) error
+	Update(book models.BookRepository, audit AuditEntry) error
+	Delete(id int, audit AuditEntry) error
+	Restore(id int, audit AuditEntry) error
+	Purge(before time.Time, audit AuditEntry) ([]int, error)
 	FindByID(id int) (models.BookRepository, error)
-	FindAll(title, author, category, sortName, sortType string) ([]models.BookRepository, error)
-	BorrowBook(id, count int) error
-	ReturnBook(id int) error
+	FindByISBN(isbn string) (models.BookRepository, error)
+	FindExistingISBNs(isbns []string) ([]string, error)
+	FindAll(filter models.BookFilter) ([]models.BookRepository, int64, error)
+	FindDeleted(filter models.BookFilter) ([]models.BookRepository, int64, error)
+	FindFacets(filter models.BookFilter) ([]models.BookFacetRepository, error)
+	FindInBatches(filter models.BookFilter, batchSize int, fn func(books []models.BookRepository) error) error
+	FindMostBorrowed() ([]models.BookRepository, error)
+	BorrowBook(loan models.LoanRepository, version, holdID int, audit AuditEntry) error
+	ReturnBook(copyID, version int, returnedAt time.Time, fine *models.FineRepository, holdExpireAt time.Time, audit AuditEntry) error
+}
+
+type CopyRepository interface {
+	Create(bookCopy models.CopyRepository) error
+	Update(bookCopy models.CopyRepository) error
+	FindByID(id int) (models.CopyRepository, error)
+	FindByBookID(bookID int) ([]models.CopyRepository, error)
+	FindAvailableByBookID(bookID int) (models.CopyRepository, error)
+	FindBorrowedByBookID(bookID int) ([]models.CopyRepository, error)
+}
+
+type LoanRepository interface {
+	FindByBookID(bookID int) ([]models.LoanRepository, error)
+	FindOverdue(now time.Time) ([]models.LoanRepository, error)
+	FindOpenByCopyID(copyID int) (models.LoanRepository, error)
+	FindOpenByMember(bookID, memberID int) (models.LoanRepository, error)
+	Renew(id, maxRenewals int, dueAt, renewedAt time.Time, audit AuditEntry) error
+}
+
+type HoldRepository interface {
+	Create(hold models.HoldRepository) error
+	FindActiveByBookID(bookID int) ([]models.HoldRepository, error)
+	FindActiveByMember(bookID, memberID int) (models.HoldRepository, error)
+	Cancel(hold models.HoldRepository, now, expireAt time.Time) error
+	AdvanceQueue(bookID int, now, expireAt time.Time) error
+}
+
+type FineRepository interface {
+	FindByID(id int) (models.FineRepository, error)
+	FindOutstandingByMemberID(memberID int) ([]models.FineRepository, error)
+	SumOutstandingByMemberID(memberID int) (float64, error)
+	Pay(id int, paidAt time.Time) error
+}
+
+type MemberRepository interface {
+	Create(member models.MemberRepository) error
+	Update(member models.MemberRepository) error
+	Suspend(id int) error
+	FindByID(id int) (models.MemberRepository, error)
+	FindAll() ([]models.MemberRepository, error)
+}
+
+type UserRepository interface {
+	Create(user models.UserRepository) error
+	FindByUsername(username string) (models.UserRepository, error)
+}
+
+type AuditRepository interface {
+	Create(entry models.AuditRepository) error
+	FindAll(filter models.AuditFilter) ([]models.AuditRepository, int64, error)
+}
+
+type ApiKeyRepository interface {
+	Create(apiKey models.ApiKeyRepository) error
+	FindAll() ([]models.ApiKeyRepository, error)
+	FindByID(id int) (models.ApiKeyRepository, error)
+	FindByHash(keyHash string) (models.ApiKeyRepository, error)
+	Revoke(id int, revokedAt time.Time) error
 }
@@ -0,0 +1,63 @@
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"test-exam-forviz/config"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/repositories/migrations"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// tables are dropped and migrated again before every test.
+var tables = []interface{}{
+	"schema_migrations",
+	&models.BookRepository{},
+	&models.CopyRepository{},
+	&models.MemberRepository{},
+	&models.LoanRepository{},
+	&models.FineRepository{},
+	&models.HoldRepository{},
+	&models.UserRepository{},
+	&models.ApiKeyRepository{},
+	&models.AuditRepository{},
+}
+
+// backends are the databases the repository tests run against: a sqlite file
+// always, postgres and mysql when TEST_POSTGRES_DSN or TEST_MYSQL_DSN point at
+// a server, like one started from a local container.
+func backends(t *testing.T) []config.Database {
+	t.Helper()
+	databases := []config.Database{
+		{Driver: db.DriverSqlite, DSN: filepath.Join(t.TempDir(), "library.db")},
+	}
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		databases = append(databases, config.Database{Driver: db.DriverPostgres, DSN: dsn})
+	}
+	if dsn := os.Getenv("TEST_MYSQL_DSN"); dsn != "" {
+		databases = append(databases, config.Database{Driver: db.DriverMysql, DSN: dsn})
+	}
+	return databases
+}
+
+// eachBackend runs fn on an empty schema in every backend.
+func eachBackend(t *testing.T, fn func(t *testing.T, DB *gorm.DB)) {
+	for _, database := range backends(t) {
+		t.Run(database.Driver, func(t *testing.T) {
+			DB, err := db.Open(database, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			sqlDB, err := DB.DB()
+			require.NoError(t, err)
+			t.Cleanup(func() { sqlDB.Close() })
+			require.NoError(t, DB.Migrator().DropTable(tables...))
+			_, err = migrations.New(DB, migrations.All).Up()
+			require.NoError(t, err)
+			fn(t, DB)
+		})
+	}
+}
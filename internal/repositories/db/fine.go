@@ -0,0 +1,70 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fineRepository struct {
+	db *gorm.DB
+}
+
+// FindByID implements FineRepository.
+func (f fineRepository) FindByID(id int) (models.FineRepository, error) {
+	fineRepoResp := models.FineRepository{}
+	db := f.db.Where("id = ?", id).First(&fineRepoResp)
+	if db.Error != nil {
+		return fineRepoResp, db.Error
+	}
+	return fineRepoResp, nil
+}
+
+// FindOutstandingByMemberID implements FineRepository.
+func (f fineRepository) FindOutstandingByMemberID(memberID int) ([]models.FineRepository, error) {
+	fineList := []models.FineRepository{}
+	db := f.db.Where("member_id = ? AND paid_at IS NULL", memberID).Order("create_at asc").Find(&fineList)
+	if db.Error != nil {
+		return fineList, db.Error
+	}
+	return fineList, nil
+}
+
+// SumOutstandingByMemberID implements FineRepository.
+func (f fineRepository) SumOutstandingByMemberID(memberID int) (float64, error) {
+	var total float64
+	db := f.db.Model(&models.FineRepository{}).
+		Where("member_id = ? AND paid_at IS NULL", memberID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total)
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return total, nil
+}
+
+// Pay implements FineRepository.
+// Only an unpaid fine is paid, so of two concurrent payments one gets
+// ErrFinePaid.
+func (f fineRepository) Pay(id int, paidAt time.Time) error {
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.FineRepository{}).Where("id = ? AND paid_at IS NULL", id).Update("paid_at", paidAt)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return ErrFinePaid
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func NewFineRepository(db *gorm.DB) FineRepository {
+	return fineRepository{db: db}
+}
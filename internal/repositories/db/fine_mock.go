@@ -0,0 +1,32 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockFineRepository struct {
+	mock.Mock
+}
+
+func (mockFineRepo *mockFineRepository) FindByID(id int) (models.FineRepository, error) {
+	args := mockFineRepo.Called()
+	return args.Get(0).(models.FineRepository), args.Error(1)
+}
+func (mockFineRepo *mockFineRepository) FindOutstandingByMemberID(memberID int) ([]models.FineRepository, error) {
+	args := mockFineRepo.Called()
+	return args.Get(0).([]models.FineRepository), args.Error(1)
+}
+func (mockFineRepo *mockFineRepository) SumOutstandingByMemberID(memberID int) (float64, error) {
+	args := mockFineRepo.Called()
+	return args.Get(0).(float64), args.Error(1)
+}
+func (mockFineRepo *mockFineRepository) Pay(id int, paidAt time.Time) error {
+	args := mockFineRepo.Called()
+	return args.Error(0)
+}
+func NewFineRepositoryMock() *mockFineRepository {
+	return &mockFineRepository{}
+}
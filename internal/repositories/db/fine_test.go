@@ -0,0 +1,33 @@
+package db_test
+
+import (
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPayFineConcurrent(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewFineRepository(DB)
+		book, _ := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 0)
+		fine := models.FineRepository{LoanID: 1, MemberID: 1, BookID: book.ID, Amount: 30, DaysLate: 6}
+		require.NoError(t, DB.Create(&fine).Error)
+
+		results := race(func(i int) error {
+			return repo.Pay(fine.ID, time.Now())
+		})
+
+		succeeded, conflicted := countErrors(t, results, db.ErrFinePaid)
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, concurrentRequests-1, conflicted)
+		paid, err := repo.FindByID(fine.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, paid.PaidAt)
+	})
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type holdRepository struct {
+	db *gorm.DB
+}
+
+// Create implements HoldRepository.
+func (h holdRepository) Create(hold models.HoldRepository) error {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Create(&hold)
+		if db.Error != nil {
+			return db.Error
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindActiveByBookID implements HoldRepository.
+func (h holdRepository) FindActiveByBookID(bookID int) ([]models.HoldRepository, error) {
+	holdList := []models.HoldRepository{}
+	db := h.db.Where("book_id = ? AND status IN ?", bookID, []string{models.HoldStatusReady, models.HoldStatusWaiting}).
+		Order("id asc").
+		Find(&holdList)
+	if db.Error != nil {
+		return holdList, db.Error
+	}
+	return holdList, nil
+}
+
+// FindActiveByMember implements HoldRepository.
+func (h holdRepository) FindActiveByMember(bookID, memberID int) (models.HoldRepository, error) {
+	holdRepoResp := models.HoldRepository{}
+	db := h.db.Where("book_id = ? AND member_id = ? AND status IN ?", bookID, memberID, []string{models.HoldStatusReady, models.HoldStatusWaiting}).
+		First(&holdRepoResp)
+	if db.Error != nil {
+		return holdRepoResp, db.Error
+	}
+	return holdRepoResp, nil
+}
+
+// Cancel implements HoldRepository.
+func (h holdRepository) Cancel(hold models.HoldRepository, now, expireAt time.Time) error {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.HoldRepository{}).Where("id = ?", hold.ID).Update("status", models.HoldStatusCancelled)
+		if db.Error != nil {
+			return db.Error
+		}
+		if hold.CopyID != 0 {
+			db = tx.Model(&models.CopyRepository{}).Where("id = ? AND reserved_for = ?", hold.CopyID, hold.MemberID).Update("reserved_for", 0)
+			if db.Error != nil {
+				return db.Error
+			}
+			if err := touchBook(tx, hold.BookID); err != nil {
+				return err
+			}
+		}
+		return advanceHoldQueue(tx, hold.BookID, now, expireAt)
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// AdvanceQueue implements HoldRepository.
+func (h holdRepository) AdvanceQueue(bookID int, now, expireAt time.Time) error {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return advanceHoldQueue(tx, bookID, now, expireAt)
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// advanceHoldQueue expires ready holds whose pickup window has passed, then
+// sets every free copy of the title aside for the next waiting member. The
+// book moves to its next version when a copy was released or set aside.
+func advanceHoldQueue(tx *gorm.DB, bookID int, now, expireAt time.Time) error {
+	changed := false
+	expired := []models.HoldRepository{}
+	db := tx.Where("book_id = ? AND status = ? AND expire_at < ?", bookID, models.HoldStatusReady, now).Find(&expired)
+	if db.Error != nil {
+		return db.Error
+	}
+	for _, hold := range expired {
+		db = tx.Model(&models.HoldRepository{}).Where("id = ?", hold.ID).Update("status", models.HoldStatusExpired)
+		if db.Error != nil {
+			return db.Error
+		}
+		db = tx.Model(&models.CopyRepository{}).Where("id = ? AND reserved_for = ?", hold.CopyID, hold.MemberID).Update("reserved_for", 0)
+		if db.Error != nil {
+			return db.Error
+		}
+		changed = true
+	}
+
+	freeCopies := []models.CopyRepository{}
+	db = tx.Where("book_id = ? AND is_borrowed = ? AND reserved_for = 0", bookID, false).Order("id asc").Find(&freeCopies)
+	if db.Error != nil {
+		return db.Error
+	}
+	for _, bookCopy := range freeCopies {
+		next := models.HoldRepository{}
+		db = tx.Where("book_id = ? AND status = ?", bookID, models.HoldStatusWaiting).Order("id asc").Limit(1).Find(&next)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			break
+		}
+		db = tx.Model(&models.HoldRepository{}).Where("id = ?", next.ID).Updates(map[string]interface{}{
+			"status":    models.HoldStatusReady,
+			"copy_id":   bookCopy.ID,
+			"ready_at":  now,
+			"expire_at": expireAt,
+		})
+		if db.Error != nil {
+			return db.Error
+		}
+		db = tx.Model(&models.CopyRepository{}).Where("id = ?", bookCopy.ID).Update("reserved_for", next.MemberID)
+		if db.Error != nil {
+			return db.Error
+		}
+		changed = true
+	}
+	if changed {
+		return touchBook(tx, bookID)
+	}
+	return nil
+}
+
+func NewHoldRepository(db *gorm.DB) HoldRepository {
+	return holdRepository{db: db}
+}
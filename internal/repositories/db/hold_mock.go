@@ -0,0 +1,36 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockHoldRepository struct {
+	mock.Mock
+}
+
+func (mockHoldRepo *mockHoldRepository) Create(hold models.HoldRepository) error {
+	args := mockHoldRepo.Called()
+	return args.Error(0)
+}
+func (mockHoldRepo *mockHoldRepository) FindActiveByBookID(bookID int) ([]models.HoldRepository, error) {
+	args := mockHoldRepo.Called()
+	return args.Get(0).([]models.HoldRepository), args.Error(1)
+}
+func (mockHoldRepo *mockHoldRepository) FindActiveByMember(bookID, memberID int) (models.HoldRepository, error) {
+	args := mockHoldRepo.Called()
+	return args.Get(0).(models.HoldRepository), args.Error(1)
+}
+func (mockHoldRepo *mockHoldRepository) Cancel(hold models.HoldRepository, now, expireAt time.Time) error {
+	args := mockHoldRepo.Called()
+	return args.Error(0)
+}
+func (mockHoldRepo *mockHoldRepository) AdvanceQueue(bookID int, now, expireAt time.Time) error {
+	args := mockHoldRepo.Called()
+	return args.Error(0)
+}
+func NewHoldRepositoryMock() *mockHoldRepository {
+	return &mockHoldRepository{}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type loanRepository struct {
+	db *gorm.DB
+}
+
+// FindByBookID implements LoanRepository.
+func (l loanRepository) FindByBookID(bookID int) ([]models.LoanRepository, error) {
+	loanList := []models.LoanRepository{}
+	db := l.db.Where("book_id = ?", bookID).Order("borrowed_at desc, id desc").Find(&loanList)
+	if db.Error != nil {
+		return loanList, db.Error
+	}
+	return loanList, nil
+}
+
+// FindOverdue implements LoanRepository.
+func (l loanRepository) FindOverdue(now time.Time) ([]models.LoanRepository, error) {
+	loanList := []models.LoanRepository{}
+	db := l.db.Where("returned_at IS NULL AND due_at < ?", now).Order("due_at asc").Find(&loanList)
+	if db.Error != nil {
+		return loanList, db.Error
+	}
+	return loanList, nil
+}
+
+// FindOpenByCopyID implements LoanRepository.
+func (l loanRepository) FindOpenByCopyID(copyID int) (models.LoanRepository, error) {
+	loanRepoResp := models.LoanRepository{}
+	db := l.db.Where("copy_id = ? AND returned_at IS NULL", copyID).Order("borrowed_at desc").First(&loanRepoResp)
+	if db.Error != nil {
+		return loanRepoResp, db.Error
+	}
+	return loanRepoResp, nil
+}
+
+// FindOpenByMember implements LoanRepository.
+func (l loanRepository) FindOpenByMember(bookID, memberID int) (models.LoanRepository, error) {
+	loanRepoResp := models.LoanRepository{}
+	db := l.db.Where("book_id = ? AND member_id = ? AND returned_at IS NULL", bookID, memberID).Order("due_at asc").First(&loanRepoResp)
+	if db.Error != nil {
+		return loanRepoResp, db.Error
+	}
+	return loanRepoResp, nil
+}
+
+// Renew implements LoanRepository.
+// Only an open loan renewed fewer than maxRenewals times is renewed, checked
+// in the UPDATE itself so concurrent renewals cannot pass the limit.
+// gorm.ErrRecordNotFound means the loan was returned meanwhile and
+// ErrRenewLimit that it reached the limit.
+func (l loanRepository) Renew(id, maxRenewals int, dueAt, renewedAt time.Time, audit AuditEntry) error {
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.LoanRepository{}).Where("id = ? AND returned_at IS NULL AND renew_count < ?", id, maxRenewals).Updates(map[string]interface{}{
+			"due_at":      dueAt,
+			"renewed_at":  renewedAt,
+			"renew_count": gorm.Expr("renew_count + 1"),
+		})
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			var open int64
+			db = tx.Model(&models.LoanRepository{}).Where("id = ? AND returned_at IS NULL", id).Count(&open)
+			if db.Error != nil {
+				return db.Error
+			}
+			if open > 0 {
+				return ErrRenewLimit
+			}
+			return gorm.ErrRecordNotFound
+		}
+		loan := models.LoanRepository{}
+		db = tx.Where("id = ?", id).First(&loan)
+		if db.Error != nil {
+			return db.Error
+		}
+		return recordAudit(tx, audit, models.BookRepository{ID: loan.BookID})
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func NewLoanRepository(db *gorm.DB) LoanRepository {
+	return loanRepository{db: db}
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockLoanRepository struct {
+	mock.Mock
+}
+
+func (mockLoanRepo *mockLoanRepository) FindByBookID(bookID int) ([]models.LoanRepository, error) {
+	args := mockLoanRepo.Called()
+	return args.Get(0).([]models.LoanRepository), args.Error(1)
+}
+func (mockLoanRepo *mockLoanRepository) FindOverdue(now time.Time) ([]models.LoanRepository, error) {
+	args := mockLoanRepo.Called()
+	return args.Get(0).([]models.LoanRepository), args.Error(1)
+}
+func (mockLoanRepo *mockLoanRepository) FindOpenByCopyID(copyID int) (models.LoanRepository, error) {
+	args := mockLoanRepo.Called()
+	return args.Get(0).(models.LoanRepository), args.Error(1)
+}
+func (mockLoanRepo *mockLoanRepository) FindOpenByMember(bookID, memberID int) (models.LoanRepository, error) {
+	args := mockLoanRepo.Called()
+	return args.Get(0).(models.LoanRepository), args.Error(1)
+}
+func (mockLoanRepo *mockLoanRepository) Renew(id, maxRenewals int, dueAt, renewedAt time.Time, audit AuditEntry) error {
+	args := mockLoanRepo.Called()
+	return args.Error(0)
+}
+func NewLoanRepositoryMock() *mockLoanRepository {
+	return &mockLoanRepository{}
+}
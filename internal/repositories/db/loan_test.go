@@ -0,0 +1,53 @@
+package db_test
+
+import (
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRenewConcurrentLimit(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewLoanRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 1)
+		now := time.Now()
+		require.NoError(t, db.NewBookRepository(DB).BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[0].ID, MemberID: 1, BorrowedAt: now, DueAt: now}, 0, 0, nil))
+		loan, err := repo.FindOpenByCopyID(bookCopies[0].ID)
+		require.NoError(t, err)
+
+		const maxRenewals = 2
+		results := race(func(i int) error {
+			return repo.Renew(loan.ID, maxRenewals, now.AddDate(0, 0, 14), time.Now(), nil)
+		})
+
+		succeeded, conflicted := countErrors(t, results, db.ErrRenewLimit)
+		assert.Equal(t, maxRenewals, succeeded)
+		assert.Equal(t, concurrentRequests-maxRenewals, conflicted)
+		renewed, err := repo.FindOpenByCopyID(bookCopies[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, maxRenewals, renewed.RenewCount)
+	})
+}
+
+func TestRenewReturned(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewLoanRepository(DB)
+		book, bookCopies := createBookWithCopies(t, DB, newBook("title test1", "author test1", "category test1"), 1)
+		now := time.Now()
+		bookRepo := db.NewBookRepository(DB)
+		require.NoError(t, bookRepo.BorrowBook(models.LoanRepository{BookID: book.ID, CopyID: bookCopies[0].ID, MemberID: 1, BorrowedAt: now, DueAt: now}, 0, 0, nil))
+		loan, err := repo.FindOpenByCopyID(bookCopies[0].ID)
+		require.NoError(t, err)
+		require.NoError(t, bookRepo.ReturnBook(bookCopies[0].ID, 0, now, nil, now, nil))
+
+		err = repo.Renew(loan.ID, 2, now.AddDate(0, 0, 14), now, nil)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type memberRepository struct {
+	db *gorm.DB
+}
+
+// Create implements MemberRepository.
+func (m memberRepository) Create(member models.MemberRepository) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Create(&member)
+		if db.Error != nil {
+			return db.Error
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Update implements MemberRepository.
+// Empty fields are written too, so an update can clear the phone.
+func (m memberRepository) Update(req models.MemberRepository) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.MemberRepository{}).Where("id = ?", req.ID).Updates(map[string]interface{}{
+			"name":  req.Name,
+			"email": req.Email,
+			"phone": req.Phone,
+		})
+		if db.Error != nil {
+			return db.Error
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Suspend implements MemberRepository.
+func (m memberRepository) Suspend(id int) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Model(&models.MemberRepository{}).Where("id=?", id).Update("is_suspended", true)
+		if db.Error != nil {
+			return db.Error
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindByID implements MemberRepository.
+func (m memberRepository) FindByID(id int) (models.MemberRepository, error) {
+	memberRepoResp := models.MemberRepository{}
+	db := m.db.Where("id = ?", id).First(&memberRepoResp)
+	if db.Error != nil {
+		return memberRepoResp, db.Error
+	}
+	return memberRepoResp, nil
+}
+
+// FindAll implements MemberRepository.
+func (m memberRepository) FindAll() ([]models.MemberRepository, error) {
+	memberList := []models.MemberRepository{}
+	db := m.db.Order("id asc").Find(&memberList)
+	if db.Error != nil {
+		return memberList, db.Error
+	}
+	return memberList, nil
+}
+
+func NewMemberRepository(db *gorm.DB) MemberRepository {
+	return memberRepository{db: db}
+}
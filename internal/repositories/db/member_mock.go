@@ -0,0 +1,35 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockMemberRepository struct {
+	mock.Mock
+}
+
+func (mockMemberRepo *mockMemberRepository) Create(member models.MemberRepository) error {
+	args := mockMemberRepo.Called()
+	return args.Error(0)
+}
+func (mockMemberRepo *mockMemberRepository) Update(member models.MemberRepository) error {
+	args := mockMemberRepo.Called()
+	return args.Error(0)
+}
+func (mockMemberRepo *mockMemberRepository) Suspend(id int) error {
+	args := mockMemberRepo.Called()
+	return args.Error(0)
+}
+func (mockMemberRepo *mockMemberRepository) FindByID(id int) (models.MemberRepository, error) {
+	args := mockMemberRepo.Called()
+	return args.Get(0).(models.MemberRepository), args.Error(1)
+}
+func (mockMemberRepo *mockMemberRepository) FindAll() ([]models.MemberRepository, error) {
+	args := mockMemberRepo.Called()
+	return args.Get(0).([]models.MemberRepository), args.Error(1)
+}
+func NewMemberRepositoryMock() *mockMemberRepository {
+	return &mockMemberRepository{}
+}
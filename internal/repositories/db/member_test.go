@@ -0,0 +1,35 @@
+package db_test
+
+import (
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMemberRepositoryUpdate(t *testing.T) {
+
+	eachBackend(t, func(t *testing.T, DB *gorm.DB) {
+		repo := db.NewMemberRepository(DB)
+		require.NoError(t, repo.Create(models.MemberRepository{Name: "Ann", Email: "ann@example.com", Phone: "0812345678"}))
+		require.NoError(t, repo.Create(models.MemberRepository{Name: "Bob", Email: "bob@example.com"}))
+		members, err := repo.FindAll()
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+		ann, bob := members[0], members[1]
+
+		// an empty phone is written, not skipped
+		require.NoError(t, repo.Update(models.MemberRepository{ID: ann.ID, Name: "Ann", Email: "ann@example.com"}))
+		updated, err := repo.FindByID(ann.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "", updated.Phone)
+
+		err = repo.Update(models.MemberRepository{ID: bob.ID, Name: "Bob", Email: "ann@example.com"})
+		assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+		err = repo.Create(models.MemberRepository{Name: "Ann", Email: "ann@example.com"})
+		assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+	})
+}
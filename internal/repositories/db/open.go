@@ -0,0 +1,69 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"test-exam-forviz/config"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	DriverSqlite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMysql    = "mysql"
+)
+
+// mysqlStringSize is the varchar length of string columns on mysql, which
+// cannot index the longtext it would use otherwise.
+const mysqlStringSize = 255
+
+// Open connects to the database named by cfg and applies its pool settings.
+// Driver errors are translated, so a unique index violation is
+// gorm.ErrDuplicatedKey on every backend.
+func Open(cfg config.Database, gormConfig *gorm.Config) (*gorm.DB, error) {
+	gormConfig.TranslateError = true
+	dialector, err := newDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	DB, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.MaxLifeTimeMinutes) * time.Minute)
+	return DB, nil
+}
+
+func newDialector(cfg config.Database) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverSqlite:
+		separator := "?"
+		if strings.Contains(cfg.DSN, "?") {
+			separator = "&"
+		}
+		return sqlite.Open(cfg.DSN + separator + SqliteOptions), nil
+	case DriverPostgres:
+		return postgres.Open(cfg.DSN), nil
+	case DriverMysql:
+		// the repositories scan DATETIME columns into time.Time
+		dsnConfig, err := mysqldriver.ParseDSN(cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		dsnConfig.ParseTime = true
+		return mysql.New(mysql.Config{DSN: dsnConfig.FormatDSN(), DefaultStringSize: mysqlStringSize}), nil
+	}
+	return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+// Create implements UserRepository.
+func (u userRepository) Create(user models.UserRepository) error {
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Create(&user)
+		if db.Error != nil {
+			return db.Error
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindByUsername implements UserRepository.
+func (u userRepository) FindByUsername(username string) (models.UserRepository, error) {
+	userRepoResp := models.UserRepository{}
+	db := u.db.Where("username = ?", username).First(&userRepoResp)
+	if db.Error != nil {
+		return userRepoResp, db.Error
+	}
+	return userRepoResp, nil
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return userRepository{db: db}
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"test-exam-forviz/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockUserRepository struct {
+	mock.Mock
+}
+
+func (mockUserRepo *mockUserRepository) Create(user models.UserRepository) error {
+	args := mockUserRepo.Called()
+	return args.Error(0)
+}
+func (mockUserRepo *mockUserRepository) FindByUsername(username string) (models.UserRepository, error) {
+	args := mockUserRepo.Called()
+	return args.Get(0).(models.UserRepository), args.Error(1)
+}
+func NewUserRepositoryMock() *mockUserRepository {
+	return &mockUserRepository{}
+}
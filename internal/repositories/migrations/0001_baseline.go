@@ -0,0 +1,152 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// baseline creates the schema as AutoMigrate left it before versioned
+// migrations. On a database that AutoMigrate already created it only adds what
+// is missing, so existing installs adopt it in place.
+var baseline = Migration{
+	Version: 1,
+	Name:    "baseline",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(baselineTables...)
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(baselineTables...)
+	},
+}
+
+var baselineTables = []interface{}{
+	&book{}, &bookCopy{}, &member{}, &loan{}, &fine{}, &hold{}, &user{}, &apiKey{}, &audit{},
+}
+
+// The structs below are a frozen copy of the models at version 1. Later
+// schema changes go in new migrations, never in these.
+
+type book struct {
+	ID          int     `gorm:"primaryKey;autoIncrement"`
+	Title       string  `gorm:"index;not null"`
+	Author      string  `gorm:"index;not null"`
+	Category    string  `gorm:"index;not null"`
+	ISBN        *string `gorm:"uniqueIndex"`
+	BorrowCount int     `gorm:"borrow_count;default:0"`
+	Version     int     `gorm:"not null;default:1"`
+	UpdateAt    time.Time
+	CreateAt    time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (book) TableName() string { return "book_repositories" }
+
+type bookCopy struct {
+	ID            int    `gorm:"primaryKey;autoIncrement"`
+	BookID        int    `gorm:"index;not null"`
+	Barcode       string `gorm:"uniqueIndex;not null"`
+	Condition     string `gorm:"not null;default:'good'"`
+	ShelfLocation string `gorm:"default:''"`
+	IsBorrowed    bool   `gorm:"index;default:false"`
+	BorrowerID    int    `gorm:"index;default:0"`
+	ReservedFor   int    `gorm:"index;default:0"`
+	UpdateAt      time.Time
+	CreateAt      time.Time
+}
+
+func (bookCopy) TableName() string { return "copy_repositories" }
+
+type member struct {
+	ID          int    `gorm:"primaryKey;autoIncrement"`
+	Name        string `gorm:"index;not null"`
+	Email       string `gorm:"uniqueIndex;not null"`
+	Phone       string `gorm:"default:''"`
+	IsSuspended bool   `gorm:"default:false"`
+	UpdateAt    time.Time
+	CreateAt    time.Time
+}
+
+func (member) TableName() string { return "member_repositories" }
+
+type loan struct {
+	ID         int        `gorm:"primaryKey;autoIncrement"`
+	BookID     int        `gorm:"index;not null"`
+	CopyID     int        `gorm:"index;not null;default:0"`
+	MemberID   int        `gorm:"index;not null"`
+	BorrowedAt time.Time  `gorm:"not null"`
+	DueAt      time.Time  `gorm:"index;not null"`
+	ReturnedAt *time.Time `gorm:"index"`
+	RenewCount int        `gorm:"not null;default:0"`
+	RenewedAt  *time.Time
+}
+
+func (loan) TableName() string { return "loan_repositories" }
+
+type fine struct {
+	ID       int        `gorm:"primaryKey;autoIncrement"`
+	LoanID   int        `gorm:"uniqueIndex;not null"`
+	MemberID int        `gorm:"index;not null"`
+	BookID   int        `gorm:"index;not null"`
+	Amount   float64    `gorm:"not null"`
+	DaysLate int        `gorm:"not null"`
+	PaidAt   *time.Time `gorm:"index"`
+	CreateAt time.Time
+}
+
+func (fine) TableName() string { return "fine_repositories" }
+
+type hold struct {
+	ID       int    `gorm:"primaryKey;autoIncrement"`
+	BookID   int    `gorm:"index;not null"`
+	MemberID int    `gorm:"index;not null"`
+	Status   string `gorm:"index;not null"`
+	CopyID   int    `gorm:"default:0"`
+	ReadyAt  *time.Time
+	ExpireAt *time.Time `gorm:"index"`
+	UpdateAt time.Time
+	CreateAt time.Time
+}
+
+func (hold) TableName() string { return "hold_repositories" }
+
+type user struct {
+	ID           int    `gorm:"primaryKey;autoIncrement"`
+	Username     string `gorm:"uniqueIndex;not null"`
+	PasswordHash string `gorm:"not null"`
+	Role         string `gorm:"not null"`
+	MemberID     int    `gorm:"index;default:0"`
+	UpdateAt     time.Time
+	CreateAt     time.Time
+}
+
+func (user) TableName() string { return "user_repositories" }
+
+type apiKey struct {
+	ID        int        `gorm:"primaryKey;autoIncrement"`
+	Name      string     `gorm:"not null"`
+	Prefix    string     `gorm:"not null"`
+	KeyHash   string     `gorm:"uniqueIndex;not null"`
+	Scope     string     `gorm:"not null"`
+	RevokedAt *time.Time `gorm:"index"`
+	UpdateAt  time.Time
+	CreateAt  time.Time
+}
+
+func (apiKey) TableName() string { return "api_key_repositories" }
+
+type audit struct {
+	ID        int       `gorm:"primaryKey;autoIncrement"`
+	Entity    string    `gorm:"index:idx_audit_entity;not null"`
+	EntityID  int       `gorm:"index:idx_audit_entity;not null"`
+	Action    string    `gorm:"not null"`
+	Actor     string    `gorm:"not null"`
+	ActorID   int       `gorm:"default:0"`
+	ActorRole string    `gorm:"default:''"`
+	RequestID string    `gorm:"index;default:''"`
+	Before    string    `gorm:"type:text;not null"`
+	After     string    `gorm:"type:text;not null"`
+	CreateAt  time.Time `gorm:"index"`
+}
+
+func (audit) TableName() string { return "audit_repositories" }
@@ -0,0 +1,34 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// splitBookCopies moves the circulation state of databases created before
+// titles and copies were split: every legacy book row becomes one copy and the
+// old book columns are dropped. Only sqlite databases predate the split, and
+// on newer ones it does nothing, so there is nothing to revert.
+var splitBookCopies = Migration{
+	Version: 2,
+	Name:    "split book copies",
+	Up: func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(&book{}, "is_borrowed") {
+			return nil
+		}
+		err := tx.Exec(`INSERT INTO copy_repositories (book_id, barcode, condition, shelf_location, is_borrowed, borrower_id, update_at, create_at)
+			SELECT b.id, 'LEGACY-' || b.id, 'good', '', b.is_borrowed, b.borrower_id, b.update_at, b.create_at
+			FROM book_repositories b
+			WHERE NOT EXISTS (SELECT 1 FROM copy_repositories c WHERE c.book_id = b.id)`).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("UPDATE loan_repositories SET copy_id = (SELECT c.id FROM copy_repositories c WHERE c.book_id = loan_repositories.book_id) WHERE copy_id = 0").Error
+		if err != nil {
+			return err
+		}
+		for _, column := range []string{"is_borrowed", "borrower_id"} {
+			if err := tx.Migrator().DropColumn(&book{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,195 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one numbered step of the schema. Up and Down run in a
+// transaction together with the schema_migrations row that records them;
+// mysql commits DDL implicitly, so a failed step there may need manual repair.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	// Down reverts Up; nil when Up has nothing to revert
+	Down func(tx *gorm.DB) error
+}
+
+// All are the migrations of the library schema in version order. Append new
+// ones with the next version and never edit an applied one.
+var All = []Migration{
+	baseline,
+	splitBookCopies,
+}
+
+// ErrSchemaTooNew is returned when the database has migrations this build
+// does not know, written by a newer release.
+var ErrSchemaTooNew = errors.New("database schema is newer than this build")
+
+// schemaMigration is a row of schema_migrations: one applied migration.
+type schemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status is a migration of Migrator.Status. AppliedAt is nil while it is
+// pending; Known is false for migrations applied by a newer build.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	Known     bool
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Latest is the version of the newest known migration, 0 without any.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Check returns ErrSchemaTooNew when the database has a migration that is not
+// known to this build.
+func (m *Migrator) Check() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for _, row := range applied {
+		if _, ok := m.find(row.Version); !ok {
+			return fmt.Errorf("%w: migration %d %q is applied, this build knows up to %d", ErrSchemaTooNew, row.Version, row.Name, m.Latest())
+		}
+	}
+	return nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. It stops at the first failing migration.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.Check(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	done := map[int]bool{}
+	for _, row := range applied {
+		done[row.Version] = true
+	}
+	ran := []Migration{}
+	for _, migration := range m.migrations {
+		if done[migration.Version] {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %d %q: %w", migration.Version, migration.Name, err)
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones it reverted.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := m.Check(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	ran := []Migration{}
+	for i := len(applied) - 1; i >= 0 && len(ran) < steps; i-- {
+		migration, _ := m.find(applied[i].Version)
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if migration.Down != nil {
+				if err := migration.Down(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return ran, fmt.Errorf("revert migration %d %q: %w", migration.Version, migration.Name, err)
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+// Status lists the known migrations and any unknown applied ones by version.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	rows := map[int]schemaMigration{}
+	for _, row := range applied {
+		rows[row.Version] = row
+	}
+	statuses := []Status{}
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, Known: true}
+		if row, ok := rows[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(rows, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range rows {
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// applied reads schema_migrations by version, creating it on first use.
+func (m *Migrator) applied() ([]schemaMigration, error) {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+	rows := []schemaMigration{}
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,192 @@
+package migrations_test
+
+import (
+	"errors"
+	"path/filepath"
+	"test-exam-forviz/config"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/repositories/migrations"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var tables = []interface{}{
+	&models.BookRepository{},
+	&models.CopyRepository{},
+	&models.MemberRepository{},
+	&models.LoanRepository{},
+	&models.FineRepository{},
+	&models.HoldRepository{},
+	&models.UserRepository{},
+	&models.ApiKeyRepository{},
+	&models.AuditRepository{},
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	DB, err := db.Open(config.Database{Driver: db.DriverSqlite, DSN: filepath.Join(t.TempDir(), "library.db")}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := DB.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return DB
+}
+
+func versions(migrations []migrations.Migration) []int {
+	result := []int{}
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	DB := openDB(t)
+	migrator := migrations.New(DB, migrations.All)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions(applied))
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.Down(1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, versions(reverted))
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+
+	reverted, err = migrator.Down(5)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, versions(reverted))
+	assert.False(t, DB.Migrator().HasTable(&models.BookRepository{}))
+
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions(applied))
+}
+
+// TestMigrationsMatchModels fails when a model changes without a migration.
+func TestMigrationsMatchModels(t *testing.T) {
+	DB := openDB(t)
+	_, err := migrations.New(DB, migrations.All).Up()
+	require.NoError(t, err)
+
+	for _, model := range tables {
+		stmt := &gorm.Statement{DB: DB}
+		require.NoError(t, stmt.Parse(model))
+		require.True(t, DB.Migrator().HasTable(model), stmt.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || field.IgnoreMigration {
+				continue
+			}
+			assert.True(t, DB.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Table, field.DBName)
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			assert.True(t, DB.Migrator().HasIndex(model, index.Name), "%s %s", stmt.Table, index.Name)
+		}
+	}
+}
+
+func TestMigrateSchemaTooNew(t *testing.T) {
+	DB := openDB(t)
+	_, err := migrations.New(DB, migrations.All).Up()
+	require.NoError(t, err)
+
+	newer := append([]migrations.Migration{}, migrations.All...)
+	newer = append(newer, migrations.Migration{Version: 99, Name: "from the future", Up: func(tx *gorm.DB) error { return nil }})
+	_, err = migrations.New(DB, newer).Up()
+	require.NoError(t, err)
+
+	migrator := migrations.New(DB, migrations.All)
+	assert.True(t, errors.Is(migrator.Check(), migrations.ErrSchemaTooNew))
+	_, err = migrator.Up()
+	assert.True(t, errors.Is(err, migrations.ErrSchemaTooNew))
+	_, err = migrator.Down(1)
+	assert.True(t, errors.Is(err, migrations.ErrSchemaTooNew))
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.Equal(t, 99, statuses[2].Version)
+	assert.False(t, statuses[2].Known)
+}
+
+func TestMigrateFailureRollsBack(t *testing.T) {
+	DB := openDB(t)
+	failing := append([]migrations.Migration{}, migrations.All...)
+	failing = append(failing, migrations.Migration{
+		Version: 3,
+		Name:    "fails",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("UPDATE book_repositories SET version = 2").Error; err != nil {
+				return err
+			}
+			return errors.New("broken")
+		},
+	})
+
+	applied, err := migrations.New(DB, failing).Up()
+	assert.Error(t, err)
+	assert.Equal(t, []int{1, 2}, versions(applied))
+	statuses, err := migrations.New(DB, failing).Status()
+	require.NoError(t, err)
+	assert.Nil(t, statuses[2].AppliedAt)
+}
+
+// legacyBook is the book row from before titles and copies were split.
+type legacyBook struct {
+	ID         int    `gorm:"primaryKey;autoIncrement"`
+	Title      string `gorm:"not null"`
+	Author     string `gorm:"not null"`
+	Category   string `gorm:"not null"`
+	IsBorrowed bool
+	BorrowerID int
+	UpdateAt   time.Time
+	CreateAt   time.Time
+}
+
+func (legacyBook) TableName() string { return "book_repositories" }
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	DB := openDB(t)
+	require.NoError(t, DB.AutoMigrate(&legacyBook{}, &models.LoanRepository{}))
+	require.NoError(t, DB.Create(&legacyBook{Title: "title test1", Author: "author test1", Category: "category test1", IsBorrowed: true, BorrowerID: 7}).Error)
+	require.NoError(t, DB.Create(&models.LoanRepository{BookID: 1, MemberID: 7, BorrowedAt: time.Now(), DueAt: time.Now()}).Error)
+
+	_, err := migrations.New(DB, migrations.All).Up()
+	require.NoError(t, err)
+
+	assert.False(t, DB.Migrator().HasColumn(&models.BookRepository{}, "is_borrowed"))
+	copies := []models.CopyRepository{}
+	require.NoError(t, DB.Find(&copies).Error)
+	require.Len(t, copies, 1)
+	assert.Equal(t, "LEGACY-1", copies[0].Barcode)
+	assert.True(t, copies[0].IsBorrowed)
+	assert.Equal(t, 7, copies[0].BorrowerID)
+	loan := models.LoanRepository{}
+	require.NoError(t, DB.First(&loan).Error)
+	assert.Equal(t, copies[0].ID, loan.CopyID)
+}
+
+func TestMigrateAutoMigratedDatabase(t *testing.T) {
+	DB := openDB(t)
+	require.NoError(t, DB.AutoMigrate(tables...))
+	require.NoError(t, DB.Create(&models.BookRepository{Title: "title test1", Author: "author test1", Category: "category test1"}).Error)
+
+	applied, err := migrations.New(DB, migrations.All).Up()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions(applied))
+	var count int64
+	require.NoError(t, DB.Model(&models.BookRepository{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
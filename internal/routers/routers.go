@@ -2,27 +2,85 @@ package routers
 
 import (
 	"test-exam-forviz/internal/handlers"
+	"test-exam-forviz/internal/models"
 	"test-exam-forviz/internal/services"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-func InitRouter(bookSvc services.BookService) *echo.Echo {
+func InitRouter(bookSvc services.BookService, copySvc services.CopyService, holdSvc services.HoldService, memberSvc services.MemberService, fineSvc services.FineService, authSvc services.AuthService, apiKeySvc services.ApiKeyService, auditSvc services.AuditService) *echo.Echo {
 	e := echo.New()
+	// the request id is kept with every audit entry
+	e.Use(middleware.RequestID())
 	e.Use(middleware.Logger())
-	e.Use(middleware.CORS())
+	// browsers may only read the ETag they send back in If-Match when it is exposed
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{ExposeHeaders: []string{handlers.HeaderETag}}))
 	e.Use(middleware.Recover())
+	// catalog reads stay public; staff manage the catalog and members,
+	// and members may only borrow, renew and hold for themselves.
+	// API keys pass as their scope: read-only keys read loans and members,
+	// circulation keys also run the desk, admin keys act as admins.
+	authenticate := handlers.Authenticate(authSvc, apiKeySvc)
+	admin := []echo.MiddlewareFunc{authenticate, handlers.RequireRole(models.RoleAdmin)}
+	staff := []echo.MiddlewareFunc{authenticate, handlers.RequireRole(models.RoleAdmin, models.RoleLibrarian)}
+	reader := []echo.MiddlewareFunc{authenticate, handlers.RequireRole(models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation, models.RoleReadOnly)}
+	desk := []echo.MiddlewareFunc{authenticate, handlers.RequireRole(models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation)}
+	patron := []echo.MiddlewareFunc{authenticate, handlers.RequireRole(models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation, models.RoleMember)}
+	patronReader := []echo.MiddlewareFunc{authenticate, handlers.RequireRole(models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation, models.RoleReadOnly, models.RoleMember)}
+	//auth
+	authHandle := handlers.NewAuthHandlers(authSvc)
+	e.POST("/auth/login", authHandle.LoginHandler)
+	e.POST("/user/create", authHandle.CreateUserHandler, admin...)
+	//api key
+	apiKeyHandle := handlers.NewApiKeyHandlers(apiKeySvc)
+	apiKeyApi := e.Group("/apikey")
+	apiKeyApi.POST("/create", apiKeyHandle.CreateApiKeyHandler, admin...)
+	apiKeyApi.GET("/list", apiKeyHandle.GetApiKeysHandler, admin...)
+	apiKeyApi.PATCH("/revoke/:id", apiKeyHandle.RevokeApiKeyHandler, admin...)
+	//audit
+	auditHandle := handlers.NewAuditHandlers(auditSvc)
+	e.GET("/audit", auditHandle.GetAuditLogsHandler, admin...)
 	//book
 	bookHandle := handlers.NewBookHandlers(bookSvc)
 	api := e.Group("/book")
-	api.POST("/create", bookHandle.CreateBookHandler)
+	api.POST("/create", bookHandle.CreateBookHandler, staff...)
+	api.POST("/import", bookHandle.ImportBooksHandler, staff...)
 	api.GET("/list", bookHandle.SearchBooksHandler)
 	api.GET("/summary", bookHandle.GetMostBorrowedBooksHandler)
+	api.GET("/export", bookHandle.ExportBooksHandler, reader...)
+	api.GET("/overdue", bookHandle.GetOverdueLoansHandler, reader...)
+	api.GET("/trash", bookHandle.GetTrashHandler, staff...)
+	api.GET("/isbn/:isbn", bookHandle.GetBookByISBNHandler)
 	api.GET("/:id", bookHandle.GetBookByIDHandler)
-	api.PUT("/:id", bookHandle.UpdateBookHandler)
-	api.DELETE("/:id", bookHandle.DeleteBookHandler)
-	api.PATCH("/borrow/:id", bookHandle.BorrowBookHandler)
-	api.PATCH("/return/:id", bookHandle.ReturnBookHandler)
+	api.GET("/:id/loans", bookHandle.GetBookLoansHandler, reader...)
+	api.PUT("/:id", bookHandle.UpdateBookHandler, staff...)
+	api.DELETE("/:id", bookHandle.DeleteBookHandler, staff...)
+	api.POST("/:id/restore", bookHandle.RestoreBookHandler, staff...)
+	api.PATCH("/borrow/:id", bookHandle.BorrowBookHandler, patron...)
+	api.PATCH("/return/:id", bookHandle.ReturnBookHandler, desk...)
+	api.PATCH("/renew/:id", bookHandle.RenewBookHandler, patron...)
+	//copy
+	copyHandle := handlers.NewCopyHandlers(copySvc)
+	api.POST("/:id/copies", copyHandle.CreateCopyHandler, staff...)
+	api.GET("/:id/copies", copyHandle.GetCopiesHandler, reader...)
+	api.PUT("/copy/:id", copyHandle.UpdateCopyHandler, staff...)
+	//hold
+	holdHandle := handlers.NewHoldHandlers(holdSvc)
+	api.POST("/:id/hold", holdHandle.PlaceHoldHandler, patron...)
+	api.DELETE("/:id/hold", holdHandle.CancelHoldHandler, patron...)
+	api.GET("/:id/holds", holdHandle.GetHoldsHandler, reader...)
+	//member
+	memberHandle := handlers.NewMemberHandlers(memberSvc)
+	memberApi := e.Group("/member")
+	memberApi.POST("/create", memberHandle.CreateMemberHandler, staff...)
+	memberApi.GET("/list", memberHandle.GetMembersHandler, reader...)
+	memberApi.PUT("/:id", memberHandle.UpdateMemberHandler, staff...)
+	memberApi.PATCH("/suspend/:id", memberHandle.SuspendMemberHandler, staff...)
+	//fine
+	fineHandle := handlers.NewFineHandlers(fineSvc)
+	memberApi.GET("/:id/fines", fineHandle.GetMemberFinesHandler, patronReader...)
+	fineApi := e.Group("/fine")
+	fineApi.PATCH("/pay/:id", fineHandle.PayFineHandler, staff...)
 	return e
 }
@@ -0,0 +1,393 @@
+package routers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/handlers"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/routers"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const secretKey = "secret test"
+
+// stubBookVersion is the version of every book the stub book service knows.
+const stubBookVersion = 3
+
+// the stubs answer every call with an empty success so a request that passes
+// the auth layer ends with the handler's success status.
+type stubBookService struct{ services.BookService }
+
+func (stubBookService) checkVersion(version int) error {
+	if version != 0 && version != stubBookVersion {
+		return errs.NewPreconditionFailedError(constant.BookVersionMismatchErrorMessage)
+	}
+	return nil
+}
+
+func (stubBookService) CreateBook(actor models.Actor, book models.BookRequest) (models.BookResponse, error) {
+	return models.BookResponse{}, nil
+}
+func (s stubBookService) UpdateBook(actor models.Actor, id, version int, book models.BookRequest) (models.BookResponse, error) {
+	return models.BookResponse{}, s.checkVersion(version)
+}
+func (stubBookService) DeleteBook(actor models.Actor, id int) (models.BookResponse, error) {
+	return models.BookResponse{}, nil
+}
+func (stubBookService) RestoreBook(actor models.Actor, id int) (models.BookResponse, error) {
+	return models.BookResponse{}, nil
+}
+func (stubBookService) GetTrash(page, pageSize int) (models.BookListResponse, error) {
+	return models.BookListResponse{}, nil
+}
+func (stubBookService) GetBookByID(id int) (models.BookResponse, error) {
+	return models.BookResponse{Data: &models.BookData{ID: id, Version: stubBookVersion}}, nil
+}
+func (stubBookService) GetBookByISBN(isbnCode string) (models.BookResponse, error) {
+	return models.BookResponse{}, nil
+}
+func (stubBookService) ImportBooks(actor models.Actor, rows services.BookImportReader, dryRun bool) (models.BookImportResponse, error) {
+	// the file is only parsed as it is read, so read it to surface a bad one
+	for {
+		if _, err := rows.Read(); err == io.EOF {
+			return models.BookImportResponse{}, nil
+		} else if err != nil {
+			return models.BookImportResponse{}, err
+		}
+	}
+}
+func (stubBookService) ExportBooks(search models.BookSearchRequest, format string, w io.Writer) error {
+	return nil
+}
+func (stubBookService) SearchBooks(search models.BookSearchRequest) (models.BookListResponse, error) {
+	return models.BookListResponse{}, nil
+}
+func (stubBookService) GetMostBorrowedBooks() (models.BookListResponse, error) {
+	return models.BookListResponse{}, nil
+}
+func (stubBookService) GetBookLoans(id int) (models.LoanListResponse, error) {
+	return models.LoanListResponse{}, nil
+}
+func (stubBookService) GetOverdueLoans() (models.LoanListResponse, error) {
+	return models.LoanListResponse{}, nil
+}
+func (s stubBookService) BorrowBook(actor models.Actor, id, version, memberID, copyID int) (models.BookResponse, error) {
+	return models.BookResponse{}, s.checkVersion(version)
+}
+func (s stubBookService) ReturnBook(actor models.Actor, id, version, copyID int) (models.BookResponse, error) {
+	return models.BookResponse{}, s.checkVersion(version)
+}
+func (s stubBookService) RenewBook(actor models.Actor, id, version, memberID, copyID int) (models.BookResponse, error) {
+	return models.BookResponse{}, s.checkVersion(version)
+}
+
+type stubCopyService struct{ services.CopyService }
+
+func (stubCopyService) CreateCopy(bookID int, bookCopy models.CopyRequest) (models.CopyResponse, error) {
+	return models.CopyResponse{}, nil
+}
+func (stubCopyService) UpdateCopy(id int, bookCopy models.CopyRequest) (models.CopyResponse, error) {
+	return models.CopyResponse{}, nil
+}
+func (stubCopyService) GetCopies(bookID int) (models.CopyListResponse, error) {
+	return models.CopyListResponse{}, nil
+}
+
+type stubHoldService struct{ services.HoldService }
+
+func (stubHoldService) PlaceHold(bookID, memberID int) (models.HoldResponse, error) {
+	return models.HoldResponse{}, nil
+}
+func (stubHoldService) CancelHold(bookID, memberID int) (models.HoldResponse, error) {
+	return models.HoldResponse{}, nil
+}
+func (stubHoldService) GetHolds(bookID int) (models.HoldListResponse, error) {
+	return models.HoldListResponse{}, nil
+}
+
+type stubMemberService struct{ services.MemberService }
+
+func (stubMemberService) CreateMember(member models.MemberRequest) (models.MemberResponse, error) {
+	return models.MemberResponse{}, nil
+}
+func (stubMemberService) UpdateMember(id int, member models.MemberRequest) (models.MemberResponse, error) {
+	return models.MemberResponse{}, nil
+}
+func (stubMemberService) SuspendMember(id int) (models.MemberResponse, error) {
+	return models.MemberResponse{}, nil
+}
+func (stubMemberService) GetMembers() (models.MemberListResponse, error) {
+	return models.MemberListResponse{}, nil
+}
+
+type stubFineService struct{ services.FineService }
+
+func (stubFineService) GetOutstandingFines(memberID int) (models.FineListResponse, error) {
+	return models.FineListResponse{}, nil
+}
+func (stubFineService) PayFine(id int) (models.FineResponse, error) {
+	return models.FineResponse{}, nil
+}
+
+// stubAuthService keeps the real token parsing of the embedded service.
+type stubAuthService struct{ services.AuthService }
+
+func (stubAuthService) Login(username, password string) (models.TokenResponse, error) {
+	return models.TokenResponse{}, nil
+}
+func (stubAuthService) CreateUser(user models.UserRequest) (models.UserResponse, error) {
+	return models.UserResponse{}, nil
+}
+
+type stubAuditService struct{ services.AuditService }
+
+func (stubAuditService) GetAuditLogs(search models.AuditSearchRequest) (models.AuditListResponse, error) {
+	return models.AuditListResponse{}, nil
+}
+
+// stubApiKeyService knows one key per scope and one revoked key.
+type stubApiKeyService struct{ services.ApiKeyService }
+
+func (stubApiKeyService) CreateApiKey(apiKey models.ApiKeyRequest) (models.ApiKeyResponse, error) {
+	return models.ApiKeyResponse{}, nil
+}
+func (stubApiKeyService) RevokeApiKey(id int) (models.ApiKeyResponse, error) {
+	return models.ApiKeyResponse{}, nil
+}
+func (stubApiKeyService) GetApiKeys() (models.ApiKeyListResponse, error) {
+	return models.ApiKeyListResponse{}, nil
+}
+func (stubApiKeyService) ParseKey(key string) (*services.AuthClaims, error) {
+	scope, found := strings.CutPrefix(key, "key-")
+	if !found || scope == "revoked" {
+		return nil, errs.NewUnauthorizedError(constant.ApiKeyInvalidErrorMessage)
+	}
+	return &services.AuthClaims{Role: scope}, nil
+}
+
+func newTestRouter() *echo.Echo {
+	authSvc := services.NewAuthService(db.NewUserRepositoryMock(), db.NewMemberRepositoryMock(), config.Auth{SecretKey: secretKey})
+	return routers.InitRouter(stubBookService{}, stubCopyService{}, stubHoldService{}, stubMemberService{}, stubFineService{}, stubAuthService{authSvc}, stubApiKeyService{}, stubAuditService{})
+}
+
+func signToken(role string, memberID int) string {
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, services.AuthClaims{
+		UserID:   1,
+		Role:     role,
+		MemberID: memberID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(secretKey))
+	return token
+}
+
+func TestRouterAuthorization(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	e := newTestRouter()
+
+	// the member role acts for member 7
+	type client struct {
+		role   string
+		header string
+		value  string
+	}
+	clients := map[string]client{
+		"anonymous":             {},
+		"token admin":           {models.RoleAdmin, echo.HeaderAuthorization, "Bearer " + signToken(models.RoleAdmin, 0)},
+		"token librarian":       {models.RoleLibrarian, echo.HeaderAuthorization, "Bearer " + signToken(models.RoleLibrarian, 0)},
+		"token member":          {models.RoleMember, echo.HeaderAuthorization, "Bearer " + signToken(models.RoleMember, 7)},
+		"api key admin":         {models.RoleAdmin, handlers.HeaderApiKey, "key-" + models.RoleAdmin},
+		"api key circulation":   {models.RoleCirculation, handlers.HeaderApiKey, "key-" + models.RoleCirculation},
+		"api key read-only":     {models.RoleReadOnly, handlers.HeaderApiKey, "key-" + models.RoleReadOnly},
+		"api key revoked admin": {"", handlers.HeaderApiKey, "key-revoked"},
+	}
+	var (
+		public       []string
+		admin        = []string{models.RoleAdmin}
+		staff        = []string{models.RoleAdmin, models.RoleLibrarian}
+		reader       = []string{models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation, models.RoleReadOnly}
+		desk         = []string{models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation}
+		patron       = []string{models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation, models.RoleMember}
+		patronReader = []string{models.RoleAdmin, models.RoleLibrarian, models.RoleCirculation, models.RoleReadOnly, models.RoleMember}
+	)
+	testCases := []struct {
+		method  string
+		path    string
+		body    string
+		roles   []string
+		success int
+		// forOther marks a patron route used by member 7 for member 8
+		forOther bool
+	}{
+		{http.MethodPost, "/auth/login", `{"username":"admin","password":"password1"}`, public, http.StatusOK, false},
+		{http.MethodPost, "/user/create", `{"username":"librarian1","password":"password1","role":"librarian"}`, admin, http.StatusCreated, false},
+		{http.MethodPost, "/apikey/create", `{"name":"kiosk","scope":"circulation"}`, admin, http.StatusCreated, false},
+		{http.MethodGet, "/apikey/list", "", admin, http.StatusOK, false},
+		{http.MethodGet, "/audit?entity=book&id=1&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z", "", admin, http.StatusOK, false},
+		{http.MethodGet, "/audit?id=1", "", admin, http.StatusBadRequest, false},
+		{http.MethodGet, "/audit?from=yesterday", "", admin, http.StatusBadRequest, false},
+		{http.MethodPatch, "/apikey/revoke/1", "", admin, http.StatusOK, false},
+		{http.MethodPost, "/book/create", `{"title":"title","author":"author","category":"category"}`, staff, http.StatusCreated, false},
+		{http.MethodPost, "/book/create", `{"title":"title","author":"author","category":"category","isbn":"0-8044-2957-X"}`, staff, http.StatusCreated, false},
+		{http.MethodPost, "/book/create", `{"title":"title","author":"author","category":"category","isbn":"0-8044-2957-1"}`, staff, http.StatusBadRequest, false},
+		{http.MethodPost, "/book/import?format=ndjson&dry_run=true", `{"title":"title","author":"author","category":"category"}`, staff, http.StatusOK, false},
+		{http.MethodPost, "/book/import", `{"title":"title","author":"author","category":"category"}`, staff, http.StatusBadRequest, false},
+		{http.MethodPost, "/book/import?format=marcxml&dry_run=true", `<collection><record><datafield tag="100"><subfield code="a">author</subfield></datafield><datafield tag="245"><subfield code="a">title</subfield></datafield><datafield tag="650"><subfield code="a">category</subfield></datafield></record></collection>`, staff, http.StatusOK, false},
+		{http.MethodPost, "/book/import?format=marcxml", `<collection><record>`, staff, http.StatusBadRequest, false},
+		{http.MethodGet, "/book/list", "", public, http.StatusOK, false},
+		{http.MethodGet, "/book/summary", "", public, http.StatusOK, false},
+		{http.MethodGet, "/book/export?format=marc21", "", reader, http.StatusOK, false},
+		{http.MethodGet, "/book/export?format=xml", "", reader, http.StatusBadRequest, false},
+		{http.MethodGet, "/book/overdue", "", reader, http.StatusOK, false},
+		{http.MethodGet, "/book/trash", "", staff, http.StatusOK, false},
+		{http.MethodGet, "/book/trash?page_size=500", "", staff, http.StatusBadRequest, false},
+		{http.MethodGet, "/book/1", "", public, http.StatusOK, false},
+		{http.MethodGet, "/book/isbn/978-0-306-40615-7", "", public, http.StatusOK, false},
+		{http.MethodGet, "/book/isbn/978-0-306-40615-8", "", public, http.StatusBadRequest, false},
+		{http.MethodGet, "/book/1/loans", "", reader, http.StatusOK, false},
+		{http.MethodPut, "/book/1", `{"title":"title","author":"author","category":"category"}`, staff, http.StatusOK, false},
+		{http.MethodDelete, "/book/1", "", staff, http.StatusOK, false},
+		{http.MethodPost, "/book/1/restore", "", staff, http.StatusOK, false},
+		{http.MethodPatch, "/book/borrow/1", `{"member_id":7}`, patron, http.StatusOK, false},
+		{http.MethodPatch, "/book/borrow/1", `{"member_id":8}`, patron, http.StatusOK, true},
+		{http.MethodPatch, "/book/return/1", `{}`, desk, http.StatusOK, false},
+		{http.MethodPatch, "/book/renew/1", `{"member_id":7}`, patron, http.StatusOK, false},
+		{http.MethodPatch, "/book/renew/1", `{"member_id":8}`, patron, http.StatusOK, true},
+		{http.MethodPost, "/book/1/copies", `{"barcode":"B-0001","condition":"new"}`, staff, http.StatusCreated, false},
+		{http.MethodGet, "/book/1/copies", "", reader, http.StatusOK, false},
+		{http.MethodPut, "/book/copy/1", `{"barcode":"B-0001","condition":"good"}`, staff, http.StatusOK, false},
+		{http.MethodPost, "/book/1/hold", `{"member_id":7}`, patron, http.StatusCreated, false},
+		{http.MethodPost, "/book/1/hold", `{"member_id":8}`, patron, http.StatusCreated, true},
+		{http.MethodDelete, "/book/1/hold?member_id=7", "", patron, http.StatusOK, false},
+		{http.MethodDelete, "/book/1/hold?member_id=8", "", patron, http.StatusOK, true},
+		{http.MethodGet, "/book/1/holds", "", reader, http.StatusOK, false},
+		{http.MethodPost, "/member/create", `{"name":"name","email":"name@example.com"}`, staff, http.StatusCreated, false},
+		{http.MethodGet, "/member/list", "", reader, http.StatusOK, false},
+		{http.MethodPut, "/member/1", `{"name":"name","email":"name@example.com"}`, staff, http.StatusOK, false},
+		{http.MethodPatch, "/member/suspend/1", "", staff, http.StatusOK, false},
+		{http.MethodGet, "/member/7/fines", "", patronReader, http.StatusOK, false},
+		{http.MethodGet, "/member/8/fines", "", patronReader, http.StatusOK, true},
+		{http.MethodPatch, "/fine/pay/1", "", staff, http.StatusOK, false},
+	}
+	for _, tC := range testCases {
+		for name, cl := range clients {
+			expect := tC.success
+			switch {
+			case tC.roles == nil:
+			case cl.role == "":
+				expect = http.StatusUnauthorized
+			case !slices.Contains(tC.roles, cl.role):
+				expect = http.StatusForbidden
+			case tC.forOther && cl.role == models.RoleMember:
+				expect = http.StatusForbidden
+			}
+			t.Run(tC.method+" "+tC.path+" "+name, func(t *testing.T) {
+				req := httptest.NewRequest(tC.method, tC.path, strings.NewReader(tC.body))
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				if cl.header != "" {
+					req.Header.Set(cl.header, cl.value)
+				}
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+				assert.Equal(t, expect, rec.Code, rec.Body.String())
+			})
+		}
+	}
+}
+
+func TestRouterBookPreconditions(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	e := newTestRouter()
+	token := "Bearer " + signToken(models.RoleAdmin, 0)
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		header     string
+		value      string
+		expectCode int
+		expectETag string
+	}{
+		{"get", http.MethodGet, "/book/1", "", "", "", http.StatusOK, `"3"`},
+		{"get if-none-match current", http.MethodGet, "/book/1", "", handlers.HeaderIfNoneMatch, `"3"`, http.StatusNotModified, `"3"`},
+		{"get if-none-match stale", http.MethodGet, "/book/1", "", handlers.HeaderIfNoneMatch, `"2"`, http.StatusOK, `"3"`},
+		{"put if-match current", http.MethodPut, "/book/1", `{"title":"title","author":"author","category":"category"}`, handlers.HeaderIfMatch, `"3"`, http.StatusOK, ""},
+		{"put if-match any", http.MethodPut, "/book/1", `{"title":"title","author":"author","category":"category"}`, handlers.HeaderIfMatch, "*", http.StatusOK, ""},
+		{"put if-match stale", http.MethodPut, "/book/1", `{"title":"title","author":"author","category":"category"}`, handlers.HeaderIfMatch, `"2"`, http.StatusPreconditionFailed, ""},
+		{"put if-match weak", http.MethodPut, "/book/1", `{"title":"title","author":"author","category":"category"}`, handlers.HeaderIfMatch, `W/"3"`, http.StatusPreconditionFailed, ""},
+		{"put if-match malformed", http.MethodPut, "/book/1", `{"title":"title","author":"author","category":"category"}`, handlers.HeaderIfMatch, "three", http.StatusPreconditionFailed, ""},
+		{"borrow if-match stale", http.MethodPatch, "/book/borrow/1", `{"member_id":7}`, handlers.HeaderIfMatch, `"2"`, http.StatusPreconditionFailed, ""},
+		{"return if-match current", http.MethodPatch, "/book/return/1", `{}`, handlers.HeaderIfMatch, `"3"`, http.StatusOK, ""},
+		{"return if-match stale", http.MethodPatch, "/book/return/1", `{}`, handlers.HeaderIfMatch, `"2"`, http.StatusPreconditionFailed, ""},
+		{"renew if-match stale", http.MethodPatch, "/book/renew/1", `{"member_id":7}`, handlers.HeaderIfMatch, `"2"`, http.StatusPreconditionFailed, ""},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			req := httptest.NewRequest(tC.method, tC.path, strings.NewReader(tC.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(echo.HeaderAuthorization, token)
+			if tC.header != "" {
+				req.Header.Set(tC.header, tC.value)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tC.expectCode, rec.Code, rec.Body.String())
+			assert.Equal(t, tC.expectETag, rec.Header().Get(handlers.HeaderETag))
+		})
+	}
+}
+
+func TestRouterRejectsInvalidToken(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	e := newTestRouter()
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, services.AuthClaims{
+		Role: models.RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	}).SignedString([]byte(secretKey))
+	for name, header := range map[string]string{
+		"expired":   "Bearer " + expired,
+		"malformed": "Bearer not-a-token",
+		"no scheme": signToken(models.RoleAdmin, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/book/1", nil)
+			req.Header.Set(echo.HeaderAuthorization, header)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+}
+
+func TestRouterImportTooLarge(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	e := newTestRouter()
+	req := httptest.NewRequest(http.MethodPost, "/book/import?format=ndjson", strings.NewReader(`{"title":"title","author":"author","category":"category"}`))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+signToken(models.RoleAdmin, 0))
+	req.ContentLength = 64 << 20
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const apiKeyPrefix = "lk_"
+
+type apiKeyService struct {
+	repo db.ApiKeyRepository
+}
+
+// CreateApiKey implements ApiKeyService.
+// The plain key is only returned here; the database keeps its hash.
+func (a apiKeyService) CreateApiKey(apiKey models.ApiKeyRequest) (models.ApiKeyResponse, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		loggers.Error("Error generate api key",
+			zap.String("type", "service"),
+			zap.Error(err))
+		return models.ApiKeyResponse{}, errs.NewInternalServerError(constant.ApiKeyErrorMessageInternalServerError)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	apiKeyDataCreate := models.ApiKeyRepository{
+		Name:    apiKey.Name,
+		Prefix:  key[:len(apiKeyPrefix)+8],
+		KeyHash: hashApiKey(key),
+		Scope:   apiKey.Scope,
+	}
+	err := a.repo.Create(apiKeyDataCreate)
+	if err != nil {
+		loggers.Error("Error Create api key",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.String("name", apiKey.Name),
+			zap.String("scope", apiKey.Scope))
+		return models.ApiKeyResponse{}, errs.NewInternalServerError(constant.ApiKeyErrorMessageInternalServerError)
+	}
+	return models.ApiKeyResponse{
+		Message: constant.ApiKeyCreateSuccessMessage,
+		Data: &models.ApiKeyData{
+			Name:   apiKeyDataCreate.Name,
+			Prefix: apiKeyDataCreate.Prefix,
+			Scope:  apiKeyDataCreate.Scope,
+			Key:    key,
+		},
+	}, nil
+}
+
+// RevokeApiKey implements ApiKeyService.
+func (a apiKeyService) RevokeApiKey(id int) (models.ApiKeyResponse, error) {
+	apiKey, err := a.repo.FindByID(id)
+	if err != nil {
+		loggers.Error("Error FindByID api key",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("api_key_id", id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ApiKeyResponse{}, errs.NewNotFoundError(constant.ApiKeyErrorsMessageFindNotFound)
+		} else {
+			return models.ApiKeyResponse{}, errs.NewInternalServerError(constant.ApiKeyErrorMessageInternalServerError)
+		}
+	}
+	if apiKey.RevokedAt != nil {
+		return models.ApiKeyResponse{}, errs.NewBadRequest(constant.ApiKeyRevokedErrorMessage)
+	}
+	err = a.repo.Revoke(id, time.Now())
+	if err != nil {
+		loggers.Error("Error Revoke api key",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("api_key_id", id))
+		return models.ApiKeyResponse{}, errs.NewInternalServerError(constant.ApiKeyErrorMessageInternalServerError)
+	}
+	return models.ApiKeyResponse{
+		Message: constant.ApiKeyRevokeSuccessMessage,
+	}, nil
+}
+
+// GetApiKeys implements ApiKeyService.
+func (a apiKeyService) GetApiKeys() (models.ApiKeyListResponse, error) {
+	apiKeys, err := a.repo.FindAll()
+	if err != nil {
+		loggers.Error("Error FindAll api key",
+			zap.String("type", "repo"),
+			zap.Error(err))
+		return models.ApiKeyListResponse{}, errs.NewInternalServerError(constant.ApiKeyErrorMessageInternalServerError)
+	}
+	apiKeyList := []models.ApiKeyData{}
+	for _, apiKey := range apiKeys {
+		apiKeyData := models.ApiKeyData{
+			ID:       apiKey.ID,
+			Name:     apiKey.Name,
+			Prefix:   apiKey.Prefix,
+			Scope:    apiKey.Scope,
+			CreateAt: apiKey.CreateAt.Format(dateTimeFormat),
+		}
+		if apiKey.RevokedAt != nil {
+			apiKeyData.RevokedAt = apiKey.RevokedAt.Format(dateTimeFormat)
+		}
+		apiKeyList = append(apiKeyList, apiKeyData)
+	}
+	return models.ApiKeyListResponse{
+		Message: constant.ApiKeyGetSuccessMessage,
+		Data:    apiKeyList,
+	}, nil
+}
+
+// ParseKey implements ApiKeyService.
+// The key's scope becomes the role checked by the router.
+func (a apiKeyService) ParseKey(key string) (*AuthClaims, error) {
+	apiKey, err := a.repo.FindByHash(hashApiKey(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NewUnauthorizedError(constant.ApiKeyInvalidErrorMessage)
+		}
+		loggers.Error("Error FindByHash api key",
+			zap.String("type", "repo"),
+			zap.Error(err))
+		return nil, errs.NewInternalServerError(constant.ApiKeyErrorMessageInternalServerError)
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, errs.NewUnauthorizedError(constant.ApiKeyRevokedErrorMessage)
+	}
+	return &AuthClaims{
+		Role: apiKey.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "apikey:" + apiKey.Name,
+		},
+	}, nil
+}
+
+// hashApiKey needs no salt or stretching: keys are 256 random bits.
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewApiKeyService(repo db.ApiKeyRepository) ApiKeyService {
+	return apiKeyService{repo: repo}
+}
@@ -0,0 +1,192 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCreateApiKey(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name        string
+		request     models.ApiKeyRequest
+		expectError error
+	}{
+		{
+			name:        "TestCreateApiKeySuccess",
+			request:     models.ApiKeyRequest{Name: "kiosk", Scope: models.RoleCirculation},
+			expectError: nil,
+		},
+		{
+			name:        "TestCreateApiKeyErrorInternalServerError",
+			request:     models.ApiKeyRequest{Name: "kiosk", Scope: models.RoleCirculation},
+			expectError: errors.New(constant.ApiKeyErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			apiKeyRepo := db.NewApiKeyRepositoryMock()
+
+			switch tC.name {
+			case "TestCreateApiKeyErrorInternalServerError":
+				apiKeyRepo.On("Create").Return(errors.New(""))
+			default:
+				apiKeyRepo.On("Create").Return(nil)
+			}
+
+			apiKeySvc := services.NewApiKeyService(apiKeyRepo)
+			resp, err := apiKeySvc.CreateApiKey(tC.request)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, constant.ApiKeyCreateSuccessMessage, resp.Message)
+				assert.Equal(t, models.RoleCirculation, resp.Data.Scope)
+				assert.True(t, strings.HasPrefix(resp.Data.Key, resp.Data.Prefix))
+				assert.Len(t, resp.Data.Key, 67)
+			}
+
+		})
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	revokedAt := time.Now()
+	testCases := []struct {
+		name          string
+		requestId     int
+		mockData      models.ApiKeyRepository
+		expectSuccess models.ApiKeyResponse
+		expectError   error
+	}{
+		{
+			name:      "TestRevokeApiKeySuccess",
+			requestId: 1,
+			mockData:  models.ApiKeyRepository{ID: 1, Name: "kiosk", Scope: models.RoleCirculation},
+			expectSuccess: models.ApiKeyResponse{
+				Message: constant.ApiKeyRevokeSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestRevokeApiKeyFindNotFound",
+			requestId:   1,
+			mockData:    models.ApiKeyRepository{},
+			expectError: errors.New(constant.ApiKeyErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestRevokeApiKeyRevoked",
+			requestId:   1,
+			mockData:    models.ApiKeyRepository{ID: 1, Name: "kiosk", Scope: models.RoleCirculation, RevokedAt: &revokedAt},
+			expectError: errors.New(constant.ApiKeyRevokedErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			apiKeyRepo := db.NewApiKeyRepositoryMock()
+
+			apiKeyRepo.On("Revoke").Return(nil)
+			switch tC.name {
+			case "TestRevokeApiKeyFindNotFound":
+				apiKeyRepo.On("FindByID").Return(tC.mockData, gorm.ErrRecordNotFound)
+			default:
+				apiKeyRepo.On("FindByID").Return(tC.mockData, nil)
+			}
+
+			apiKeySvc := services.NewApiKeyService(apiKeyRepo)
+			resp, err := apiKeySvc.RevokeApiKey(tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestGetApiKeys(t *testing.T) {
+	const dateTimeFormat = "02/01/2006 15:04:05"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	apiKeyRepo := db.NewApiKeyRepositoryMock()
+	apiKeyRepo.On("FindAll").Return([]models.ApiKeyRepository{
+		{ID: 1, Name: "kiosk", Prefix: "lk_0123abcd", KeyHash: "hash", Scope: models.RoleCirculation, CreateAt: now},
+		{ID: 2, Name: "etl", Prefix: "lk_4567ef01", KeyHash: "hash2", Scope: models.RoleReadOnly, RevokedAt: &now, CreateAt: now},
+	}, nil)
+
+	apiKeySvc := services.NewApiKeyService(apiKeyRepo)
+	resp, err := apiKeySvc.GetApiKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, models.ApiKeyListResponse{
+		Message: constant.ApiKeyGetSuccessMessage,
+		Data: []models.ApiKeyData{
+			{ID: 1, Name: "kiosk", Prefix: "lk_0123abcd", Scope: models.RoleCirculation, CreateAt: now.Format(dateTimeFormat)},
+			{ID: 2, Name: "etl", Prefix: "lk_4567ef01", Scope: models.RoleReadOnly, RevokedAt: now.Format(dateTimeFormat), CreateAt: now.Format(dateTimeFormat)},
+		},
+	}, resp)
+}
+
+func TestParseKey(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	revokedAt := time.Now()
+	testCases := []struct {
+		name        string
+		mockData    models.ApiKeyRepository
+		expectError error
+	}{
+		{
+			name:        "TestParseKeySuccess",
+			mockData:    models.ApiKeyRepository{ID: 1, Name: "kiosk", Scope: models.RoleCirculation},
+			expectError: nil,
+		},
+		{
+			name:        "TestParseKeyFindNotFound",
+			mockData:    models.ApiKeyRepository{},
+			expectError: errors.New(constant.ApiKeyInvalidErrorMessage),
+		},
+		{
+			name:        "TestParseKeyRevoked",
+			mockData:    models.ApiKeyRepository{ID: 1, Name: "kiosk", Scope: models.RoleCirculation, RevokedAt: &revokedAt},
+			expectError: errors.New(constant.ApiKeyRevokedErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			apiKeyRepo := db.NewApiKeyRepositoryMock()
+
+			switch tC.name {
+			case "TestParseKeyFindNotFound":
+				apiKeyRepo.On("FindByHash").Return(tC.mockData, gorm.ErrRecordNotFound)
+			default:
+				apiKeyRepo.On("FindByHash").Return(tC.mockData, nil)
+			}
+
+			apiKeySvc := services.NewApiKeyService(apiKeyRepo)
+			claims, err := apiKeySvc.ParseKey("lk_0123abcd")
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, models.RoleCirculation, claims.Role)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+
+	"go.uber.org/zap"
+)
+
+// auditIdentityFields are kept in both sides of a diff even when unchanged,
+// so an entry still tells which copy a borrow or return was about.
+var auditIdentityFields = map[string]bool{"id": true, "copy_id": true}
+
+// circulationState is what the audit log keeps of a copy around a borrow,
+// renew or return.
+type circulationState struct {
+	CopyID     int     `json:"copy_id"`
+	MemberID   int     `json:"member_id,omitempty"`
+	IsBorrowed bool    `json:"is_borrowed"`
+	DueAt      string  `json:"due_at,omitempty"`
+	Fine       float64 `json:"fine,omitempty"`
+}
+
+type auditService struct {
+	repo db.AuditRepository
+}
+
+// auditDiff reduces the JSON forms of before and after to the fields that
+// differ; a nil side stays "null" and the other is kept whole.
+func auditDiff(before, after any) (string, string) {
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+	if beforeFields != nil && afterFields != nil {
+		for name, value := range beforeFields {
+			if other, ok := afterFields[name]; ok && reflect.DeepEqual(value, other) && !auditIdentityFields[name] {
+				delete(beforeFields, name)
+				delete(afterFields, name)
+			}
+		}
+		// a field missing on one side was empty there
+		for name := range afterFields {
+			if _, ok := beforeFields[name]; !ok {
+				beforeFields[name] = nil
+			}
+		}
+		for name := range beforeFields {
+			if _, ok := afterFields[name]; !ok {
+				afterFields[name] = nil
+			}
+		}
+	}
+	beforeJSON, _ := json.Marshal(beforeFields)
+	afterJSON, _ := json.Marshal(afterFields)
+	return string(beforeJSON), string(afterJSON)
+}
+
+func auditFields(value any) map[string]any {
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Pointer && reflect.ValueOf(value).IsNil() {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// newAuditEntry is the audit entry of actor's action on book bookID.
+func newAuditEntry(actor models.Actor, action string, bookID int, before, after any) models.AuditRepository {
+	beforeJSON, afterJSON := auditDiff(before, after)
+	return models.AuditRepository{
+		Entity:    models.AuditEntityBook,
+		EntityID:  bookID,
+		Action:    action,
+		Actor:     actor.Name,
+		ActorID:   actor.UserID,
+		ActorRole: actor.Role,
+		RequestID: actor.RequestID,
+		Before:    beforeJSON,
+		After:     afterJSON,
+	}
+}
+
+// auditChange records actor's action taking a book from before to after, in
+// the transaction of the write that makes it.
+func auditChange(actor models.Actor, action string, before, after any) db.AuditEntry {
+	return func(book models.BookRepository) models.AuditRepository {
+		return newAuditEntry(actor, action, book.ID, before, after)
+	}
+}
+
+// auditCreate records actor's action adding a book, once the write has
+// given it an id.
+func auditCreate(actor models.Actor, action string) db.AuditEntry {
+	return func(book models.BookRepository) models.AuditRepository {
+		return newAuditEntry(actor, action, book.ID, nil, toBookData(book))
+	}
+}
+
+// GetAuditLogs implements AuditService.
+// Entries come a page at a time, newest first.
+func (a auditService) GetAuditLogs(search models.AuditSearchRequest) (models.AuditListResponse, error) {
+	page := search.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := search.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	entries, total, err := a.repo.FindAll(models.AuditFilter{
+		Entity:   search.Entity,
+		EntityID: search.EntityID,
+		From:     search.From,
+		To:       search.To,
+		Offset:   (page - 1) * pageSize,
+		Limit:    pageSize,
+	})
+	if err != nil {
+		loggers.Error("Error FindAll audit",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("search", search))
+		return models.AuditListResponse{}, errs.NewInternalServerError(constant.AuditErrorMessageInternalServerError)
+	}
+	auditList := []models.AuditData{}
+	for _, entry := range entries {
+		auditList = append(auditList, models.AuditData{
+			ID:        entry.ID,
+			Entity:    entry.Entity,
+			EntityID:  entry.EntityID,
+			Action:    entry.Action,
+			Actor:     entry.Actor,
+			ActorID:   entry.ActorID,
+			ActorRole: entry.ActorRole,
+			RequestID: entry.RequestID,
+			Before:    json.RawMessage(entry.Before),
+			After:     json.RawMessage(entry.After),
+			CreateAt:  entry.CreateAt.Format(dateTimeFormat),
+		})
+	}
+	return models.AuditListResponse{
+		Message:  constant.AuditGetSuccessMessage,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Data:     auditList,
+	}, nil
+}
+
+func NewAuditService(repo db.AuditRepository) AuditService {
+	return auditService{repo: repo}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditDiff(t *testing.T) {
+	type book struct {
+		ID       int    `json:"id"`
+		Title    string `json:"title"`
+		Author   string `json:"author"`
+		ISBN13   string `json:"isbn13,omitempty"`
+		Category string `json:"category"`
+	}
+	testCases := []struct {
+		name         string
+		before       any
+		after        any
+		expectBefore string
+		expectAfter  string
+	}{
+		{
+			name:         "TestAuditDiffCreate",
+			before:       nil,
+			after:        book{ID: 1, Title: "title test1", Author: "author test1", Category: "category test1"},
+			expectBefore: "null",
+			expectAfter:  `{"author":"author test1","category":"category test1","id":1,"title":"title test1"}`,
+		},
+		{
+			name:         "TestAuditDiffUpdate",
+			before:       book{ID: 1, Title: "title test1", Author: "author test1", Category: "category test1"},
+			after:        book{ID: 1, Title: "title test2", Author: "author test1", Category: "category test1", ISBN13: "9780306406157"},
+			expectBefore: `{"id":1,"isbn13":null,"title":"title test1"}`,
+			expectAfter:  `{"id":1,"isbn13":"9780306406157","title":"title test2"}`,
+		},
+		{
+			name:         "TestAuditDiffBorrow",
+			before:       circulationState{CopyID: 3},
+			after:        circulationState{CopyID: 3, MemberID: 7, IsBorrowed: true, DueAt: "15/01/2025 10:00:00"},
+			expectBefore: `{"copy_id":3,"due_at":null,"is_borrowed":false,"member_id":null}`,
+			expectAfter:  `{"copy_id":3,"due_at":"15/01/2025 10:00:00","is_borrowed":true,"member_id":7}`,
+		},
+		{
+			name:         "TestAuditDiffDelete",
+			before:       book{ID: 1, Title: "title test1"},
+			after:        nil,
+			expectBefore: `{"author":"","category":"","id":1,"title":"title test1"}`,
+			expectAfter:  "null",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			before, after := auditDiff(tC.before, tC.after)
+			assert.Equal(t, tC.expectBefore, before)
+			assert.Equal(t, tC.expectAfter, after)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testActor = models.Actor{Name: "librarian1", UserID: 2, Role: models.RoleLibrarian, RequestID: "request-1"}
+
+func TestGetAuditLogs(t *testing.T) {
+	const dateTimeFormat = "02/01/2006 15:04:05"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	testCases := []struct {
+		name          string
+		mockData      []models.AuditRepository
+		expectSuccess models.AuditListResponse
+		expectError   error
+	}{
+		{
+			name: "TestGetAuditLogsSuccess",
+			mockData: []models.AuditRepository{
+				{ID: 2, Entity: models.AuditEntityBook, EntityID: 1, Action: models.AuditActionUpdate, Actor: "librarian1", ActorID: 2, ActorRole: models.RoleLibrarian, RequestID: "request-1", Before: `{"title":"title test1"}`, After: `{"title":"title test2"}`, CreateAt: now},
+				{ID: 1, Entity: models.AuditEntityBook, EntityID: 1, Action: models.AuditActionCreate, Actor: "admin", ActorID: 1, ActorRole: models.RoleAdmin, Before: "null", After: `{"id":1,"title":"title test1"}`, CreateAt: now},
+			},
+			expectSuccess: models.AuditListResponse{
+				Message:  constant.AuditGetSuccessMessage,
+				Total:    2,
+				Page:     1,
+				PageSize: 20,
+				Data: []models.AuditData{
+					{ID: 2, Entity: models.AuditEntityBook, EntityID: 1, Action: models.AuditActionUpdate, Actor: "librarian1", ActorID: 2, ActorRole: models.RoleLibrarian, RequestID: "request-1", Before: json.RawMessage(`{"title":"title test1"}`), After: json.RawMessage(`{"title":"title test2"}`), CreateAt: now.Format(dateTimeFormat)},
+					{ID: 1, Entity: models.AuditEntityBook, EntityID: 1, Action: models.AuditActionCreate, Actor: "admin", ActorID: 1, ActorRole: models.RoleAdmin, Before: json.RawMessage("null"), After: json.RawMessage(`{"id":1,"title":"title test1"}`), CreateAt: now.Format(dateTimeFormat)},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetAuditLogsErrorInternalServerError",
+			expectError: errors.New(constant.AuditErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			auditRepo := db.NewAuditRepositoryMock()
+
+			switch tC.name {
+			case "TestGetAuditLogsErrorInternalServerError":
+				auditRepo.On("FindAll").Return([]models.AuditRepository{}, int64(0), errors.New(""))
+			default:
+				auditRepo.On("FindAll").Return(tC.mockData, int64(len(tC.mockData)), nil)
+			}
+
+			auditSvc := services.NewAuditService(auditRepo)
+			resp, err := auditSvc.GetAuditLogs(models.AuditSearchRequest{Entity: models.AuditEntityBook, EntityID: 1})
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AuthClaims is the payload of an access token. MemberID is only set for RoleMember.
+type AuthClaims struct {
+	UserID   int    `json:"uid"`
+	Role     string `json:"role"`
+	MemberID int    `json:"member_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type authService struct {
+	repo       db.UserRepository
+	memberRepo db.MemberRepository
+	cfg        config.Auth
+}
+
+// Login implements AuthService.
+func (a authService) Login(username, password string) (models.TokenResponse, error) {
+	user, err := a.repo.FindByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.TokenResponse{}, errs.NewUnauthorizedError(constant.AuthInvalidCredentialsErrorMessage)
+		}
+		loggers.Error("Error FindByUsername user",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.String("username", username))
+		return models.TokenResponse{}, errs.NewInternalServerError(constant.AuthErrorMessageInternalServerError)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return models.TokenResponse{}, errs.NewUnauthorizedError(constant.AuthInvalidCredentialsErrorMessage)
+	}
+	now := time.Now()
+	expireAt := now.Add(time.Duration(a.cfg.TokenExpireMinutes) * time.Minute)
+	claims := AuthClaims{
+		UserID:   user.ID,
+		Role:     user.Role,
+		MemberID: user.MemberID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expireAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(a.cfg.SecretKey))
+	if err != nil {
+		loggers.Error("Error sign token",
+			zap.String("type", "service"),
+			zap.Error(err),
+			zap.String("username", username))
+		return models.TokenResponse{}, errs.NewInternalServerError(constant.AuthErrorMessageInternalServerError)
+	}
+	return models.TokenResponse{
+		Message: constant.AuthLoginSuccessMessage,
+		Data: &models.TokenData{
+			AccessToken: token,
+			TokenType:   "Bearer",
+			ExpireAt:    expireAt.Format(dateTimeFormat),
+		},
+	}, nil
+}
+
+// CreateUser implements AuthService.
+func (a authService) CreateUser(user models.UserRequest) (models.UserResponse, error) {
+	memberID := 0
+	if user.Role == models.RoleMember {
+		if user.MemberID == 0 {
+			return models.UserResponse{}, errs.NewBadRequest(constant.UserMemberRequiredErrorMessage)
+		}
+		_, err := a.memberRepo.FindByID(user.MemberID)
+		if err != nil {
+			loggers.Error("Error FindByID member",
+				zap.String("type", "repo"),
+				zap.Error(err),
+				zap.Int("member_id", user.MemberID))
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.UserResponse{}, errs.NewNotFoundError(constant.MemberErrorsMessageFindNotFound)
+			} else {
+				return models.UserResponse{}, errs.NewInternalServerError(constant.AuthErrorMessageInternalServerError)
+			}
+		}
+		memberID = user.MemberID
+	}
+	_, err := a.repo.FindByUsername(user.Username)
+	if err == nil {
+		return models.UserResponse{}, errs.NewBadRequest(constant.UserExistsErrorMessage)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		loggers.Error("Error FindByUsername user",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.String("username", user.Username))
+		return models.UserResponse{}, errs.NewInternalServerError(constant.AuthErrorMessageInternalServerError)
+	}
+	err = a.createUser(user.Username, user.Password, user.Role, memberID)
+	if err != nil {
+		return models.UserResponse{}, errs.NewInternalServerError(constant.AuthErrorMessageInternalServerError)
+	}
+	return models.UserResponse{
+		Message: constant.UserCreateSuccessMessage,
+	}, nil
+}
+
+// ParseToken implements AuthService.
+func (a authService) ParseToken(token string) (*AuthClaims, error) {
+	claims := &AuthClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(a.cfg.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, errs.NewUnauthorizedError(constant.AuthTokenInvalidErrorMessage)
+	}
+	return claims, nil
+}
+
+// EnsureAdmin implements AuthService.
+// It creates the configured admin account on first start; without an admin
+// password nothing is seeded.
+func (a authService) EnsureAdmin() error {
+	if a.cfg.AdminUsername == "" || a.cfg.AdminPassword == "" {
+		return nil
+	}
+	_, err := a.repo.FindByUsername(a.cfg.AdminUsername)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return a.createUser(a.cfg.AdminUsername, a.cfg.AdminPassword, models.RoleAdmin, 0)
+}
+
+func (a authService) createUser(username, password, role string, memberID int) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		loggers.Error("Error hash password",
+			zap.String("type", "service"),
+			zap.Error(err),
+			zap.String("username", username))
+		return err
+	}
+	userDataCreate := models.UserRepository{
+		Username:     username,
+		PasswordHash: string(hash),
+		Role:         role,
+		MemberID:     memberID,
+	}
+	err = a.repo.Create(userDataCreate)
+	if err != nil {
+		loggers.Error("Error Create user",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.String("username", username),
+			zap.String("role", role))
+		return err
+	}
+	return nil
+}
+
+func NewAuthService(repo db.UserRepository, memberRepo db.MemberRepository, cfg config.Auth) AuthService {
+	return authService{repo: repo, memberRepo: memberRepo, cfg: cfg}
+}
@@ -0,0 +1,208 @@
+package services_test
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func TestLogin(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password1"), bcrypt.MinCost)
+	cfg := config.Auth{SecretKey: "secret test", TokenExpireMinutes: 60}
+	testCases := []struct {
+		name        string
+		username    string
+		password    string
+		mockData    models.UserRepository
+		expectError error
+	}{
+		{
+			name:        "TestLoginSuccess",
+			username:    "member1",
+			password:    "password1",
+			mockData:    models.UserRepository{ID: 1, Username: "member1", PasswordHash: string(hash), Role: models.RoleMember, MemberID: 7},
+			expectError: nil,
+		},
+		{
+			name:        "TestLoginWrongPassword",
+			username:    "member1",
+			password:    "password2",
+			mockData:    models.UserRepository{ID: 1, Username: "member1", PasswordHash: string(hash), Role: models.RoleMember, MemberID: 7},
+			expectError: errors.New(constant.AuthInvalidCredentialsErrorMessage),
+		},
+		{
+			name:        "TestLoginUserFindNotFound",
+			username:    "member1",
+			password:    "password1",
+			mockData:    models.UserRepository{},
+			expectError: errors.New(constant.AuthInvalidCredentialsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			userRepo := db.NewUserRepositoryMock()
+
+			switch tC.name {
+			case "TestLoginUserFindNotFound":
+				userRepo.On("FindByUsername").Return(tC.mockData, gorm.ErrRecordNotFound)
+			default:
+				userRepo.On("FindByUsername").Return(tC.mockData, nil)
+			}
+
+			authSvc := services.NewAuthService(userRepo, db.NewMemberRepositoryMock(), cfg)
+			resp, err := authSvc.Login(tC.username, tC.password)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, constant.AuthLoginSuccessMessage, resp.Message)
+				claims, err := authSvc.ParseToken(resp.Data.AccessToken)
+				assert.NoError(t, err)
+				assert.Equal(t, models.RoleMember, claims.Role)
+				assert.Equal(t, 7, claims.MemberID)
+			}
+
+		})
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		request       models.UserRequest
+		expectSuccess models.UserResponse
+		expectError   error
+	}{
+		{
+			name:    "TestCreateUserSuccess",
+			request: models.UserRequest{Username: "librarian1", Password: "password1", Role: models.RoleLibrarian},
+			expectSuccess: models.UserResponse{
+				Message: constant.UserCreateSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:    "TestCreateUserMemberSuccess",
+			request: models.UserRequest{Username: "member1", Password: "password1", Role: models.RoleMember, MemberID: 7},
+			expectSuccess: models.UserResponse{
+				Message: constant.UserCreateSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestCreateUserMemberRequired",
+			request:     models.UserRequest{Username: "member1", Password: "password1", Role: models.RoleMember},
+			expectError: errors.New(constant.UserMemberRequiredErrorMessage),
+		},
+		{
+			name:        "TestCreateUserMemberFindNotFound",
+			request:     models.UserRequest{Username: "member1", Password: "password1", Role: models.RoleMember, MemberID: 7},
+			expectError: errors.New(constant.MemberErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestCreateUserExists",
+			request:     models.UserRequest{Username: "librarian1", Password: "password1", Role: models.RoleLibrarian},
+			expectError: errors.New(constant.UserExistsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			userRepo := db.NewUserRepositoryMock()
+			memberRepo := db.NewMemberRepositoryMock()
+
+			userRepo.On("Create").Return(nil)
+			switch tC.name {
+			case "TestCreateUserMemberFindNotFound":
+				memberRepo.On("FindByID").Return(models.MemberRepository{}, gorm.ErrRecordNotFound)
+			default:
+				memberRepo.On("FindByID").Return(models.MemberRepository{ID: 7}, nil)
+			}
+			switch tC.name {
+			case "TestCreateUserExists":
+				userRepo.On("FindByUsername").Return(models.UserRepository{ID: 1, Username: "librarian1"}, nil)
+			default:
+				userRepo.On("FindByUsername").Return(models.UserRepository{}, gorm.ErrRecordNotFound)
+			}
+
+			authSvc := services.NewAuthService(userRepo, memberRepo, config.Auth{SecretKey: "secret test"})
+			resp, err := authSvc.CreateUser(tC.request)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestParseToken(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	sign := func(method jwt.SigningMethod, key interface{}, expireAt time.Time) string {
+		token, _ := jwt.NewWithClaims(method, services.AuthClaims{
+			UserID: 1,
+			Role:   models.RoleAdmin,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(expireAt),
+			},
+		}).SignedString(key)
+		return token
+	}
+	testCases := []struct {
+		name        string
+		token       string
+		expectError error
+	}{
+		{
+			name:        "TestParseTokenSuccess",
+			token:       sign(jwt.SigningMethodHS256, []byte("secret test"), now.Add(time.Hour)),
+			expectError: nil,
+		},
+		{
+			name:        "TestParseTokenExpired",
+			token:       sign(jwt.SigningMethodHS256, []byte("secret test"), now.Add(-time.Hour)),
+			expectError: errors.New(constant.AuthTokenInvalidErrorMessage),
+		},
+		{
+			name:        "TestParseTokenWrongKey",
+			token:       sign(jwt.SigningMethodHS256, []byte("other secret"), now.Add(time.Hour)),
+			expectError: errors.New(constant.AuthTokenInvalidErrorMessage),
+		},
+		{
+			name:        "TestParseTokenWrongMethod",
+			token:       sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, now.Add(time.Hour)),
+			expectError: errors.New(constant.AuthTokenInvalidErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			authSvc := services.NewAuthService(db.NewUserRepositoryMock(), db.NewMemberRepositoryMock(), config.Auth{SecretKey: "secret test"})
+			claims, err := authSvc.ParseToken(tC.token)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, models.RoleAdmin, claims.Role)
+			}
+
+		})
+	}
+}
@@ -2,24 +2,65 @@ package services
 
 import (
 	"errors"
+	"math"
+	"test-exam-forviz/config"
 	"test-exam-forviz/constant"
 	"test-exam-forviz/errs"
 	"test-exam-forviz/internal/models"
 	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/isbn"
 	"test-exam-forviz/loggers"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 const dateFormat = "02/01/2006"
+const dateTimeFormat = "02/01/2006 15:04:05"
+const defaultPageSize = 20
 
 type bookService struct {
-	repo db.BookRepository
+	repo       db.BookRepository
+	memberRepo db.MemberRepository
+	copyRepo   db.CopyRepository
+	loanRepo   db.LoanRepository
+	fineRepo   db.FineRepository
+	holdRepo   db.HoldRepository
+	loanCfg    config.Loan
+	fineCfg    config.Fine
 }
 
 // BorrowBook implements BookService.
-func (b bookService) BorrowBook(id int) (models.BookResponse, error) {
+// When copyID is 0 the first available copy of the title is lent. A version
+// other than 0 must match the book's current one.
+func (b bookService) BorrowBook(actor models.Actor, id, version, memberID, copyID int) (models.BookResponse, error) {
+	member, err := b.memberRepo.FindByID(memberID)
+	if err != nil {
+		loggers.Error("Error FindByID member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", memberID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.BookResponse{}, errs.NewNotFoundError(constant.MemberErrorsMessageFindNotFound)
+		} else {
+			return models.BookResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+		}
+	}
+	if member.IsSuspended {
+		return models.BookResponse{}, errs.NewBadRequest(constant.MemberSuspendedErrorMessage)
+	}
+	outstanding, err := b.fineRepo.SumOutstandingByMemberID(member.ID)
+	if err != nil {
+		loggers.Error("Error SumOutstandingByMemberID fine",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", member.ID))
+		return models.BookResponse{}, errs.NewInternalServerError(constant.FineErrorMessageInternalServerError)
+	}
+	if outstanding > b.fineCfg.BlockThreshold {
+		return models.BookResponse{}, errs.NewForbiddenError(constant.MemberFineBlockedErrorMessage)
+	}
 	book, err := b.repo.FindByID(id)
 	if err != nil {
 		loggers.Error("Error FindByID book",
@@ -33,24 +74,159 @@ func (b bookService) BorrowBook(id int) (models.BookResponse, error) {
 		}
 
 	}
-	if book.IsBorrowed {
-		return models.BookResponse{}, errs.NewBadRequest(constant.BookBarrowErrorMessage)
+	if err := checkVersion(book, version); err != nil {
+		return models.BookResponse{}, err
 	}
-	err = b.repo.BorrowBook(id, book.BorrowCount+1)
+	now := time.Now()
+	err = b.holdRepo.AdvanceQueue(book.ID, now, b.holdExpireAt(now))
+	if err != nil {
+		loggers.Error("Error AdvanceQueue hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", book.ID))
+		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	hold, err := b.holdRepo.FindActiveByMember(book.ID, member.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		loggers.Error("Error FindActiveByMember hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", book.ID),
+			zap.Int("member_id", member.ID))
+		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	var bookCopy models.CopyRepository
+	if hold.Status == models.HoldStatusReady {
+		// the member picks up the copy set aside for their hold
+		if copyID != 0 && copyID != hold.CopyID {
+			return models.BookResponse{}, errs.NewBadRequest(constant.BookReservedErrorMessage)
+		}
+		bookCopy, err = b.findCopyOfBook(book.ID, hold.CopyID)
+	} else {
+		bookCopy, err = b.findCopyToBorrow(book.ID, copyID)
+	}
+	if err != nil {
+		return models.BookResponse{}, err
+	}
+	loan := models.LoanRepository{
+		BookID:     book.ID,
+		CopyID:     bookCopy.ID,
+		MemberID:   member.ID,
+		BorrowedAt: now,
+		DueAt:      now.AddDate(0, 0, b.loanCfg.PeriodDays),
+	}
+	audit := auditChange(actor, models.AuditActionBorrow,
+		circulationState{CopyID: bookCopy.ID},
+		circulationState{CopyID: bookCopy.ID, MemberID: member.ID, IsBorrowed: true, DueAt: loan.DueAt.Format(dateTimeFormat)})
+	err = b.repo.BorrowBook(loan, version, hold.ID, audit)
 	if err != nil {
 		loggers.Error("Error Borrow book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", id),
+			zap.Int("copy_id", bookCopy.ID),
+			zap.Int("member_id", member.ID))
+		return models.BookResponse{}, bookWriteError(err, version)
+	}
+	return models.BookResponse{
+		Message: constant.BookBorrowSuccessMessage,
+	}, nil
+}
+
+// RenewBook implements BookService.
+// The due date moves one loan period forward; copyID picks the loan when the
+// member has borrowed several copies of the title. A version other than 0 must
+// match the book's current one.
+func (b bookService) RenewBook(actor models.Actor, id, version, memberID, copyID int) (models.BookResponse, error) {
+	member, err := b.memberRepo.FindByID(memberID)
+	if err != nil {
+		loggers.Error("Error FindByID member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", memberID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.BookResponse{}, errs.NewNotFoundError(constant.MemberErrorsMessageFindNotFound)
+		} else {
+			return models.BookResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+		}
+	}
+	if member.IsSuspended {
+		return models.BookResponse{}, errs.NewBadRequest(constant.MemberSuspendedErrorMessage)
+	}
+	book, err := b.repo.FindByID(id)
+	if err != nil {
+		loggers.Error("Error FindByID book",
 			zap.String("type", "repo"),
 			zap.Error(err),
 			zap.Int("book_id", id))
+		if errors.Is(gorm.ErrRecordNotFound, err) {
+			return models.BookResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindNotFound)
+		} else {
+			return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		}
+	}
+	if err := checkVersion(book, version); err != nil {
+		return models.BookResponse{}, err
+	}
+	loan, err := b.findLoanToRenew(book.ID, member.ID, copyID)
+	if err != nil {
+		return models.BookResponse{}, err
+	}
+	if loan.RenewCount >= b.loanCfg.MaxRenewals {
+		return models.BookResponse{}, errs.NewBadRequest(constant.BookRenewLimitErrorMessage)
+	}
+	now := time.Now()
+	if now.After(loan.DueAt) {
+		return models.BookResponse{}, errs.NewBadRequest(constant.BookRenewOverdueErrorMessage)
+	}
+	err = b.holdRepo.AdvanceQueue(book.ID, now, b.holdExpireAt(now))
+	if err != nil {
+		loggers.Error("Error AdvanceQueue hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", book.ID))
+		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	holds, err := b.holdRepo.FindActiveByBookID(book.ID)
+	if err != nil {
+		loggers.Error("Error FindActiveByBookID hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", book.ID))
+		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	for _, hold := range holds {
+		if hold.MemberID != member.ID {
+			return models.BookResponse{}, errs.NewBadRequest(constant.BookReservedErrorMessage)
+		}
+	}
+	dueAt := loan.DueAt.AddDate(0, 0, b.loanCfg.PeriodDays)
+	audit := auditChange(actor, models.AuditActionRenew,
+		circulationState{CopyID: loan.CopyID, MemberID: member.ID, IsBorrowed: true, DueAt: loan.DueAt.Format(dateTimeFormat)},
+		circulationState{CopyID: loan.CopyID, MemberID: member.ID, IsBorrowed: true, DueAt: dueAt.Format(dateTimeFormat)})
+	err = b.loanRepo.Renew(loan.ID, b.loanCfg.MaxRenewals, dueAt, now, audit)
+	if err != nil {
+		loggers.Error("Error Renew loan",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("loan_id", loan.ID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.BookResponse{}, errs.NewBadRequest(constant.BookNotBorrowedErrorMessage)
+		}
+		if errors.Is(err, db.ErrRenewLimit) {
+			return models.BookResponse{}, errs.NewConflictError(constant.BookRenewLimitErrorMessage)
+		}
 		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
 	}
 	return models.BookResponse{
-		Message: constant.BookBorrowSuccessMessage,
+		Message: constant.BookRenewSuccessMessage,
 	}, nil
 }
 
 // ReturnBook implements BookService.
-func (b bookService) ReturnBook(id int) (models.BookResponse, error) {
+// copyID may be 0 when only one copy of the title is out. A version other
+// than 0 must match the book's current one.
+func (b bookService) ReturnBook(actor models.Actor, id, version, copyID int) (models.BookResponse, error) {
 	book, err := b.repo.FindByID(id)
 	if err != nil {
 		loggers.Error("Error FindByID book",
@@ -64,36 +240,83 @@ func (b bookService) ReturnBook(id int) (models.BookResponse, error) {
 		}
 
 	}
-	if !book.IsBorrowed {
-		return models.BookResponse{}, errs.NewBadRequest(constant.BookReturnErrorMessage)
+	if err := checkVersion(book, version); err != nil {
+		return models.BookResponse{}, err
 	}
-	err = b.repo.ReturnBook(id)
+	bookCopy, err := b.findCopyToReturn(book.ID, copyID)
 	if err != nil {
-		loggers.Error("Error Return book",
+		return models.BookResponse{}, err
+	}
+	now := time.Now()
+	var fine *models.FineRepository
+	loan, err := b.loanRepo.FindOpenByCopyID(bookCopy.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		loggers.Error("Error FindOpenByCopyID loan",
 			zap.String("type", "repo"),
 			zap.Error(err),
-			zap.Int("book_id", id))
+			zap.Int("copy_id", bookCopy.ID))
 		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
 	}
+	// copies borrowed before loan history was recorded have no open loan to fine
+	if err == nil {
+		amount, late := calculateFine(b.fineCfg, book.Category, loan.DueAt, now)
+		if amount > 0 {
+			fine = &models.FineRepository{
+				LoanID:   loan.ID,
+				MemberID: loan.MemberID,
+				BookID:   book.ID,
+				Amount:   amount,
+				DaysLate: late,
+			}
+		}
+	}
+	borrowed := circulationState{CopyID: bookCopy.ID, MemberID: bookCopy.BorrowerID, IsBorrowed: true}
+	if loan.ID != 0 {
+		borrowed.DueAt = loan.DueAt.Format(dateTimeFormat)
+	}
+	returned := circulationState{CopyID: bookCopy.ID}
+	if fine != nil {
+		returned.Fine = fine.Amount
+	}
+	err = b.repo.ReturnBook(bookCopy.ID, version, now, fine, b.holdExpireAt(now), auditChange(actor, models.AuditActionReturn, borrowed, returned))
+	if err != nil {
+		loggers.Error("Error Return book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", id),
+			zap.Int("copy_id", bookCopy.ID))
+		return models.BookResponse{}, bookWriteError(err, version)
+	}
 	return models.BookResponse{
 		Message: constant.BookReturnSuccessMessage,
 	}, nil
 }
 
 // CreateBook implements BookService.
-func (b bookService) CreateBook(book models.BookRequest) (models.BookResponse, error) {
+// An ISBN is stored as ISBN-13 and may belong to one book only.
+func (b bookService) CreateBook(actor models.Actor, book models.BookRequest) (models.BookResponse, error) {
+	isbn13, err := b.checkISBN(book.ISBN, 0)
+	if err != nil {
+		return models.BookResponse{}, err
+	}
 	bookDataCreate := models.BookRepository{
 		Title:    book.Title,
 		Author:   book.Author,
 		Category: book.Category,
+		ISBN:     isbn13,
+		Version:  1,
 	}
-	err := b.repo.Create(bookDataCreate)
+	err = b.repo.Create(&bookDataCreate, auditCreate(actor, models.AuditActionCreate))
 	if err != nil {
 		loggers.Error("Error Create book",
 			zap.String("type", "repo"),
 			zap.Error(err),
 			zap.Any("request", bookDataCreate))
-		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.BookResponse{}, errs.NewBadRequest(constant.BookISBNExistsErrorMessage)
+		} else {
+			return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		}
 	}
 	return models.BookResponse{
 		Message: constant.BookCreateSuccessMessage,
@@ -101,7 +324,7 @@ func (b bookService) CreateBook(book models.BookRequest) (models.BookResponse, e
 }
 
 // DeleteBook implements BookService.
-func (b bookService) DeleteBook(id int) (models.BookResponse, error) {
+func (b bookService) DeleteBook(actor models.Actor, id int) (models.BookResponse, error) {
 	book, err := b.repo.FindByID(id)
 	if err != nil {
 		loggers.Error("Error FindByID book",
@@ -114,12 +337,13 @@ func (b bookService) DeleteBook(id int) (models.BookResponse, error) {
 			return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
 		}
 	}
-	err = b.repo.Delete(book.ID)
+	err = b.repo.Delete(book.ID, auditChange(actor, models.AuditActionDelete, toBookData(book), nil))
 	if err != nil {
 		loggers.Error("Error Delete book",
 			zap.String("type", "repo"),
 			zap.Error(err),
 			zap.Int("book_id", id))
+		return models.BookResponse{}, bookWriteError(err, 0)
 	}
 	return models.BookResponse{
 		Message: constant.BookDeleteSuccessMessage,
@@ -140,16 +364,7 @@ func (b bookService) GetBookByID(id int) (models.BookResponse, error) {
 			return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
 		}
 	}
-	data := models.BookData{
-		ID:          book.ID,
-		Title:       book.Title,
-		Author:      book.Author,
-		Category:    book.Category,
-		IsBorrowed:  book.IsBorrowed,
-		BorrowCount: book.BorrowCount,
-		CreateAt:    book.CreateAt.Format(dateFormat),
-		UpdateAt:    book.UpdateAt.Format(dateFormat),
-	}
+	data := toBookData(book)
 	return models.BookResponse{
 		Message: constant.BookGetSuccessMessage,
 		Data:    &data,
@@ -158,9 +373,9 @@ func (b bookService) GetBookByID(id int) (models.BookResponse, error) {
 
 // GetMostBorrowedBooks implements BookService.
 func (b bookService) GetMostBorrowedBooks() (models.BookListResponse, error) {
-	books, err := b.repo.FindAll("", "", "", "borrow_count", "desc")
+	books, err := b.repo.FindMostBorrowed()
 	if err != nil {
-		loggers.Error("Error FindAll book",
+		loggers.Error("Error FindMostBorrowed book",
 			zap.String("type", "repo"),
 			zap.Error(err))
 		if errors.Is(gorm.ErrRecordNotFound, err) {
@@ -171,59 +386,186 @@ func (b bookService) GetMostBorrowedBooks() (models.BookListResponse, error) {
 	}
 	bookList := []models.BookData{}
 	for _, book := range books {
-		bookData := models.BookData{
-			ID:          book.ID,
-			Title:       book.Title,
-			Author:      book.Author,
-			Category:    book.Category,
-			IsBorrowed:  book.IsBorrowed,
-			BorrowCount: book.BorrowCount,
-			CreateAt:    book.CreateAt.Format(dateFormat),
-			UpdateAt:    book.UpdateAt.Format(dateFormat),
-		}
-		bookList = append(bookList, bookData)
+		bookList = append(bookList, toBookData(book))
 	}
 	return models.BookListResponse{
 		Message: constant.BookGetSuccessMessage,
+		Total:   int64(len(bookList)),
 		Data:    bookList,
 	}, nil
 }
 
+// GetBookLoans implements BookService.
+func (b bookService) GetBookLoans(id int) (models.LoanListResponse, error) {
+	_, err := b.repo.FindByID(id)
+	if err != nil {
+		loggers.Error("Error FindByID book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LoanListResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindNotFound)
+		} else {
+			return models.LoanListResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		}
+	}
+	loans, err := b.loanRepo.FindByBookID(id)
+	if err != nil {
+		loggers.Error("Error FindByBookID loan",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", id))
+		return models.LoanListResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	loanList := []models.LoanData{}
+	for _, loan := range loans {
+		loanList = append(loanList, toLoanData(loan))
+	}
+	return models.LoanListResponse{
+		Message: constant.BookGetSuccessMessage,
+		Data:    loanList,
+	}, nil
+}
+
+// GetOverdueLoans implements BookService.
+func (b bookService) GetOverdueLoans() (models.LoanListResponse, error) {
+	now := time.Now()
+	loans, err := b.loanRepo.FindOverdue(now)
+	if err != nil {
+		loggers.Error("Error FindOverdue loan",
+			zap.String("type", "repo"),
+			zap.Error(err))
+		return models.LoanListResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	loanList := []models.LoanData{}
+	for _, loan := range loans {
+		loanData := toLoanData(loan)
+		loanData.DaysLate = daysLate(loan.DueAt, now)
+		loanList = append(loanList, loanData)
+	}
+	return models.LoanListResponse{
+		Message: constant.BookGetSuccessMessage,
+		Data:    loanList,
+	}, nil
+}
+
+// GetBookByISBN implements BookService.
+// Either form of the ISBN finds the book.
+func (b bookService) GetBookByISBN(isbnCode string) (models.BookResponse, error) {
+	isbn13, err := isbn.Normalize(isbnCode)
+	if err != nil {
+		return models.BookResponse{}, errs.NewBadRequest(constant.BookISBNInvalidErrorMessage)
+	}
+	book, err := b.repo.FindByISBN(isbn13)
+	if err != nil {
+		loggers.Error("Error FindByISBN book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.String("isbn", isbn13))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.BookResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindISBNNotFound)
+		} else {
+			return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		}
+	}
+	if book.DeletedAt.Valid {
+		return models.BookResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindISBNNotFound)
+	}
+	data := toBookData(book)
+	return models.BookResponse{
+		Message: constant.BookGetSuccessMessage,
+		Data:    &data,
+	}, nil
+}
+
+// checkISBN normalizes isbnCode and makes sure no book but bookID has it.
+// A nil or empty isbnCode gives nil: the book has no ISBN.
+func (b bookService) checkISBN(isbnCode *string, bookID int) (*string, error) {
+	if isbnCode == nil || *isbnCode == "" {
+		return nil, nil
+	}
+	isbn13, err := isbn.Normalize(*isbnCode)
+	if err != nil {
+		return nil, errs.NewBadRequest(constant.BookISBNInvalidErrorMessage)
+	}
+	book, err := b.repo.FindByISBN(isbn13)
+	if err == nil && book.DeletedAt.Valid {
+		return nil, errs.NewBadRequest(constant.BookISBNInTrashErrorMessage)
+	}
+	if err == nil && book.ID != bookID {
+		return nil, errs.NewBadRequest(constant.BookISBNExistsErrorMessage)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		loggers.Error("Error FindByISBN book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.String("isbn", isbn13))
+		return nil, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	return &isbn13, nil
+}
+
 // SearchBooks implements BookService.
-func (b bookService) SearchBooks(title string, author string, category string) (models.BookListResponse, error) {
-	books, err := b.repo.FindAll(title, author, category, "", "")
+// Results come a page at a time, page_size 20 by default.
+func (b bookService) SearchBooks(search models.BookSearchRequest) (models.BookListResponse, error) {
+	page := search.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := search.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	filter := toBookFilter(search)
+	filter.Offset, filter.Limit = (page-1)*pageSize, pageSize
+	fuzzy := isFuzzySearch(search)
+	if fuzzy {
+		// the query is scored below, so fetch the books that can match it
+		filter.Query, filter.Fragments = "", fuzzyFragments(tokenize(search.Query))
+		filter.Offset, filter.Limit = 0, fuzzyCandidates
+	}
+	books, total, err := b.repo.FindAll(filter)
 	if err != nil {
 		loggers.Error("Error FindAll book",
 			zap.String("type", "repo"),
-			zap.Error(err))
+			zap.Error(err),
+			zap.Any("filter", filter))
 		if errors.Is(gorm.ErrRecordNotFound, err) {
 			return models.BookListResponse{}, errs.NewInternalServerError(constant.BookErrorsMessageFindNotFound)
 		} else {
 			return models.BookListResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
 		}
 	}
+	if fuzzy {
+		resp := searchBooksFuzzy(books, search, page, pageSize)
+		resp.Truncated = total > fuzzyCandidates
+		return resp, nil
+	}
+	facets, err := b.repo.FindFacets(filter)
+	if err != nil {
+		loggers.Error("Error FindFacets book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("filter", filter))
+		return models.BookListResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
 	bookList := []models.BookData{}
 	for _, book := range books {
-		bookData := models.BookData{
-			ID:          book.ID,
-			Title:       book.Title,
-			Author:      book.Author,
-			Category:    book.Category,
-			IsBorrowed:  book.IsBorrowed,
-			BorrowCount: book.BorrowCount,
-			CreateAt:    book.CreateAt.Format(dateFormat),
-			UpdateAt:    book.UpdateAt.Format(dateFormat),
-		}
-		bookList = append(bookList, bookData)
+		bookList = append(bookList, toBookData(book))
 	}
 	return models.BookListResponse{
-		Message: constant.BookGetSuccessMessage,
-		Data:    bookList,
+		Message:  constant.BookGetSuccessMessage,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Facets:   toBookFacets(facets),
+		Data:     bookList,
 	}, nil
 }
 
 // UpdateBook implements BookService.
-func (b bookService) UpdateBook(id int, book models.BookRequest) (models.BookResponse, error) {
+// A version other than 0 must match the book's current one.
+func (b bookService) UpdateBook(actor models.Actor, id, version int, book models.BookRequest) (models.BookResponse, error) {
 	bookRepo, err := b.repo.FindByID(id)
 	if err != nil {
 		loggers.Error("Error FindAll book",
@@ -235,27 +577,270 @@ func (b bookService) UpdateBook(id int, book models.BookRequest) (models.BookRes
 			return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
 		}
 	}
+	if err := checkVersion(bookRepo, version); err != nil {
+		return models.BookResponse{}, err
+	}
+	// without an ISBN in the request the book keeps its own
+	isbn13 := bookRepo.ISBN
+	if book.ISBN != nil {
+		isbn13, err = b.checkISBN(book.ISBN, id)
+		if err != nil {
+			return models.BookResponse{}, err
+		}
+	}
 	bookDataUpdate := models.BookRepository{
-		ID:          id,
-		Title:       book.Title,
-		Author:      book.Author,
-		Category:    book.Category,
-		IsBorrowed:  bookRepo.IsBorrowed,
-		BorrowCount: bookRepo.BorrowCount,
+		ID:       id,
+		Title:    book.Title,
+		Author:   book.Author,
+		Category: book.Category,
+		ISBN:     isbn13,
+		Version:  bookRepo.Version,
 	}
-	err = b.repo.Update(bookDataUpdate)
+	updated := bookRepo
+	updated.Title, updated.Author, updated.Category, updated.ISBN = book.Title, book.Author, book.Category, isbn13
+	updated.Version++
+	err = b.repo.Update(bookDataUpdate, auditChange(actor, models.AuditActionUpdate, toBookData(bookRepo), toBookData(updated)))
 	if err != nil {
 		loggers.Error("Error Update book",
 			zap.String("type", "repo"),
 			zap.Error(err),
 			zap.Any("request", bookDataUpdate))
-		return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		return models.BookResponse{}, bookWriteError(err, version)
 	}
 	return models.BookResponse{
 		Message: constant.BookUpdateSuccessMessage,
 	}, nil
 }
 
-func NewBookService(repo db.BookRepository) BookService {
-	return bookService{repo: repo}
+func (b bookService) findCopyToBorrow(bookID, copyID int) (models.CopyRepository, error) {
+	if copyID == 0 {
+		bookCopy, err := b.copyRepo.FindAvailableByBookID(bookID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return bookCopy, errs.NewBadRequest(constant.BookBarrowErrorMessage)
+			}
+			loggers.Error("Error FindAvailableByBookID copy",
+				zap.String("type", "repo"),
+				zap.Error(err),
+				zap.Int("book_id", bookID))
+			return bookCopy, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		}
+		return bookCopy, nil
+	}
+	bookCopy, err := b.findCopyOfBook(bookID, copyID)
+	if err != nil {
+		return bookCopy, err
+	}
+	if bookCopy.IsBorrowed {
+		return bookCopy, errs.NewBadRequest(constant.BookBarrowErrorMessage)
+	}
+	if bookCopy.ReservedFor != 0 {
+		return bookCopy, errs.NewBadRequest(constant.BookReservedErrorMessage)
+	}
+	return bookCopy, nil
+}
+
+// holdExpireAt is the end of the pickup window for a copy set aside at now.
+func (b bookService) holdExpireAt(now time.Time) time.Time {
+	return now.AddDate(0, 0, b.loanCfg.HoldPickupDays)
+}
+
+func (b bookService) findCopyToReturn(bookID, copyID int) (models.CopyRepository, error) {
+	if copyID == 0 {
+		borrowed, err := b.copyRepo.FindBorrowedByBookID(bookID)
+		if err != nil {
+			loggers.Error("Error FindBorrowedByBookID copy",
+				zap.String("type", "repo"),
+				zap.Error(err),
+				zap.Int("book_id", bookID))
+			return models.CopyRepository{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		}
+		switch len(borrowed) {
+		case 0:
+			return models.CopyRepository{}, errs.NewBadRequest(constant.BookReturnErrorMessage)
+		case 1:
+			return borrowed[0], nil
+		default:
+			return models.CopyRepository{}, errs.NewBadRequest(constant.BookCopyRequiredErrorMessage)
+		}
+	}
+	bookCopy, err := b.findCopyOfBook(bookID, copyID)
+	if err != nil {
+		return bookCopy, err
+	}
+	if !bookCopy.IsBorrowed {
+		return bookCopy, errs.NewBadRequest(constant.BookReturnErrorMessage)
+	}
+	return bookCopy, nil
+}
+
+func (b bookService) findLoanToRenew(bookID, memberID, copyID int) (models.LoanRepository, error) {
+	var loan models.LoanRepository
+	var err error
+	if copyID == 0 {
+		loan, err = b.loanRepo.FindOpenByMember(bookID, memberID)
+	} else {
+		var bookCopy models.CopyRepository
+		bookCopy, err = b.findCopyOfBook(bookID, copyID)
+		if err != nil {
+			return loan, err
+		}
+		loan, err = b.loanRepo.FindOpenByCopyID(bookCopy.ID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return loan, errs.NewBadRequest(constant.BookNotBorrowedErrorMessage)
+		}
+		loggers.Error("Error find open loan",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID),
+			zap.Int("member_id", memberID),
+			zap.Int("copy_id", copyID))
+		return loan, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	if loan.MemberID != memberID {
+		return loan, errs.NewBadRequest(constant.BookNotBorrowedErrorMessage)
+	}
+	return loan, nil
+}
+
+func (b bookService) findCopyOfBook(bookID, copyID int) (models.CopyRepository, error) {
+	bookCopy, err := b.copyRepo.FindByID(copyID)
+	if err != nil {
+		loggers.Error("Error FindByID copy",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("copy_id", copyID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bookCopy, errs.NewNotFoundError(constant.CopyErrorsMessageFindNotFound)
+		}
+		return bookCopy, errs.NewInternalServerError(constant.CopyErrorMessageInternalServerError)
+	}
+	if bookCopy.BookID != bookID {
+		return bookCopy, errs.NewNotFoundError(constant.CopyErrorsMessageFindNotFound)
+	}
+	return bookCopy, nil
+}
+
+// checkVersion refuses a change asked for at a version the book has moved
+// past; version 0 means the caller did not ask for one.
+func checkVersion(book models.BookRepository, version int) error {
+	if version != 0 && version != book.Version {
+		return errs.NewPreconditionFailedError(constant.BookVersionMismatchErrorMessage)
+	}
+	return nil
+}
+
+// bookWriteError maps a failed conditional book write. A copy taken or
+// returned by another request first is a conflict; so is a book changed
+// since it was read, unless the caller named a version, which then fails the
+// precondition.
+func bookWriteError(err error, version int) error {
+	switch {
+	case errors.Is(err, db.ErrCopyUnavailable):
+		return errs.NewConflictError(constant.BookBarrowErrorMessage)
+	case errors.Is(err, db.ErrCopyNotBorrowed):
+		return errs.NewConflictError(constant.BookReturnErrorMessage)
+	case errors.Is(err, db.ErrBookBorrowed):
+		return errs.NewConflictError(constant.BookDeleteBorrowedErrorMessage)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.NewBadRequest(constant.BookISBNExistsErrorMessage)
+	case errors.Is(err, db.ErrVersionConflict) && version != 0:
+		return errs.NewPreconditionFailedError(constant.BookVersionMismatchErrorMessage)
+	case errors.Is(err, db.ErrVersionConflict):
+		return errs.NewConflictError(constant.BookVersionConflictErrorMessage)
+	}
+	return errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+}
+
+func toBookData(book models.BookRepository) models.BookData {
+	bookData := models.BookData{
+		ID:              book.ID,
+		Title:           book.Title,
+		Author:          book.Author,
+		Category:        book.Category,
+		IsBorrowed:      book.TotalCopies > 0 && book.AvailableCopies == 0,
+		BorrowCount:     book.BorrowCount,
+		TotalCopies:     book.TotalCopies,
+		AvailableCopies: book.AvailableCopies,
+		Version:         book.Version,
+		CreateAt:        book.CreateAt.Format(dateFormat),
+		UpdateAt:        book.UpdateAt.Format(dateFormat),
+	}
+	if book.ISBN != nil {
+		bookData.ISBN13 = *book.ISBN
+		bookData.ISBN10, _ = isbn.To10(*book.ISBN)
+	}
+	if book.DeletedAt.Valid {
+		bookData.DeletedAt = book.DeletedAt.Time.Format(dateTimeFormat)
+	}
+	return bookData
+}
+
+func toBookFilter(search models.BookSearchRequest) models.BookFilter {
+	return models.BookFilter{
+		Query:          search.Query,
+		Title:          search.Title,
+		Author:         search.Author,
+		Category:       search.Category,
+		IsBorrowed:     search.IsBorrowed,
+		MinBorrowCount: search.MinBorrowCount,
+		MaxBorrowCount: search.MaxBorrowCount,
+		SortName:       search.Sort,
+		SortType:       search.Order,
+	}
+}
+
+func toBookFacets(facets []models.BookFacetRepository) *models.BookFacets {
+	bookFacets := &models.BookFacets{
+		Category: []models.FacetCount{},
+		Author:   []models.FacetCount{},
+	}
+	for _, facet := range facets {
+		switch facet.Facet {
+		case models.BookFacetCategory:
+			bookFacets.Category = append(bookFacets.Category, models.FacetCount{Value: facet.Value, Count: facet.Count})
+		case models.BookFacetAuthor:
+			bookFacets.Author = append(bookFacets.Author, models.FacetCount{Value: facet.Value, Count: facet.Count})
+		case models.BookFacetAvailability:
+			if facet.Value == models.BookBorrowed {
+				bookFacets.Borrowed = facet.Count
+			} else {
+				bookFacets.Available = facet.Count
+			}
+		}
+	}
+	return bookFacets
+}
+
+func toLoanData(loan models.LoanRepository) models.LoanData {
+	loanData := models.LoanData{
+		ID:         loan.ID,
+		BookID:     loan.BookID,
+		CopyID:     loan.CopyID,
+		MemberID:   loan.MemberID,
+		BorrowedAt: loan.BorrowedAt.Format(dateTimeFormat),
+		DueAt:      loan.DueAt.Format(dateTimeFormat),
+		RenewCount: loan.RenewCount,
+	}
+	if loan.ReturnedAt != nil {
+		loanData.ReturnedAt = loan.ReturnedAt.Format(dateTimeFormat)
+	}
+	if loan.RenewedAt != nil {
+		loanData.RenewedAt = loan.RenewedAt.Format(dateTimeFormat)
+	}
+	return loanData
+}
+
+// daysLate counts started days past dueAt, so one hour late is one day late.
+func daysLate(dueAt, at time.Time) int {
+	if !at.After(dueAt) {
+		return 0
+	}
+	return int(math.Ceil(at.Sub(dueAt).Hours() / 24))
+}
+
+func NewBookService(repo db.BookRepository, memberRepo db.MemberRepository, copyRepo db.CopyRepository, loanRepo db.LoanRepository, fineRepo db.FineRepository, holdRepo db.HoldRepository, loanCfg config.Loan, fineCfg config.Fine) BookService {
+	return bookService{repo: repo, memberRepo: memberRepo, copyRepo: copyRepo, loanRepo: loanRepo, fineRepo: fineRepo, holdRepo: holdRepo, loanCfg: loanCfg, fineCfg: fineCfg}
 }
@@ -2,8 +2,15 @@ package services_test
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"test-exam-forviz/config"
 	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
 	"test-exam-forviz/internal/models"
 	"test-exam-forviz/internal/repositories/db"
 	"test-exam-forviz/internal/services"
@@ -12,6 +19,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -21,19 +29,29 @@ func TestBorrowBook(t *testing.T) {
 	testCases := []struct {
 		name          string
 		requestId     int
+		memberId      int
+		copyId        int
 		mockData      models.BookRepository
+		mockMember    models.MemberRepository
 		expectSuccess models.BookResponse
 		expectError   error
 	}{
 		{
 			name:      "TestBorrowBookSuccess",
 			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: false,
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -45,12 +63,19 @@ func TestBorrowBook(t *testing.T) {
 		{
 			name:      "TestBorrowBookErrorInternalServerError",
 			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: false,
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -62,12 +87,19 @@ func TestBorrowBook(t *testing.T) {
 		{
 			name:      "TestBorrowBookFindNotFound",
 			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: false,
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -79,12 +111,19 @@ func TestBorrowBook(t *testing.T) {
 		{
 			name:      "TestBorrowBookNotMatchFindNotFound",
 			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: false,
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -96,24 +135,229 @@ func TestBorrowBook(t *testing.T) {
 		{
 			name:      "TestBorrowBookBorrowed",
 			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookBorrowSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.BookBarrowErrorMessage),
+		},
+		{
+			name:       "TestBorrowBookMemberFindNotFound",
+			requestId:  1,
+			memberId:   2,
+			mockMember: models.MemberRepository{},
+			mockData: models.BookRepository{
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookBorrowSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.MemberErrorsMessageFindNotFound),
+		},
+		{
+			name:      "TestBorrowBookMemberSuspended",
+			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:          1,
+				Name:        "member test",
+				Email:       "member@test.com",
+				IsSuspended: true,
+			},
+			mockData: models.BookRepository{
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookBorrowSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.MemberSuspendedErrorMessage),
+		},
+		{
+			name:      "TestBorrowBookMemberFineBlocked",
+			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			mockData: models.BookRepository{
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookBorrowSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.MemberFineBlockedErrorMessage),
+		},
+		{
+			name:      "TestBorrowBookCopyOfOtherBook",
+			requestId: 1,
+			memberId:  1,
+			copyId:    9,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			mockData: models.BookRepository{
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookBorrowSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.CopyErrorsMessageFindNotFound),
+		},
+		{
+			name:      "TestBorrowBookReadyHold",
+			requestId: 1,
+			memberId:  1,
+			copyId:    0,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookBorrowSuccessMessage,
+				Data:    nil,
+			},
+			expectError: nil,
+		},
+		{
+			name:      "TestBorrowBookReadyHoldOtherCopy",
+			requestId: 1,
+			memberId:  1,
+			copyId:    3,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookBorrowSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.BookReservedErrorMessage),
+		},
+		{
+			name:      "TestBorrowBookCopyReserved",
+			requestId: 1,
+			memberId:  1,
+			copyId:    2,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
 				Message: constant.BookBorrowSuccessMessage,
 				Data:    nil,
 			},
+			expectError: errors.New(constant.BookReservedErrorMessage),
+		},
+		{
+			name:      "TestBorrowBookCopyTaken",
+			requestId: 1,
+			memberId:  1,
+			mockMember: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			mockData: models.BookRepository{
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
+			},
 			expectError: errors.New(constant.BookBarrowErrorMessage),
 		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.name, func(t *testing.T) {
 			bookRepo := db.NewBookRepositoryMock()
+			memberRepo := db.NewMemberRepositoryMock()
+			if tC.name == "TestBorrowBookMemberFindNotFound" {
+				memberRepo.On("FindByID").Return(tC.mockMember, gorm.ErrRecordNotFound)
+			} else {
+				memberRepo.On("FindByID").Return(tC.mockMember, nil)
+			}
+
+			copyRepo := db.NewCopyRepositoryMock()
+			copyRepo.On("FindByID").Return(models.CopyRepository{ID: 9, BookID: 2}, nil)
+			if tC.name == "TestBorrowBookBorrowed" {
+				copyRepo.On("FindAvailableByBookID").Return(models.CopyRepository{}, gorm.ErrRecordNotFound)
+			} else {
+				copyRepo.On("FindAvailableByBookID").Return(models.CopyRepository{ID: 1, BookID: 1}, nil)
+			}
 
 			switch tC.name {
 			case "TestBorrowBookFindNotFound":
@@ -132,19 +376,45 @@ func TestBorrowBook(t *testing.T) {
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("BorrowBook").Return(tC.expectError)
 				break
+			case "TestBorrowBookCopyTaken":
+				bookRepo.On("FindByID").Return(tC.mockData, nil)
+				bookRepo.On("BorrowBook").Return(db.ErrCopyUnavailable)
+				break
 			default:
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("BorrowBook").Return(nil)
 				break
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
-
-			resp, err := bookSvc.BorrowBook(tC.requestId)
-			if err != nil {
-				assert.EqualError(t, tC.expectError, err.Error())
+			holdRepo := db.NewHoldRepositoryMock()
+			holdRepo.On("AdvanceQueue").Return(nil)
+			switch tC.name {
+			case "TestBorrowBookReadyHold":
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{ID: 1, BookID: 1, MemberID: 1, Status: models.HoldStatusReady, CopyID: 2}, nil)
+				copyRepo.ExpectedCalls = nil
+				copyRepo.On("FindByID").Return(models.CopyRepository{ID: 2, BookID: 1, ReservedFor: 1}, nil)
+			case "TestBorrowBookReadyHoldOtherCopy":
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{ID: 1, BookID: 1, MemberID: 1, Status: models.HoldStatusReady, CopyID: 2}, nil)
+			case "TestBorrowBookCopyReserved":
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{}, gorm.ErrRecordNotFound)
+				copyRepo.ExpectedCalls = nil
+				copyRepo.On("FindByID").Return(models.CopyRepository{ID: 2, BookID: 1, ReservedFor: 7}, nil)
+			default:
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{}, gorm.ErrRecordNotFound)
+			}
+			fineRepo := db.NewFineRepositoryMock()
+			if tC.name == "TestBorrowBookMemberFineBlocked" {
+				fineRepo.On("SumOutstandingByMemberID").Return(150.0, nil)
 			} else {
+				fineRepo.On("SumOutstandingByMemberID").Return(0.0, nil)
+			}
+			bookSvc := services.NewBookService(bookRepo, memberRepo, copyRepo, db.NewLoanRepositoryMock(), fineRepo, holdRepo, config.Loan{PeriodDays: 14}, config.Fine{RatePerDay: 5, BlockThreshold: 100})
 
+			resp, err := bookSvc.BorrowBook(testActor, tC.requestId, 0, tC.memberId, tC.copyId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
 				assert.Equal(t, tC.expectSuccess, resp)
 			}
 
@@ -166,11 +436,11 @@ func TestReturnBook(t *testing.T) {
 			name:      "TestReturnBookSuccess",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -183,11 +453,11 @@ func TestReturnBook(t *testing.T) {
 			name:      "TestReturnBookErrorInternalServerError",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -200,11 +470,11 @@ func TestReturnBook(t *testing.T) {
 			name:      "TestReturnBookFindNotFound",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -217,11 +487,11 @@ func TestReturnBook(t *testing.T) {
 			name:      "TestReturnBookNotMatchFindNotFound",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -234,23 +504,109 @@ func TestReturnBook(t *testing.T) {
 			name:      "TestReturnBookReturned",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: false,
+				ID:              1,
+				Title:           "title test2",
+				Author:          "author test2",
+				Category:        "category test2",
+				TotalCopies:     1,
+				AvailableCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookReturnSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.BookReturnErrorMessage),
+		},
+		{
+			name:      "TestReturnBookWithoutLoan",
+			requestId: 1,
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookReturnSuccessMessage,
+				Data:    nil,
+			},
+			expectError: nil,
+		},
+		{
+			name:      "TestReturnBookLoanErrorInternalServerError",
+			requestId: 1,
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookReturnSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+		{
+			name:      "TestReturnBookCopyRequired",
+			requestId: 1,
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 2,
 			},
 
 			expectSuccess: models.BookResponse{
 				Message: constant.BookReturnSuccessMessage,
 				Data:    nil,
 			},
+			expectError: errors.New(constant.BookCopyRequiredErrorMessage),
+		},
+		{
+			name:      "TestReturnBookCopyReturnedMeanwhile",
+			requestId: 1,
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
 			expectError: errors.New(constant.BookReturnErrorMessage),
 		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.name, func(t *testing.T) {
 			bookRepo := db.NewBookRepositoryMock()
+			loanRepo := db.NewLoanRepositoryMock()
+			copyRepo := db.NewCopyRepositoryMock()
+			dueAt := time.Now().Add(-3 * 24 * time.Hour)
+			switch tC.name {
+			case "TestReturnBookReturned":
+				copyRepo.On("FindBorrowedByBookID").Return([]models.CopyRepository{}, nil)
+			case "TestReturnBookCopyRequired":
+				copyRepo.On("FindBorrowedByBookID").Return([]models.CopyRepository{
+					{ID: 1, BookID: 1, IsBorrowed: true},
+					{ID: 2, BookID: 1, IsBorrowed: true},
+				}, nil)
+			default:
+				copyRepo.On("FindBorrowedByBookID").Return([]models.CopyRepository{{ID: 1, BookID: 1, IsBorrowed: true}}, nil)
+			}
+			switch tC.name {
+			case "TestReturnBookWithoutLoan":
+				loanRepo.On("FindOpenByCopyID").Return(models.LoanRepository{}, gorm.ErrRecordNotFound)
+			case "TestReturnBookLoanErrorInternalServerError":
+				loanRepo.On("FindOpenByCopyID").Return(models.LoanRepository{}, errors.New(""))
+			default:
+				loanRepo.On("FindOpenByCopyID").Return(models.LoanRepository{ID: 1, BookID: 1, MemberID: 1, DueAt: dueAt}, nil)
+			}
 
 			switch tC.name {
 			case "TestReturnBookFindNotFound":
@@ -269,19 +625,23 @@ func TestReturnBook(t *testing.T) {
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("ReturnBook").Return(tC.expectError)
 				break
+			case "TestReturnBookCopyReturnedMeanwhile":
+				bookRepo.On("FindByID").Return(tC.mockData, nil)
+				bookRepo.On("ReturnBook").Return(db.ErrCopyNotBorrowed)
+				break
 			default:
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("ReturnBook").Return(nil)
 				break
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), copyRepo, loanRepo, db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{RatePerDay: 5})
 
-			resp, err := bookSvc.ReturnBook(tC.requestId)
-			if err != nil {
-				assert.EqualError(t, tC.expectError, err.Error())
+			resp, err := bookSvc.ReturnBook(testActor, tC.requestId, 0, 0)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
 			} else {
-
+				assert.NoError(t, err)
 				assert.Equal(t, tC.expectSuccess, resp)
 			}
 
@@ -289,67 +649,260 @@ func TestReturnBook(t *testing.T) {
 	}
 }
 
-func TestCreateBook(t *testing.T) {
+func TestRenewBook(t *testing.T) {
 
 	loggers.InitLogger(config.App{Env: "dev"})
 	testCases := []struct {
 		name          string
-		request       models.BookRequest
+		requestId     int
+		memberId      int
 		mockData      models.BookRepository
 		expectSuccess models.BookResponse
 		expectError   error
 	}{
 		{
-			name: "createBookSuccess",
-			request: models.BookRequest{
-				Title:    "title test2",
-				Author:   "author test2",
-				Category: "category test2",
-			},
-			mockData: models.BookRepository{
-				Title:    "title test2",
-				Author:   "author test2",
-				Category: "category test2",
-			},
-
+			name:      "TestRenewBookSuccess",
+			requestId: 1,
+			memberId:  2,
+			mockData:  models.BookRepository{ID: 1, Title: "title test2", Author: "author test2", Category: "category test2", TotalCopies: 1},
 			expectSuccess: models.BookResponse{
-				Message: constant.BookCreateSuccessMessage,
-				Data:    nil,
+				Message: constant.BookRenewSuccessMessage,
 			},
 			expectError: nil,
 		},
 		{
-			name: "createBookError",
-			request: models.BookRequest{
-				Title:    "Ttitle test2",
-				Author:   "author test2",
-				Category: "category test2",
-			},
-			mockData: models.BookRepository{
-				Title:    "title test2",
-				Author:   "author test2",
-				Category: "category test2",
-			},
-
-			expectSuccess: models.BookResponse{
-				Message: constant.BookCreateSuccessMessage,
-				Data:    nil,
-			},
-			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+			name:        "TestRenewBookMemberFindNotFound",
+			requestId:   1,
+			memberId:    2,
+			mockData:    models.BookRepository{ID: 1, TotalCopies: 1},
+			expectError: errors.New(constant.MemberErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestRenewBookFindNotFound",
+			requestId:   1,
+			memberId:    2,
+			mockData:    models.BookRepository{},
+			expectError: errors.New(constant.BookErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestRenewBookNotBorrowed",
+			requestId:   1,
+			memberId:    2,
+			mockData:    models.BookRepository{ID: 1, TotalCopies: 1},
+			expectError: errors.New(constant.BookNotBorrowedErrorMessage),
+		},
+		{
+			name:        "TestRenewBookLimitReached",
+			requestId:   1,
+			memberId:    2,
+			mockData:    models.BookRepository{ID: 1, TotalCopies: 1},
+			expectError: errors.New(constant.BookRenewLimitErrorMessage),
+		},
+		{
+			name:        "TestRenewBookLimitReachedMeanwhile",
+			requestId:   1,
+			memberId:    2,
+			mockData:    models.BookRepository{ID: 1, TotalCopies: 1},
+			expectError: errs.NewConflictError(constant.BookRenewLimitErrorMessage),
+		},
+		{
+			name:        "TestRenewBookOverdue",
+			requestId:   1,
+			memberId:    2,
+			mockData:    models.BookRepository{ID: 1, TotalCopies: 1},
+			expectError: errors.New(constant.BookRenewOverdueErrorMessage),
+		},
+		{
+			name:        "TestRenewBookReserved",
+			requestId:   1,
+			memberId:    2,
+			mockData:    models.BookRepository{ID: 1, TotalCopies: 1},
+			expectError: errors.New(constant.BookReservedErrorMessage),
 		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.name, func(t *testing.T) {
-
 			bookRepo := db.NewBookRepositoryMock()
-			if tC.expectError != nil {
-				bookRepo.On("Create").Return(errors.New(constant.BookErrorMessageInternalServerError))
-			} else {
+			memberRepo := db.NewMemberRepositoryMock()
+			loanRepo := db.NewLoanRepositoryMock()
+			holdRepo := db.NewHoldRepositoryMock()
+			dueAt := time.Now().Add(5 * 24 * time.Hour)
+
+			switch tC.name {
+			case "TestRenewBookMemberFindNotFound":
+				memberRepo.On("FindByID").Return(models.MemberRepository{}, gorm.ErrRecordNotFound)
+			default:
+				memberRepo.On("FindByID").Return(models.MemberRepository{ID: 2}, nil)
+			}
+			switch tC.name {
+			case "TestRenewBookFindNotFound":
+				bookRepo.On("FindByID").Return(tC.mockData, gorm.ErrRecordNotFound)
+			default:
+				bookRepo.On("FindByID").Return(tC.mockData, nil)
+			}
+			switch tC.name {
+			case "TestRenewBookNotBorrowed":
+				loanRepo.On("FindOpenByMember").Return(models.LoanRepository{}, gorm.ErrRecordNotFound)
+			case "TestRenewBookLimitReached":
+				loanRepo.On("FindOpenByMember").Return(models.LoanRepository{ID: 1, BookID: 1, CopyID: 1, MemberID: 2, DueAt: dueAt, RenewCount: 2}, nil)
+			case "TestRenewBookOverdue":
+				loanRepo.On("FindOpenByMember").Return(models.LoanRepository{ID: 1, BookID: 1, CopyID: 1, MemberID: 2, DueAt: time.Now().Add(-24 * time.Hour)}, nil)
+			default:
+				loanRepo.On("FindOpenByMember").Return(models.LoanRepository{ID: 1, BookID: 1, CopyID: 1, MemberID: 2, DueAt: dueAt, RenewCount: 1}, nil)
+			}
+			switch tC.name {
+			case "TestRenewBookReserved":
+				holdRepo.On("FindActiveByBookID").Return([]models.HoldRepository{{ID: 1, BookID: 1, MemberID: 3, Status: models.HoldStatusWaiting}}, nil)
+			default:
+				holdRepo.On("FindActiveByBookID").Return([]models.HoldRepository{}, nil)
+			}
+			holdRepo.On("AdvanceQueue").Return(nil)
+			switch tC.name {
+			case "TestRenewBookLimitReachedMeanwhile":
+				loanRepo.On("Renew").Return(db.ErrRenewLimit)
+			default:
+				loanRepo.On("Renew").Return(nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, memberRepo, db.NewCopyRepositoryMock(), loanRepo, db.NewFineRepositoryMock(), holdRepo, config.Loan{PeriodDays: 14, MaxRenewals: 2}, config.Fine{RatePerDay: 5})
+
+			resp, err := bookSvc.RenewBook(testActor, tC.requestId, 0, tC.memberId, 0)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+// isbnOf is the BookRequest ISBN of code.
+func isbnOf(code string) *string {
+	return &code
+}
+
+func TestCreateBook(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		request       models.BookRequest
+		mockData      models.BookRepository
+		expectSuccess models.BookResponse
+		expectError   error
+	}{
+		{
+			name: "createBookSuccess",
+			request: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+			mockData: models.BookRepository{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookCreateSuccessMessage,
+				Data:    nil,
+			},
+			expectError: nil,
+		},
+		{
+			name: "createBookError",
+			request: models.BookRequest{
+				Title:    "Ttitle test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+			mockData: models.BookRepository{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookCreateSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+		{
+			name: "createBookIsbnSuccess",
+			request: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf("0-306-40615-2"),
+			},
+			expectSuccess: models.BookResponse{
+				Message: constant.BookCreateSuccessMessage,
+				Data:    nil,
+			},
+			expectError: nil,
+		},
+		{
+			name: "createBookIsbnInvalid",
+			request: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf("0-306-40615-3"),
+			},
+			expectError: errors.New(constant.BookISBNInvalidErrorMessage),
+		},
+		{
+			name: "createBookIsbnExists",
+			request: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf("978-0-306-40615-7"),
+			},
+			mockData: models.BookRepository{
+				ID:       3,
+				Title:    "title test3",
+				Author:   "author test3",
+				Category: "category test3",
+			},
+			expectError: errors.New(constant.BookISBNExistsErrorMessage),
+		},
+		{
+			// another book took the ISBN after the FindByISBN check
+			name: "createBookIsbnExistsMeanwhile",
+			request: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf("978-0-306-40615-7"),
+			},
+			expectError: errs.NewBadRequest(constant.BookISBNExistsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+
+			bookRepo := db.NewBookRepositoryMock()
+			switch tC.name {
+			case "createBookIsbnExists":
+				bookRepo.On("FindByISBN").Return(tC.mockData, nil)
+			default:
+				bookRepo.On("FindByISBN").Return(models.BookRepository{}, gorm.ErrRecordNotFound)
+			}
+			if tC.name == "createBookIsbnExistsMeanwhile" {
+				bookRepo.On("Create").Return(gorm.ErrDuplicatedKey)
+			} else if tC.expectError != nil {
+				bookRepo.On("Create").Return(errors.New(constant.BookErrorMessageInternalServerError))
+			} else {
 				bookRepo.On("Create").Return(nil)
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
-			resp, err := bookSvc.CreateBook(tC.request)
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			resp, err := bookSvc.CreateBook(testActor, tC.request)
 			if err != nil {
 				assert.EqualError(t, tC.expectError, err.Error())
 			} else {
@@ -374,11 +927,11 @@ func TestDeleteBook(t *testing.T) {
 			name:      "TestDeleteBookSuccess",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -391,11 +944,11 @@ func TestDeleteBook(t *testing.T) {
 			name:      "TestDeleteBookErrorInternalServerError",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -408,11 +961,11 @@ func TestDeleteBook(t *testing.T) {
 			name:      "TestDeleteBookFindNotFound",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -425,11 +978,11 @@ func TestDeleteBook(t *testing.T) {
 			name:      "TestDeleteBookNotMatchFindNotFound",
 			requestId: 1,
 			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
 			},
 
 			expectSuccess: models.BookResponse{
@@ -438,6 +991,18 @@ func TestDeleteBook(t *testing.T) {
 			},
 			expectError: errors.New(constant.BookErrorMessageInternalServerError),
 		},
+		{
+			name:      "TestDeleteBookBorrowed",
+			requestId: 1,
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+			expectError: errors.New(constant.BookDeleteBorrowedErrorMessage),
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.name, func(t *testing.T) {
@@ -456,18 +1021,23 @@ func TestDeleteBook(t *testing.T) {
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("Delete").Return(tC.expectError)
 				break
+			case "TestDeleteBookBorrowed":
+				bookRepo.On("FindByID").Return(tC.mockData, nil)
+				bookRepo.On("Delete").Return(db.ErrBookBorrowed)
+				break
 			default:
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("Delete").Return(nil)
 				break
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
 
-			resp, err := bookSvc.DeleteBook(tC.requestId)
-			if err != nil {
-				assert.EqualError(t, tC.expectError, err.Error())
+			resp, err := bookSvc.DeleteBook(testActor, tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
 			} else {
+				assert.NoError(t, err)
 
 				assert.Equal(t, tC.expectSuccess, resp)
 			}
@@ -493,7 +1063,7 @@ func TestGetBookByID(t *testing.T) {
 				Title:       "title test2",
 				Author:      "author test2",
 				Category:    "category test2",
-				IsBorrowed:  true,
+				TotalCopies: 1,
 				BorrowCount: 1,
 				CreateAt:    time.Now(),
 				UpdateAt:    time.Now(),
@@ -507,6 +1077,7 @@ func TestGetBookByID(t *testing.T) {
 					Author:      "author test2",
 					Category:    "category test2",
 					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now().Format(dateFormat),
 					UpdateAt:    time.Now().Format(dateFormat),
@@ -522,7 +1093,7 @@ func TestGetBookByID(t *testing.T) {
 				Title:       "title test2",
 				Author:      "author test2",
 				Category:    "category test2",
-				IsBorrowed:  true,
+				TotalCopies: 1,
 				BorrowCount: 1,
 				CreateAt:    time.Now(),
 				UpdateAt:    time.Now(),
@@ -542,7 +1113,7 @@ func TestGetBookByID(t *testing.T) {
 				Title:       "title test2",
 				Author:      "author test2",
 				Category:    "category test2",
-				IsBorrowed:  true,
+				TotalCopies: 1,
 				BorrowCount: 1,
 				CreateAt:    time.Now(),
 				UpdateAt:    time.Now(),
@@ -562,7 +1133,7 @@ func TestGetBookByID(t *testing.T) {
 				Title:       "title test2",
 				Author:      "author test2",
 				Category:    "category test2",
-				IsBorrowed:  true,
+				TotalCopies: 1,
 				BorrowCount: 1,
 				CreateAt:    time.Now(),
 				UpdateAt:    time.Now(),
@@ -596,7 +1167,7 @@ func TestGetBookByID(t *testing.T) {
 				break
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
 
 			resp, err := bookSvc.GetBookByID(tC.requestId)
 			if err != nil {
@@ -609,6 +1180,76 @@ func TestGetBookByID(t *testing.T) {
 		})
 	}
 }
+func TestGetBookByISBN(t *testing.T) {
+	const dateFormat = "02/01/2006"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	isbn13 := "9780306406157"
+	testCases := []struct {
+		name          string
+		requestIsbn   string
+		mockData      models.BookRepository
+		expectSuccess models.BookResponse
+		expectError   error
+	}{
+		{
+			name:        "TestGetBookByISBN10Success",
+			requestIsbn: "0306406152",
+			mockData:    models.BookRepository{ID: 1, Title: "title test2", Author: "author test2", Category: "category test2", ISBN: &isbn13, TotalCopies: 1, AvailableCopies: 1, CreateAt: now, UpdateAt: now},
+			expectSuccess: models.BookResponse{
+				Message: constant.BookGetSuccessMessage,
+				Data:    &models.BookData{ID: 1, Title: "title test2", Author: "author test2", Category: "category test2", ISBN13: "9780306406157", ISBN10: "0306406152", TotalCopies: 1, AvailableCopies: 1, CreateAt: now.Format(dateFormat), UpdateAt: now.Format(dateFormat)},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetBookByISBNInvalid",
+			requestIsbn: "9780306406158",
+			expectError: errors.New(constant.BookISBNInvalidErrorMessage),
+		},
+		{
+			name:        "TestGetBookByISBNFindNotFound",
+			requestIsbn: "978-0-306-40615-7",
+			expectError: errors.New(constant.BookErrorsMessageFindISBNNotFound),
+		},
+		{
+			name:        "TestGetBookByISBNInTrash",
+			requestIsbn: "978-0-306-40615-7",
+			mockData:    models.BookRepository{ID: 1, Title: "title test2", ISBN: &isbn13, DeletedAt: gorm.DeletedAt{Time: now, Valid: true}},
+			expectError: errors.New(constant.BookErrorsMessageFindISBNNotFound),
+		},
+		{
+			name:        "TestGetBookByISBNErrorInternalServerError",
+			requestIsbn: "978-0-306-40615-7",
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+
+			switch tC.name {
+			case "TestGetBookByISBNFindNotFound":
+				bookRepo.On("FindByISBN").Return(tC.mockData, gorm.ErrRecordNotFound)
+			case "TestGetBookByISBNErrorInternalServerError":
+				bookRepo.On("FindByISBN").Return(tC.mockData, errors.New(""))
+			default:
+				bookRepo.On("FindByISBN").Return(tC.mockData, nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			resp, err := bookSvc.GetBookByISBN(tC.requestIsbn)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
 func TestGetMostBorrowedBooks(t *testing.T) {
 	const dateFormat = "02/01/2006"
 	loggers.InitLogger(config.App{Env: "dev"})
@@ -626,7 +1267,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -636,7 +1277,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -645,6 +1286,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 
 			expectSuccess: models.BookListResponse{
 				Message: constant.BookGetSuccessMessage,
+				Total:   2,
 				Data: []models.BookData{
 					{
 						ID:          1,
@@ -652,6 +1294,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 						Author:      "author test2",
 						Category:    "category test2",
 						IsBorrowed:  true,
+						TotalCopies: 1,
 						BorrowCount: 10,
 						CreateAt:    time.Now().Format(dateFormat),
 						UpdateAt:    time.Now().Format(dateFormat),
@@ -662,6 +1305,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 						Author:      "author test2",
 						Category:    "category test2",
 						IsBorrowed:  true,
+						TotalCopies: 1,
 						BorrowCount: 1,
 						CreateAt:    time.Now().Format(dateFormat),
 						UpdateAt:    time.Now().Format(dateFormat),
@@ -678,7 +1322,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -688,7 +1332,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -709,7 +1353,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -719,7 +1363,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -740,7 +1384,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -750,7 +1394,7 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -770,22 +1414,22 @@ func TestGetMostBorrowedBooks(t *testing.T) {
 
 			switch tC.name {
 			case "TestGetMostBorrowedBooksByIDFindNotFound":
-				bookRepo.On("FindAll").Return(tC.mockData, gorm.ErrRecordNotFound)
+				bookRepo.On("FindMostBorrowed").Return(tC.mockData, gorm.ErrRecordNotFound)
 				break
 			case "TestGetMostBorrowedBooksByIDNotMatchFindNotFound":
-				bookRepo.On("FindAll").Return(tC.mockData, errors.New(""))
+				bookRepo.On("FindMostBorrowed").Return(tC.mockData, errors.New(""))
 
 				break
 			case "TestGetMostBorrowedBooksByIDErrorInternalServerError":
-				bookRepo.On("FindAll").Return(tC.mockData, errors.New(""))
+				bookRepo.On("FindMostBorrowed").Return(tC.mockData, errors.New(""))
 
 				break
 			default:
-				bookRepo.On("FindAll").Return(tC.mockData, nil)
+				bookRepo.On("FindMostBorrowed").Return(tC.mockData, nil)
 				break
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
 
 			resp, err := bookSvc.GetMostBorrowedBooks()
 			if err != nil {
@@ -821,7 +1465,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -831,7 +1475,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -839,7 +1483,11 @@ func TestSearchBooks(t *testing.T) {
 			},
 
 			expectSuccess: models.BookListResponse{
-				Message: constant.BookGetSuccessMessage,
+				Message:  constant.BookGetSuccessMessage,
+				Total:    2,
+				Page:     1,
+				PageSize: 20,
+				Facets:   &models.BookFacets{Category: []models.FacetCount{}, Author: []models.FacetCount{}},
 				Data: []models.BookData{
 					{
 						ID:          1,
@@ -847,6 +1495,7 @@ func TestSearchBooks(t *testing.T) {
 						Author:      "author test2",
 						Category:    "category test2",
 						IsBorrowed:  true,
+						TotalCopies: 1,
 						BorrowCount: 10,
 						CreateAt:    time.Now().Format(dateFormat),
 						UpdateAt:    time.Now().Format(dateFormat),
@@ -857,6 +1506,7 @@ func TestSearchBooks(t *testing.T) {
 						Author:      "author test2",
 						Category:    "category test2",
 						IsBorrowed:  true,
+						TotalCopies: 1,
 						BorrowCount: 1,
 						CreateAt:    time.Now().Format(dateFormat),
 						UpdateAt:    time.Now().Format(dateFormat),
@@ -876,7 +1526,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -886,7 +1536,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -910,7 +1560,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -920,7 +1570,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -944,7 +1594,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 10,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -954,7 +1604,7 @@ func TestSearchBooks(t *testing.T) {
 					Title:       "title test2",
 					Author:      "author test2",
 					Category:    "category test2",
-					IsBorrowed:  true,
+					TotalCopies: 1,
 					BorrowCount: 1,
 					CreateAt:    time.Now(),
 					UpdateAt:    time.Now(),
@@ -972,26 +1622,27 @@ func TestSearchBooks(t *testing.T) {
 		t.Run(tC.name, func(t *testing.T) {
 			bookRepo := db.NewBookRepositoryMock()
 
+			bookRepo.On("FindFacets").Return([]models.BookFacetRepository{}, nil)
 			switch tC.name {
 			case "TestSearchBooksByIDFindNotFound":
-				bookRepo.On("FindAll").Return(tC.mockData, gorm.ErrRecordNotFound)
+				bookRepo.On("FindAll").Return(tC.mockData, int64(0), gorm.ErrRecordNotFound)
 				break
 			case "TestSearchBooksByIDNotMatchFindNotFound":
-				bookRepo.On("FindAll").Return(tC.mockData, errors.New(""))
+				bookRepo.On("FindAll").Return(tC.mockData, int64(0), errors.New(""))
 
 				break
 			case "TestSearchBooksByIDErrorInternalServerError":
-				bookRepo.On("FindAll").Return(tC.mockData, errors.New(""))
+				bookRepo.On("FindAll").Return(tC.mockData, int64(0), errors.New(""))
 
 				break
 			default:
-				bookRepo.On("FindAll").Return(tC.mockData, nil)
+				bookRepo.On("FindAll").Return(tC.mockData, int64(len(tC.mockData)), nil)
 				break
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
 
-			resp, err := bookSvc.SearchBooks(tC.title, tC.author, tC.category)
+			resp, err := bookSvc.SearchBooks(models.BookSearchRequest{Title: tC.title, Author: tC.author, Category: tC.category})
 			if err != nil {
 				assert.EqualError(t, tC.expectError, err.Error())
 			} else {
@@ -1002,62 +1653,623 @@ func TestSearchBooks(t *testing.T) {
 		})
 	}
 }
-func TestUpdateBook(t *testing.T) {
 
+func TestSearchBooksPagination(t *testing.T) {
+	const dateFormat = "02/01/2006"
 	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	borrowed := false
 	testCases := []struct {
 		name          string
-		requestId     int
-		requestBody   models.BookRequest
-		mockData      models.BookRepository
-		expectSuccess models.BookResponse
-		expectError   error
+		request       models.BookSearchRequest
+		expectSuccess models.BookListResponse
 	}{
 		{
-			name:      "TestUpdateBookSuccess",
-			requestId: 1,
-			requestBody: models.BookRequest{
-
-				Title:    "title test2",
-				Author:   "author test2",
-				Category: "category test2",
-			},
-			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
-			},
-
-			expectSuccess: models.BookResponse{
-				Message: constant.BookUpdateSuccessMessage,
-				Data:    nil,
+			name:    "TestSearchBooksDefaultPage",
+			request: models.BookSearchRequest{},
+			expectSuccess: models.BookListResponse{
+				Message:  constant.BookGetSuccessMessage,
+				Total:    2,
+				Page:     1,
+				PageSize: 20,
+				Facets:   &models.BookFacets{Category: []models.FacetCount{}, Author: []models.FacetCount{}},
+				Data: []models.BookData{
+					{ID: 2, Title: "title test2", Author: "author test2", Category: "category test2", BorrowCount: 1, TotalCopies: 1, AvailableCopies: 1, CreateAt: now.Format(dateFormat), UpdateAt: now.Format(dateFormat)},
+				},
 			},
-			expectError: nil,
 		},
 		{
-			name:      "TestUpdateBookErrorInternalServerError",
-			requestId: 1,
-			requestBody: models.BookRequest{
-
-				Title:    "title test2",
-				Author:   "author test2",
-				Category: "category test2",
-			},
-			mockData: models.BookRepository{
-				ID:         1,
-				Title:      "title test2",
-				Author:     "author test2",
-				Category:   "category test2",
-				IsBorrowed: true,
-			},
-
-			expectSuccess: models.BookResponse{
-				Message: constant.BookUpdateSuccessMessage,
-				Data:    nil,
+			name:    "TestSearchBooksQuery",
+			request: models.BookSearchRequest{Query: "title test", PageSize: 5},
+			expectSuccess: models.BookListResponse{
+				Message:  constant.BookGetSuccessMessage,
+				Total:    2,
+				Page:     1,
+				PageSize: 5,
+				Facets:   &models.BookFacets{Category: []models.FacetCount{}, Author: []models.FacetCount{}},
+				Data: []models.BookData{
+					{ID: 2, Title: "title test2", Author: "author test2", Category: "category test2", BorrowCount: 1, TotalCopies: 1, AvailableCopies: 1, CreateAt: now.Format(dateFormat), UpdateAt: now.Format(dateFormat)},
+				},
 			},
-			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+		{
+			name:    "TestSearchBooksSecondPage",
+			request: models.BookSearchRequest{IsBorrowed: &borrowed, Sort: "borrow_count", Order: "desc", Page: 2, PageSize: 1},
+			expectSuccess: models.BookListResponse{
+				Message:  constant.BookGetSuccessMessage,
+				Total:    2,
+				Page:     2,
+				PageSize: 1,
+				Facets:   &models.BookFacets{Category: []models.FacetCount{}, Author: []models.FacetCount{}},
+				Data: []models.BookData{
+					{ID: 2, Title: "title test2", Author: "author test2", Category: "category test2", BorrowCount: 1, TotalCopies: 1, AvailableCopies: 1, CreateAt: now.Format(dateFormat), UpdateAt: now.Format(dateFormat)},
+				},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			bookRepo.On("FindAll").Return([]models.BookRepository{
+				{ID: 2, Title: "title test2", Author: "author test2", Category: "category test2", BorrowCount: 1, TotalCopies: 1, AvailableCopies: 1, CreateAt: now, UpdateAt: now},
+			}, int64(2), nil)
+			bookRepo.On("FindFacets").Return([]models.BookFacetRepository{}, nil)
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+
+			resp, err := bookSvc.SearchBooks(tC.request)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectSuccess, resp)
+
+		})
+	}
+}
+func TestSearchBooksFacets(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	testCases := []struct {
+		name          string
+		request       models.BookSearchRequest
+		mockData      []models.BookFacetRepository
+		expectSuccess *models.BookFacets
+		expectError   error
+	}{
+		{
+			name:    "TestSearchBooksFacetsSuccess",
+			request: models.BookSearchRequest{Query: "title"},
+			mockData: []models.BookFacetRepository{
+				{Facet: models.BookFacetAuthor, Value: "author test1", Count: 2},
+				{Facet: models.BookFacetAuthor, Value: "author test2", Count: 1},
+				{Facet: models.BookFacetAvailability, Value: models.BookAvailable, Count: 2},
+				{Facet: models.BookFacetAvailability, Value: models.BookBorrowed, Count: 1},
+				{Facet: models.BookFacetCategory, Value: "category test1", Count: 3},
+			},
+			expectSuccess: &models.BookFacets{
+				Category:  []models.FacetCount{{Value: "category test1", Count: 3}},
+				Author:    []models.FacetCount{{Value: "author test1", Count: 2}, {Value: "author test2", Count: 1}},
+				Borrowed:  1,
+				Available: 2,
+			},
+			expectError: nil,
+		},
+		{
+			name:    "TestSearchBooksFacetsFuzzySuccess",
+			request: models.BookSearchRequest{Query: "title", Mode: services.SearchModeFuzzy},
+			expectSuccess: &models.BookFacets{
+				Category:  []models.FacetCount{{Value: "category test1", Count: 3}},
+				Author:    []models.FacetCount{{Value: "author test1", Count: 2}, {Value: "author test2", Count: 1}},
+				Borrowed:  1,
+				Available: 2,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestSearchBooksFacetsErrorInternalServerError",
+			request:     models.BookSearchRequest{Query: "title"},
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			bookRepo.On("FindAll").Return([]models.BookRepository{
+				{ID: 1, Title: "title test1", Author: "author test2", Category: "category test1", TotalCopies: 1, CreateAt: now, UpdateAt: now},
+				{ID: 2, Title: "title test2", Author: "author test1", Category: "category test1", TotalCopies: 1, AvailableCopies: 1, CreateAt: now, UpdateAt: now},
+				{ID: 3, Title: "title test3", Author: "author test1", Category: "category test1", CreateAt: now, UpdateAt: now},
+			}, int64(3), nil)
+			switch tC.name {
+			case "TestSearchBooksFacetsErrorInternalServerError":
+				bookRepo.On("FindFacets").Return([]models.BookFacetRepository{}, errors.New(""))
+			default:
+				bookRepo.On("FindFacets").Return(tC.mockData, nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+
+			resp, err := bookSvc.SearchBooks(tC.request)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp.Facets)
+			}
+
+		})
+	}
+}
+
+func TestSearchBooksFuzzy(t *testing.T) {
+	const dateFormat = "02/01/2006"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	books := []models.BookRepository{
+		{ID: 1, Title: "Harry Potter and the Philosopher's Stone", Author: "J.K. Rowling", Category: "Fantasy", CreateAt: now, UpdateAt: now},
+		{ID: 2, Title: "Café Society", Author: "Émile Zola", Category: "Novel", CreateAt: now, UpdateAt: now},
+		{ID: 3, Title: "แฮร์รี่พอตเตอร์กับศิลาอาถรรพ์", Author: "เจ.เค. โรว์ลิ่ง", Category: "แฟนตาซี", CreateAt: now, UpdateAt: now},
+		{ID: 4, Title: "ความสุขของกะทิ", Author: "งามพรรณ เวชชาชีวะ", Category: "นวนิยาย", CreateAt: now, UpdateAt: now},
+		{ID: 5, Title: "The Harry Hole Collection", Author: "Jo Nesbø", Category: "Crime", CreateAt: now, UpdateAt: now},
+	}
+	testCases := []struct {
+		name            string
+		request         models.BookSearchRequest
+		expectIDs       []int
+		expectTotal     int64
+		expectTruncated bool
+	}{
+		{
+			name:        "TestSearchBooksFuzzyTypo",
+			request:     models.BookSearchRequest{Query: "hary pottr", Mode: services.SearchModeFuzzy},
+			expectIDs:   []int{1},
+			expectTotal: 1,
+		},
+		{
+			name:        "TestSearchBooksFuzzyRanked",
+			request:     models.BookSearchRequest{Query: "harry", Mode: services.SearchModeFuzzy},
+			expectIDs:   []int{1, 5},
+			expectTotal: 2,
+		},
+		{
+			name:        "TestSearchBooksFuzzyAccent",
+			request:     models.BookSearchRequest{Query: "CAFE emile", Mode: services.SearchModeFuzzy},
+			expectIDs:   []int{2},
+			expectTotal: 1,
+		},
+		{
+			name:        "TestSearchBooksFuzzyThaiWordInsideTitle",
+			request:     models.BookSearchRequest{Query: "พอตเตอร์", Mode: services.SearchModeFuzzy},
+			expectIDs:   []int{3},
+			expectTotal: 1,
+		},
+		{
+			name:        "TestSearchBooksFuzzyThaiTypo",
+			request:     models.BookSearchRequest{Query: "ความศุข", Mode: services.SearchModeFuzzy},
+			expectIDs:   []int{4},
+			expectTotal: 1,
+		},
+		{
+			// segmented into สุข|ของ, both inside ความสุขของกะทิ
+			name:        "TestSearchBooksFuzzyThaiAcrossWords",
+			request:     models.BookSearchRequest{Query: "สุขของ", Mode: services.SearchModeFuzzy},
+			expectIDs:   []int{4},
+			expectTotal: 1,
+		},
+		{
+			name:        "TestSearchBooksFuzzySecondPage",
+			request:     models.BookSearchRequest{Query: "harry", Mode: services.SearchModeFuzzy, Page: 2, PageSize: 1},
+			expectIDs:   []int{5},
+			expectTotal: 2,
+		},
+		{
+			name:        "TestSearchBooksFuzzySortTitleDesc",
+			request:     models.BookSearchRequest{Query: "harry", Mode: services.SearchModeFuzzy, Sort: "title", Order: "desc"},
+			expectIDs:   []int{5, 1},
+			expectTotal: 2,
+		},
+		{
+			name:            "TestSearchBooksFuzzyTruncated",
+			request:         models.BookSearchRequest{Query: "harry", Mode: services.SearchModeFuzzy},
+			expectIDs:       []int{1, 5},
+			expectTotal:     2,
+			expectTruncated: true,
+		},
+		{
+			name:        "TestSearchBooksFuzzyNoMatch",
+			request:     models.BookSearchRequest{Query: "xyz", Mode: services.SearchModeFuzzy},
+			expectIDs:   []int{},
+			expectTotal: 0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			total := int64(len(books))
+			if tC.expectTruncated {
+				total = 5000
+			}
+			bookRepo.On("FindAll").Return(slices.Clone(books), total, nil)
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+
+			resp, err := bookSvc.SearchBooks(tC.request)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectTotal, resp.Total)
+			assert.Equal(t, tC.expectTruncated, resp.Truncated)
+			ids := []int{}
+			for i, book := range resp.Data {
+				ids = append(ids, book.ID)
+				assert.Greater(t, book.Score, 0.0)
+				assert.LessOrEqual(t, book.Score, 1.0)
+				assert.Equal(t, now.Format(dateFormat), book.CreateAt)
+				if i > 0 && tC.request.Sort == "" {
+					assert.GreaterOrEqual(t, resp.Data[i-1].Score, book.Score)
+				}
+			}
+			assert.Equal(t, tC.expectIDs, ids)
+
+		})
+	}
+}
+func TestImportBooks(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	rows := []models.BookImportRow{
+		{Line: 2, Book: models.BookRequest{Title: "title test1", Author: "author test1", Category: "category test1", ISBN: isbnOf("0-306-40615-2")}},
+		{Line: 3, Book: models.BookRequest{Title: "title test2", Author: "author test2", Category: "category test2"}},
+		{Line: 4, Book: models.BookRequest{Title: "title test3", Author: "author test3", Category: "category test3", ISBN: isbnOf("9780306406157")}},
+		{Line: 5, Book: models.BookRequest{Title: "title test4", Author: "author test4", Category: "category test4", ISBN: isbnOf("080442957X")}},
+		{Line: 6, Book: models.BookRequest{Title: "", Author: "author test5"}, Error: "Key: 'BookRequest.Title' Error:Field validation for 'Title' failed on the 'required' tag"},
+	}
+	marcRows := []models.BookImportRow{
+		{Line: 1, Book: models.BookRequest{Title: "title test1: subtitle", Author: "author test1", Category: "category test1"}, Unsupported: []string{"001", "245$c"}},
+		{Line: 2, Book: models.BookRequest{Title: "title test2", Author: "author test2", Category: "category test2"}, Unsupported: []string{"001", "650"}},
+		{Line: 3, Error: "marc: malformed record: invalid directory"},
+	}
+	testCases := []struct {
+		name          string
+		rows          []models.BookImportRow
+		dryRun        bool
+		expectSuccess models.BookImportResponse
+		expectError   error
+	}{
+		{
+			name: "TestImportBooksSuccess",
+			expectSuccess: models.BookImportResponse{
+				Message: constant.BookImportSuccessMessage,
+				Created: 2,
+				Skipped: 2,
+				Failed:  1,
+				Rows: []models.BookImportResult{
+					{Line: 2, Status: models.ImportCreated, Title: "title test1"},
+					{Line: 3, Status: models.ImportCreated, Title: "title test2"},
+					{Line: 4, Status: models.ImportSkipped, Title: "title test3", Error: constant.BookISBNExistsErrorMessage},
+					{Line: 5, Status: models.ImportSkipped, Title: "title test4", Error: constant.BookISBNExistsErrorMessage},
+					{Line: 6, Status: models.ImportFailed, Error: rows[4].Error},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:   "TestImportBooksDryRun",
+			dryRun: true,
+			expectSuccess: models.BookImportResponse{
+				Message: constant.BookImportDryRunSuccessMessage,
+				DryRun:  true,
+				Created: 2,
+				Skipped: 2,
+				Failed:  1,
+				Rows: []models.BookImportResult{
+					{Line: 2, Status: models.ImportCreated, Title: "title test1"},
+					{Line: 3, Status: models.ImportCreated, Title: "title test2"},
+					{Line: 4, Status: models.ImportSkipped, Title: "title test3", Error: constant.BookISBNExistsErrorMessage},
+					{Line: 5, Status: models.ImportSkipped, Title: "title test4", Error: constant.BookISBNExistsErrorMessage},
+					{Line: 6, Status: models.ImportFailed, Error: rows[4].Error},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name: "TestImportBooksCreateBatchError",
+			expectSuccess: models.BookImportResponse{
+				Message: constant.BookImportSuccessMessage,
+				Created: 0,
+				Skipped: 2,
+				Failed:  3,
+				Rows: []models.BookImportResult{
+					{Line: 2, Status: models.ImportFailed, Title: "title test1", Error: constant.BookErrorMessageInternalServerError},
+					{Line: 3, Status: models.ImportFailed, Title: "title test2", Error: constant.BookErrorMessageInternalServerError},
+					{Line: 4, Status: models.ImportSkipped, Title: "title test3", Error: constant.BookISBNExistsErrorMessage},
+					{Line: 5, Status: models.ImportSkipped, Title: "title test4", Error: constant.BookISBNExistsErrorMessage},
+					{Line: 6, Status: models.ImportFailed, Error: rows[4].Error},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name: "TestImportBooksUnsupportedFields",
+			rows: marcRows,
+			expectSuccess: models.BookImportResponse{
+				Message:     constant.BookImportSuccessMessage,
+				Created:     2,
+				Failed:      1,
+				Unsupported: map[string]int{"001": 2, "245$c": 1, "650": 1},
+				Rows: []models.BookImportResult{
+					{Line: 1, Status: models.ImportCreated, Title: "title test1: subtitle", Unsupported: []string{"001", "245$c"}},
+					{Line: 2, Status: models.ImportCreated, Title: "title test2", Unsupported: []string{"001", "650"}},
+					{Line: 3, Status: models.ImportFailed, Error: marcRows[2].Error},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestImportBooksErrorInternalServerError",
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			if tC.rows == nil {
+				tC.rows = rows
+			}
+
+			switch tC.name {
+			case "TestImportBooksErrorInternalServerError":
+				bookRepo.On("FindExistingISBNs").Return([]string{}, errors.New(""))
+			default:
+				// 080442957X is in the catalog already
+				bookRepo.On("FindExistingISBNs").Return([]string{"9780804429573"}, nil)
+			}
+			switch tC.name {
+			case "TestImportBooksCreateBatchError":
+				bookRepo.On("CreateBatch").Return(errors.New(""))
+			case "TestImportBooksSuccess", "TestImportBooksUnsupportedFields":
+				bookRepo.On("CreateBatch").Return(nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			resp, err := bookSvc.ImportBooks(testActor, &importRows{rows: tC.rows}, tC.dryRun)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+// importRows reads rows, then fails with err, or io.EOF when err is nil.
+type importRows struct {
+	rows []models.BookImportRow
+	err  error
+}
+
+func (r *importRows) Read() (models.BookImportRow, error) {
+	if len(r.rows) == 0 {
+		if r.err != nil {
+			return models.BookImportRow{}, r.err
+		}
+		return models.BookImportRow{}, io.EOF
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil
+}
+
+func TestImportBooksReadError(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	readErr := errs.NewBadRequest("unexpected EOF")
+	rows := func(n int) []models.BookImportRow {
+		rows := []models.BookImportRow{}
+		for i := 0; i < n; i++ {
+			rows = append(rows, models.BookImportRow{Line: i + 2, Book: models.BookRequest{Title: fmt.Sprintf("title test%d", i), Author: "author test1", Category: "category test1"}})
+		}
+		return rows
+	}
+	testCases := []struct {
+		name          string
+		rows          []models.BookImportRow
+		expectBatches int
+		expectRows    int
+		expectError   error
+	}{
+		{
+			name:        "TestImportBooksReadErrorFirstBatch",
+			rows:        rows(499),
+			expectError: readErr,
+		},
+		{
+			name:          "TestImportBooksReadErrorLaterBatch",
+			rows:          rows(501),
+			expectBatches: 2,
+			expectRows:    502,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			bookRepo.On("FindExistingISBNs").Return([]string{}, nil)
+			bookRepo.On("CreateBatch").Return(nil)
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			resp, err := bookSvc.ImportBooks(testActor, &importRows{rows: tC.rows, err: readErr}, false)
+			bookRepo.AssertNumberOfCalls(t, "CreateBatch", tC.expectBatches)
+			if tC.expectError != nil {
+				assert.Equal(t, tC.expectError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, resp.Rows, tC.expectRows)
+			assert.Equal(t, 501, resp.Created)
+			assert.Equal(t, 1, resp.Failed)
+			assert.Equal(t, models.BookImportResult{Line: 503, Status: models.ImportFailed, Error: "unexpected EOF"}, resp.Rows[501])
+		})
+	}
+}
+
+func TestNewBookImportReaderTooLarge(t *testing.T) {
+	testCases := []struct {
+		name   string
+		format string
+		body   string
+	}{
+		{"TestNewBookImportReaderTooLargeCSV", services.ImportFormatCSV, "title,author,category\n" + strings.Repeat("title test1,author test1,category test1\n", 10)},
+		{"TestNewBookImportReaderTooLargeNDJSON", services.ImportFormatNDJSON, strings.Repeat(`{"title":"title test1","author":"author test1","category":"category test1"}`+"\n", 10)},
+		{"TestNewBookImportReaderTooLargeMARCXML", services.ImportFormatMARCXML, "<collection>" + strings.Repeat(`<record><datafield tag="245"><subfield code="a">title test1</subfield></datafield></record>`, 10) + "</collection>"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(tC.body)), int64(len(tC.body)/2))
+			rows, err := services.NewBookImportReader(body, tC.format)
+			require.NoError(t, err)
+			for err == nil {
+				_, err = rows.Read()
+			}
+			var appErr errs.AppError
+			require.True(t, errors.As(err, &appErr), "%v", err)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, appErr.Code)
+		})
+	}
+}
+
+func TestExportBooks(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	isbn13 := "9780306406157"
+	books := []models.BookRepository{
+		{ID: 1, Title: "title, test1", Author: "author test1", Category: "category test1", ISBN: &isbn13, BorrowCount: 3, TotalCopies: 2, AvailableCopies: 1, Version: 4, CreateAt: now, UpdateAt: now},
+		{ID: 2, Title: "หนังสือ", Author: "author test2", Category: "category test2", Version: 1, CreateAt: now, UpdateAt: now},
+	}
+	testCases := []struct {
+		name         string
+		search       models.BookSearchRequest
+		format       string
+		expectOutput string
+		expectError  error
+	}{
+		{
+			name:   "TestExportBooksCSV",
+			format: services.ExportFormatCSV,
+			expectOutput: "id,title,author,category,isbn,borrow_count,total_copies,available_copies\n" +
+				"1,\"title, test1\",author test1,category test1,9780306406157,3,2,1\n" +
+				"2,หนังสือ,author test2,category test2,,0,0,0\n",
+		},
+		{
+			name:   "TestExportBooksNDJSON",
+			format: services.ExportFormatNDJSON,
+			expectOutput: `{"id":1,"title":"title, test1","author":"author test1","category":"category test1","isbn13":"9780306406157","isbn10":"0306406152","is_borrowed":false,"borrow_count":3,"total_copies":2,"available_copies":1,"version":4,"update_at":"02/01/2025","create_at":"02/01/2025"}` + "\n" +
+				`{"id":2,"title":"หนังสือ","author":"author test2","category":"category test2","is_borrowed":false,"borrow_count":0,"total_copies":0,"available_copies":0,"version":1,"update_at":"02/01/2025","create_at":"02/01/2025"}` + "\n",
+		},
+		{
+			name:   "TestExportBooksMARC21",
+			format: services.ExportFormatMARC21,
+			search: models.BookSearchRequest{Query: "tittle", Mode: services.SearchModeFuzzy},
+			expectOutput: "00159nam a2200085 u 4500" +
+				"001000200000" + "020001800002" + "100001700020" + "245001700037" + "650001900054" + "\x1e" +
+				"1\x1e" +
+				"  \x1fa9780306406157\x1e" +
+				"1 \x1faauthor test1\x1e" +
+				"10\x1fatitle, test1\x1e" +
+				" 4\x1facategory test1\x1e" +
+				"\x1d",
+		},
+		{
+			name:        "TestExportBooksFormatInvalid",
+			format:      "xml",
+			expectError: errors.New(constant.BookExportFormatErrorMessage),
+		},
+		{
+			name:        "TestExportBooksErrorInternalServerError",
+			format:      services.ExportFormatCSV,
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+
+			switch tC.name {
+			case "TestExportBooksErrorInternalServerError":
+				bookRepo.On("FindInBatches").Return(books, errors.New(""))
+			default:
+				bookRepo.On("FindInBatches").Return(books, nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			output := &strings.Builder{}
+			err := bookSvc.ExportBooks(tC.search, tC.format, output)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectOutput, output.String())
+			}
+
+		})
+	}
+}
+
+func TestUpdateBook(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		requestId     int
+		version       int
+		requestBody   models.BookRequest
+		mockData      models.BookRepository
+		expectSuccess models.BookResponse
+		expectError   error
+	}{
+		{
+			name:      "TestUpdateBookSuccess",
+			requestId: 1,
+			requestBody: models.BookRequest{
+
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookUpdateSuccessMessage,
+				Data:    nil,
+			},
+			expectError: nil,
+		},
+		{
+			name:      "TestUpdateBookErrorInternalServerError",
+			requestId: 1,
+			requestBody: models.BookRequest{
+
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+			mockData: models.BookRepository{
+				ID:          1,
+				Title:       "title test2",
+				Author:      "author test2",
+				Category:    "category test2",
+				TotalCopies: 1,
+			},
+
+			expectSuccess: models.BookResponse{
+				Message: constant.BookUpdateSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
 		},
 		{
 			name:      "TestUpdateBookFindNotFound",
@@ -1093,12 +2305,133 @@ func TestUpdateBook(t *testing.T) {
 			},
 			expectError: errors.New(constant.BookErrorMessageInternalServerError),
 		},
+		{
+			name:      "TestUpdateBookVersionMismatch",
+			requestId: 1,
+			version:   1,
+			requestBody: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+			mockData: models.BookRepository{
+				ID:      1,
+				Title:   "title test1",
+				Version: 2,
+			},
+			expectError: errors.New(constant.BookVersionMismatchErrorMessage),
+		},
+		{
+			name:      "TestUpdateBookVersionConflict",
+			requestId: 1,
+			requestBody: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+			mockData: models.BookRepository{
+				ID:      1,
+				Title:   "title test1",
+				Version: 2,
+			},
+			expectError: errors.New(constant.BookVersionConflictErrorMessage),
+		},
+		{
+			name:      "TestUpdateBookVersionConflictIfMatch",
+			requestId: 1,
+			version:   2,
+			requestBody: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+			},
+			mockData: models.BookRepository{
+				ID:      1,
+				Title:   "title test1",
+				Version: 2,
+			},
+			expectError: errors.New(constant.BookVersionMismatchErrorMessage),
+		},
+		{
+			name:      "TestUpdateBookIsbnRemoved",
+			requestId: 1,
+			requestBody: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf(""),
+			},
+			mockData: models.BookRepository{
+				ID:    1,
+				Title: "title test1",
+				ISBN:  isbnOf("9780306406157"),
+			},
+			expectSuccess: models.BookResponse{
+				Message: constant.BookUpdateSuccessMessage,
+			},
+		},
+		{
+			name:      "TestUpdateBookIsbnInvalid",
+			requestId: 1,
+			requestBody: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf("0-306-40615-3"),
+			},
+			mockData: models.BookRepository{
+				ID:    1,
+				Title: "title test1",
+			},
+			expectError: errors.New(constant.BookISBNInvalidErrorMessage),
+		},
+		{
+			name:      "TestUpdateBookIsbnExists",
+			requestId: 1,
+			requestBody: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf("978-0-306-40615-7"),
+			},
+			mockData: models.BookRepository{
+				ID:    1,
+				Title: "title test1",
+			},
+			expectError: errors.New(constant.BookISBNExistsErrorMessage),
+		},
+		{
+			// another book took the ISBN after the FindByISBN check
+			name:      "TestUpdateBookIsbnExistsMeanwhile",
+			requestId: 1,
+			requestBody: models.BookRequest{
+				Title:    "title test2",
+				Author:   "author test2",
+				Category: "category test2",
+				ISBN:     isbnOf("978-0-306-40615-7"),
+			},
+			mockData: models.BookRepository{
+				ID:    1,
+				Title: "title test1",
+			},
+			expectError: errs.NewBadRequest(constant.BookISBNExistsErrorMessage),
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.name, func(t *testing.T) {
 			bookRepo := db.NewBookRepositoryMock()
 
 			switch tC.name {
+			case "TestUpdateBookIsbnExists":
+				bookRepo.On("FindByID").Return(tC.mockData, nil)
+				bookRepo.On("FindByISBN").Return(models.BookRepository{ID: 3}, nil)
+				bookRepo.On("Update").Return(nil)
+				break
+			case "TestUpdateBookIsbnExistsMeanwhile":
+				bookRepo.On("FindByID").Return(tC.mockData, nil)
+				bookRepo.On("FindByISBN").Return(models.BookRepository{}, gorm.ErrRecordNotFound)
+				bookRepo.On("Update").Return(gorm.ErrDuplicatedKey)
+				break
 			case "TestUpdateBookFindNotFound":
 				bookRepo.On("FindByID").Return(tC.mockData, gorm.ErrRecordNotFound)
 				bookRepo.On("Update").Return(tC.expectError)
@@ -1111,16 +2444,22 @@ func TestUpdateBook(t *testing.T) {
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("Update").Return(tC.expectError)
 				break
+			case "TestUpdateBookVersionConflict", "TestUpdateBookVersionConflictIfMatch":
+				bookRepo.On("FindByID").Return(tC.mockData, nil)
+				bookRepo.On("Update").Return(db.ErrVersionConflict)
+				break
 			default:
 				bookRepo.On("FindByID").Return(tC.mockData, nil)
 				bookRepo.On("Update").Return(nil)
 				break
 			}
 
-			bookSvc := services.NewBookService(bookRepo)
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
 
-			resp, err := bookSvc.UpdateBook(tC.requestId, tC.requestBody)
-			if err != nil {
+			resp, err := bookSvc.UpdateBook(testActor, tC.requestId, tC.version, tC.requestBody)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else if err != nil {
 				assert.EqualError(t, tC.expectError, err.Error())
 			} else {
 
@@ -1130,3 +2469,167 @@ func TestUpdateBook(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBookLoans(t *testing.T) {
+	const dateTimeFormat = "02/01/2006 15:04:05"
+	loggers.InitLogger(config.App{Env: "dev"})
+	borrowedAt := time.Now().Add(-48 * time.Hour)
+	returnedAt := time.Now()
+	testCases := []struct {
+		name          string
+		requestId     int
+		mockData      []models.LoanRepository
+		expectSuccess models.LoanListResponse
+		expectError   error
+	}{
+		{
+			name:      "TestGetBookLoansSuccess",
+			requestId: 1,
+			mockData: []models.LoanRepository{
+				{
+					ID:         2,
+					BookID:     1,
+					MemberID:   1,
+					BorrowedAt: borrowedAt,
+					DueAt:      borrowedAt.Add(14 * 24 * time.Hour),
+				},
+				{
+					ID:         1,
+					BookID:     1,
+					MemberID:   2,
+					BorrowedAt: borrowedAt,
+					DueAt:      borrowedAt.Add(14 * 24 * time.Hour),
+					ReturnedAt: &returnedAt,
+				},
+			},
+			expectSuccess: models.LoanListResponse{
+				Message: constant.BookGetSuccessMessage,
+				Data: []models.LoanData{
+					{
+						ID:         2,
+						BookID:     1,
+						MemberID:   1,
+						BorrowedAt: borrowedAt.Format(dateTimeFormat),
+						DueAt:      borrowedAt.Add(14 * 24 * time.Hour).Format(dateTimeFormat),
+					},
+					{
+						ID:         1,
+						BookID:     1,
+						MemberID:   2,
+						BorrowedAt: borrowedAt.Format(dateTimeFormat),
+						DueAt:      borrowedAt.Add(14 * 24 * time.Hour).Format(dateTimeFormat),
+						ReturnedAt: returnedAt.Format(dateTimeFormat),
+					},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetBookLoansFindNotFound",
+			requestId:   1,
+			mockData:    []models.LoanRepository{},
+			expectError: errors.New(constant.BookErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestGetBookLoansErrorInternalServerError",
+			requestId:   1,
+			mockData:    []models.LoanRepository{},
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			loanRepo := db.NewLoanRepositoryMock()
+
+			switch tC.name {
+			case "TestGetBookLoansFindNotFound":
+				bookRepo.On("FindByID").Return(models.BookRepository{}, gorm.ErrRecordNotFound)
+				loanRepo.On("FindByBookID").Return(tC.mockData, nil)
+			case "TestGetBookLoansErrorInternalServerError":
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+				loanRepo.On("FindByBookID").Return(tC.mockData, errors.New(""))
+			default:
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+				loanRepo.On("FindByBookID").Return(tC.mockData, nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), loanRepo, db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+
+			resp, err := bookSvc.GetBookLoans(tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestGetOverdueLoans(t *testing.T) {
+	const dateTimeFormat = "02/01/2006 15:04:05"
+	loggers.InitLogger(config.App{Env: "dev"})
+	borrowedAt := time.Now().Add(-17*24*time.Hour - time.Hour)
+	dueAt := borrowedAt.Add(14 * 24 * time.Hour)
+	testCases := []struct {
+		name          string
+		mockData      []models.LoanRepository
+		expectSuccess models.LoanListResponse
+		expectError   error
+	}{
+		{
+			name: "TestGetOverdueLoansSuccess",
+			mockData: []models.LoanRepository{
+				{
+					ID:         1,
+					BookID:     1,
+					MemberID:   1,
+					BorrowedAt: borrowedAt,
+					DueAt:      dueAt,
+				},
+			},
+			expectSuccess: models.LoanListResponse{
+				Message: constant.BookGetSuccessMessage,
+				Data: []models.LoanData{
+					{
+						ID:         1,
+						BookID:     1,
+						MemberID:   1,
+						BorrowedAt: borrowedAt.Format(dateTimeFormat),
+						DueAt:      dueAt.Format(dateTimeFormat),
+						DaysLate:   4,
+					},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetOverdueLoansErrorInternalServerError",
+			mockData:    []models.LoanRepository{},
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			loanRepo := db.NewLoanRepositoryMock()
+			if tC.expectError != nil {
+				loanRepo.On("FindOverdue").Return(tC.mockData, errors.New(""))
+			} else {
+				loanRepo.On("FindOverdue").Return(tC.mockData, nil)
+			}
+
+			bookSvc := services.NewBookService(db.NewBookRepositoryMock(), db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), loanRepo, db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+
+			resp, err := bookSvc.GetOverdueLoans()
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
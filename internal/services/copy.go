@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type copyService struct {
+	repo     db.CopyRepository
+	bookRepo db.BookRepository
+}
+
+// CreateCopy implements CopyService.
+func (c copyService) CreateCopy(bookID int, bookCopy models.CopyRequest) (models.CopyResponse, error) {
+	_, err := c.bookRepo.FindByID(bookID)
+	if err != nil {
+		loggers.Error("Error FindByID book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.CopyResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindNotFound)
+		} else {
+			return models.CopyResponse{}, errs.NewInternalServerError(constant.CopyErrorMessageInternalServerError)
+		}
+	}
+	copyDataCreate := models.CopyRepository{
+		BookID:        bookID,
+		Barcode:       bookCopy.Barcode,
+		Condition:     bookCopy.Condition,
+		ShelfLocation: bookCopy.ShelfLocation,
+	}
+	err = c.repo.Create(copyDataCreate)
+	if err != nil {
+		loggers.Error("Error Create copy",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("request", copyDataCreate))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.CopyResponse{}, errs.NewBadRequest(constant.CopyBarcodeExistsErrorMessage)
+		} else {
+			return models.CopyResponse{}, errs.NewInternalServerError(constant.CopyErrorMessageInternalServerError)
+		}
+	}
+	return models.CopyResponse{
+		Message: constant.CopyCreateSuccessMessage,
+	}, nil
+}
+
+// UpdateCopy implements CopyService.
+func (c copyService) UpdateCopy(id int, bookCopy models.CopyRequest) (models.CopyResponse, error) {
+	copyRepo, err := c.repo.FindByID(id)
+	if err != nil {
+		loggers.Error("Error FindByID copy",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("copy_id", id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.CopyResponse{}, errs.NewNotFoundError(constant.CopyErrorsMessageFindNotFound)
+		} else {
+			return models.CopyResponse{}, errs.NewInternalServerError(constant.CopyErrorMessageInternalServerError)
+		}
+	}
+	copyDataUpdate := models.CopyRepository{
+		ID:            id,
+		BookID:        copyRepo.BookID,
+		Barcode:       bookCopy.Barcode,
+		Condition:     bookCopy.Condition,
+		ShelfLocation: bookCopy.ShelfLocation,
+	}
+	err = c.repo.Update(copyDataUpdate)
+	if err != nil {
+		loggers.Error("Error Update copy",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("request", copyDataUpdate))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.CopyResponse{}, errs.NewBadRequest(constant.CopyBarcodeExistsErrorMessage)
+		} else {
+			return models.CopyResponse{}, errs.NewInternalServerError(constant.CopyErrorMessageInternalServerError)
+		}
+	}
+	return models.CopyResponse{
+		Message: constant.CopyUpdateSuccessMessage,
+	}, nil
+}
+
+// GetCopies implements CopyService.
+func (c copyService) GetCopies(bookID int) (models.CopyListResponse, error) {
+	_, err := c.bookRepo.FindByID(bookID)
+	if err != nil {
+		loggers.Error("Error FindByID book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.CopyListResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindNotFound)
+		} else {
+			return models.CopyListResponse{}, errs.NewInternalServerError(constant.CopyErrorMessageInternalServerError)
+		}
+	}
+	copies, err := c.repo.FindByBookID(bookID)
+	if err != nil {
+		loggers.Error("Error FindByBookID copy",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		return models.CopyListResponse{}, errs.NewInternalServerError(constant.CopyErrorMessageInternalServerError)
+	}
+	copyList := []models.CopyData{}
+	for _, bookCopy := range copies {
+		copyData := models.CopyData{
+			ID:            bookCopy.ID,
+			BookID:        bookCopy.BookID,
+			Barcode:       bookCopy.Barcode,
+			Condition:     bookCopy.Condition,
+			ShelfLocation: bookCopy.ShelfLocation,
+			IsBorrowed:    bookCopy.IsBorrowed,
+			BorrowerID:    bookCopy.BorrowerID,
+			ReservedFor:   bookCopy.ReservedFor,
+			CreateAt:      bookCopy.CreateAt.Format(dateFormat),
+			UpdateAt:      bookCopy.UpdateAt.Format(dateFormat),
+		}
+		copyList = append(copyList, copyData)
+	}
+	return models.CopyListResponse{
+		Message: constant.CopyGetSuccessMessage,
+		Data:    copyList,
+	}, nil
+}
+
+func NewCopyService(repo db.CopyRepository, bookRepo db.BookRepository) CopyService {
+	return copyService{repo: repo, bookRepo: bookRepo}
+}
@@ -0,0 +1,241 @@
+package services_test
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCreateCopy(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		requestId     int
+		request       models.CopyRequest
+		expectSuccess models.CopyResponse
+		expectError   error
+	}{
+		{
+			name:      "TestCreateCopySuccess",
+			requestId: 1,
+			request: models.CopyRequest{
+				Barcode:       "B-0001",
+				Condition:     "new",
+				ShelfLocation: "A1",
+			},
+			expectSuccess: models.CopyResponse{
+				Message: constant.CopyCreateSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:      "TestCreateCopyBookFindNotFound",
+			requestId: 1,
+			request: models.CopyRequest{
+				Barcode:   "B-0001",
+				Condition: "new",
+			},
+			expectError: errors.New(constant.BookErrorsMessageFindNotFound),
+		},
+		{
+			name:      "TestCreateCopyErrorInternalServerError",
+			requestId: 1,
+			request: models.CopyRequest{
+				Barcode:   "B-0001",
+				Condition: "new",
+			},
+			expectError: errors.New(constant.CopyErrorMessageInternalServerError),
+		},
+		{
+			name:      "TestCreateCopyBarcodeExists",
+			requestId: 1,
+			request: models.CopyRequest{
+				Barcode:   "B-0001",
+				Condition: "new",
+			},
+			expectError: errors.New(constant.CopyBarcodeExistsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			copyRepo := db.NewCopyRepositoryMock()
+
+			switch tC.name {
+			case "TestCreateCopyBookFindNotFound":
+				bookRepo.On("FindByID").Return(models.BookRepository{}, gorm.ErrRecordNotFound)
+				copyRepo.On("Create").Return(nil)
+			case "TestCreateCopyErrorInternalServerError":
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+				copyRepo.On("Create").Return(errors.New(""))
+			case "TestCreateCopyBarcodeExists":
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+				copyRepo.On("Create").Return(gorm.ErrDuplicatedKey)
+			default:
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+				copyRepo.On("Create").Return(nil)
+			}
+
+			copySvc := services.NewCopyService(copyRepo, bookRepo)
+			resp, err := copySvc.CreateCopy(tC.requestId, tC.request)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestUpdateCopy(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		requestId     int
+		request       models.CopyRequest
+		expectSuccess models.CopyResponse
+		expectError   error
+	}{
+		{
+			name:      "TestUpdateCopySuccess",
+			requestId: 1,
+			request: models.CopyRequest{
+				Barcode:       "B-0001",
+				Condition:     "fair",
+				ShelfLocation: "B2",
+			},
+			expectSuccess: models.CopyResponse{
+				Message: constant.CopyUpdateSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:      "TestUpdateCopyFindNotFound",
+			requestId: 1,
+			request: models.CopyRequest{
+				Barcode:   "B-0001",
+				Condition: "fair",
+			},
+			expectError: errors.New(constant.CopyErrorsMessageFindNotFound),
+		},
+		{
+			name:      "TestUpdateCopyBarcodeExists",
+			requestId: 1,
+			request: models.CopyRequest{
+				Barcode:   "B-0002",
+				Condition: "fair",
+			},
+			expectError: errors.New(constant.CopyBarcodeExistsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			copyRepo := db.NewCopyRepositoryMock()
+
+			switch tC.name {
+			case "TestUpdateCopyFindNotFound":
+				copyRepo.On("FindByID").Return(models.CopyRepository{}, gorm.ErrRecordNotFound)
+				copyRepo.On("Update").Return(nil)
+			case "TestUpdateCopyBarcodeExists":
+				copyRepo.On("FindByID").Return(models.CopyRepository{ID: 1, BookID: 1}, nil)
+				copyRepo.On("Update").Return(gorm.ErrDuplicatedKey)
+			default:
+				copyRepo.On("FindByID").Return(models.CopyRepository{ID: 1, BookID: 1}, nil)
+				copyRepo.On("Update").Return(nil)
+			}
+
+			copySvc := services.NewCopyService(copyRepo, db.NewBookRepositoryMock())
+			resp, err := copySvc.UpdateCopy(tC.requestId, tC.request)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestGetCopies(t *testing.T) {
+	const dateFormat = "02/01/2006"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	testCases := []struct {
+		name          string
+		requestId     int
+		mockData      []models.CopyRepository
+		expectSuccess models.CopyListResponse
+		expectError   error
+	}{
+		{
+			name:      "TestGetCopiesSuccess",
+			requestId: 1,
+			mockData: []models.CopyRepository{
+				{ID: 1, BookID: 1, Barcode: "B-0001", Condition: "good", ShelfLocation: "A1", CreateAt: now, UpdateAt: now},
+				{ID: 2, BookID: 1, Barcode: "B-0002", Condition: "poor", IsBorrowed: true, BorrowerID: 3, CreateAt: now, UpdateAt: now},
+			},
+			expectSuccess: models.CopyListResponse{
+				Message: constant.CopyGetSuccessMessage,
+				Data: []models.CopyData{
+					{ID: 1, BookID: 1, Barcode: "B-0001", Condition: "good", ShelfLocation: "A1", CreateAt: now.Format(dateFormat), UpdateAt: now.Format(dateFormat)},
+					{ID: 2, BookID: 1, Barcode: "B-0002", Condition: "poor", IsBorrowed: true, BorrowerID: 3, CreateAt: now.Format(dateFormat), UpdateAt: now.Format(dateFormat)},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetCopiesBookFindNotFound",
+			requestId:   1,
+			mockData:    []models.CopyRepository{},
+			expectError: errors.New(constant.BookErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestGetCopiesErrorInternalServerError",
+			requestId:   1,
+			mockData:    []models.CopyRepository{},
+			expectError: errors.New(constant.CopyErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+			copyRepo := db.NewCopyRepositoryMock()
+
+			switch tC.name {
+			case "TestGetCopiesBookFindNotFound":
+				bookRepo.On("FindByID").Return(models.BookRepository{}, gorm.ErrRecordNotFound)
+				copyRepo.On("FindByBookID").Return(tC.mockData, nil)
+			case "TestGetCopiesErrorInternalServerError":
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+				copyRepo.On("FindByBookID").Return(tC.mockData, errors.New(""))
+			default:
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+				copyRepo.On("FindByBookID").Return(tC.mockData, nil)
+			}
+
+			copySvc := services.NewCopyService(copyRepo, bookRepo)
+			resp, err := copySvc.GetCopies(tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
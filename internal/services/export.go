@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/loggers"
+	"test-exam-forviz/marc"
+
+	"go.uber.org/zap"
+)
+
+// export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatMARC21 = "marc21"
+)
+
+const exportBatchSize = 500
+
+var exportCSVHeader = []string{"id", "title", "author", "category", "isbn", "borrow_count", "total_copies", "available_copies"}
+
+// bookExporter encodes books in one export format; flush hands what was
+// encoded so far to the underlying writer.
+type bookExporter struct {
+	write func(book models.BookRepository) error
+	flush func() error
+}
+
+func newBookExporter(format string, w io.Writer) (bookExporter, error) {
+	switch format {
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		// csv.Writer buffers, so the header only goes out with the first batch
+		if err := writer.Write(exportCSVHeader); err != nil {
+			return bookExporter{}, err
+		}
+		return bookExporter{
+			write: func(book models.BookRepository) error {
+				isbn := ""
+				if book.ISBN != nil {
+					isbn = *book.ISBN
+				}
+				return writer.Write([]string{strconv.Itoa(book.ID), book.Title, book.Author, book.Category, isbn,
+					strconv.Itoa(book.BorrowCount), strconv.Itoa(book.TotalCopies), strconv.Itoa(book.AvailableCopies)})
+			},
+			flush: func() error {
+				writer.Flush()
+				return writer.Error()
+			},
+		}, nil
+	case ExportFormatNDJSON:
+		writer := bufio.NewWriter(w)
+		encoder := json.NewEncoder(writer)
+		return bookExporter{
+			write: func(book models.BookRepository) error {
+				return encoder.Encode(toBookData(book))
+			},
+			flush: writer.Flush,
+		}, nil
+	case ExportFormatMARC21:
+		writer := marc.NewWriter(w)
+		return bookExporter{
+			write: func(book models.BookRepository) error {
+				return writer.Write(marc.FromBook(book))
+			},
+			flush: writer.Flush,
+		}, nil
+	}
+	return bookExporter{}, errs.NewBadRequest(constant.BookExportFormatErrorMessage)
+}
+
+// ExportBooks implements BookService.
+// Every book matching the search filters is written to w in format, a batch
+// at a time in id order; paging and sorting do not apply.
+func (b bookService) ExportBooks(search models.BookSearchRequest, format string, w io.Writer) error {
+	exporter, err := newBookExporter(format, w)
+	if err != nil {
+		return err
+	}
+	filter := toBookFilter(search)
+	fuzzy := isFuzzySearch(search)
+	terms := tokenize(search.Query)
+	if fuzzy {
+		filter.Query, filter.Fragments = "", fuzzyFragments(terms)
+	}
+	err = b.repo.FindInBatches(filter, exportBatchSize, func(books []models.BookRepository) error {
+		for _, book := range books {
+			if fuzzy && fuzzyScore(terms, book) == 0 {
+				continue
+			}
+			if err := exporter.write(book); err != nil {
+				return err
+			}
+		}
+		if err := exporter.flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		loggers.Error("Error FindInBatches book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("filter", filter),
+			zap.String("format", format))
+		return errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	// an empty export still gets its csv header
+	return exporter.flush()
+}
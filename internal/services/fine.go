@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type fineService struct {
+	repo       db.FineRepository
+	memberRepo db.MemberRepository
+}
+
+// GetOutstandingFines implements FineService.
+func (f fineService) GetOutstandingFines(memberID int) (models.FineListResponse, error) {
+	_, err := f.memberRepo.FindByID(memberID)
+	if err != nil {
+		loggers.Error("Error FindByID member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", memberID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.FineListResponse{}, errs.NewNotFoundError(constant.MemberErrorsMessageFindNotFound)
+		} else {
+			return models.FineListResponse{}, errs.NewInternalServerError(constant.FineErrorMessageInternalServerError)
+		}
+	}
+	fines, err := f.repo.FindOutstandingByMemberID(memberID)
+	if err != nil {
+		loggers.Error("Error FindOutstandingByMemberID fine",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", memberID))
+		return models.FineListResponse{}, errs.NewInternalServerError(constant.FineErrorMessageInternalServerError)
+	}
+	var total float64
+	fineList := []models.FineData{}
+	for _, fine := range fines {
+		total += fine.Amount
+		fineList = append(fineList, toFineData(fine))
+	}
+	return models.FineListResponse{
+		Message: constant.FineGetSuccessMessage,
+		Total:   roundAmount(total),
+		Data:    fineList,
+	}, nil
+}
+
+// PayFine implements FineService.
+func (f fineService) PayFine(id int) (models.FineResponse, error) {
+	fine, err := f.repo.FindByID(id)
+	if err != nil {
+		loggers.Error("Error FindByID fine",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("fine_id", id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.FineResponse{}, errs.NewNotFoundError(constant.FineErrorsMessageFindNotFound)
+		} else {
+			return models.FineResponse{}, errs.NewInternalServerError(constant.FineErrorMessageInternalServerError)
+		}
+	}
+	if fine.PaidAt != nil {
+		return models.FineResponse{}, errs.NewBadRequest(constant.FinePaidErrorMessage)
+	}
+	err = f.repo.Pay(id, time.Now())
+	if err != nil {
+		loggers.Error("Error Pay fine",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("fine_id", id))
+		if errors.Is(err, db.ErrFinePaid) {
+			return models.FineResponse{}, errs.NewConflictError(constant.FinePaidErrorMessage)
+		} else {
+			return models.FineResponse{}, errs.NewInternalServerError(constant.FineErrorMessageInternalServerError)
+		}
+	}
+	return models.FineResponse{
+		Message: constant.FinePaySuccessMessage,
+	}, nil
+}
+
+// calculateFine returns the fine owed for a loan returned at returnedAt.
+// Days inside the grace period are free, and the amount is capped by the
+// category cap if one is configured, otherwise by MaxAmount (0 means no cap).
+func calculateFine(cfg config.Fine, category string, dueAt, returnedAt time.Time) (float64, int) {
+	late := daysLate(dueAt, returnedAt)
+	chargeable := late - cfg.GraceDays
+	if chargeable <= 0 {
+		return 0, late
+	}
+	amount := float64(chargeable) * cfg.RatePerDay
+	maxAmount := cfg.MaxAmount
+	if categoryCap, ok := cfg.CategoryCaps[strings.ToLower(category)]; ok {
+		maxAmount = categoryCap
+	}
+	if maxAmount > 0 && amount > maxAmount {
+		amount = maxAmount
+	}
+	return roundAmount(amount), late
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func toFineData(fine models.FineRepository) models.FineData {
+	fineData := models.FineData{
+		ID:       fine.ID,
+		LoanID:   fine.LoanID,
+		MemberID: fine.MemberID,
+		BookID:   fine.BookID,
+		Amount:   fine.Amount,
+		DaysLate: fine.DaysLate,
+		CreateAt: fine.CreateAt.Format(dateTimeFormat),
+	}
+	if fine.PaidAt != nil {
+		fineData.PaidAt = fine.PaidAt.Format(dateTimeFormat)
+	}
+	return fineData
+}
+
+func NewFineService(repo db.FineRepository, memberRepo db.MemberRepository) FineService {
+	return fineService{repo: repo, memberRepo: memberRepo}
+}
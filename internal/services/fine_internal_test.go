@@ -0,0 +1,74 @@
+package services
+
+import (
+	"test-exam-forviz/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateFine(t *testing.T) {
+	dueAt := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name         string
+		cfg          config.Fine
+		category     string
+		returnedAt   time.Time
+		expectAmount float64
+		expectLate   int
+	}{
+		{
+			name:         "TestCalculateFineOnTime",
+			cfg:          config.Fine{RatePerDay: 5},
+			returnedAt:   dueAt,
+			expectAmount: 0,
+			expectLate:   0,
+		},
+		{
+			name:         "TestCalculateFinePartialDay",
+			cfg:          config.Fine{RatePerDay: 5},
+			returnedAt:   dueAt.Add(time.Hour),
+			expectAmount: 5,
+			expectLate:   1,
+		},
+		{
+			name:         "TestCalculateFineGracePeriod",
+			cfg:          config.Fine{RatePerDay: 5, GraceDays: 2},
+			returnedAt:   dueAt.Add(2 * 24 * time.Hour),
+			expectAmount: 0,
+			expectLate:   2,
+		},
+		{
+			name:         "TestCalculateFineAfterGracePeriod",
+			cfg:          config.Fine{RatePerDay: 2.5, GraceDays: 2},
+			returnedAt:   dueAt.Add(5 * 24 * time.Hour),
+			expectAmount: 7.5,
+			expectLate:   5,
+		},
+		{
+			name:         "TestCalculateFineMaxAmount",
+			cfg:          config.Fine{RatePerDay: 5, MaxAmount: 20},
+			returnedAt:   dueAt.Add(10 * 24 * time.Hour),
+			expectAmount: 20,
+			expectLate:   10,
+		},
+		{
+			name: "TestCalculateFineCategoryCap",
+			cfg: config.Fine{RatePerDay: 5, MaxAmount: 20, CategoryCaps: map[string]float64{
+				"children": 8,
+			}},
+			category:     "Children",
+			returnedAt:   dueAt.Add(10 * 24 * time.Hour),
+			expectAmount: 8,
+			expectLate:   10,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			amount, late := calculateFine(tC.cfg, tC.category, dueAt, tC.returnedAt)
+			assert.Equal(t, tC.expectAmount, amount)
+			assert.Equal(t, tC.expectLate, late)
+		})
+	}
+}
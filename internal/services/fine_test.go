@@ -0,0 +1,165 @@
+package services_test
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestGetOutstandingFines(t *testing.T) {
+	const dateTimeFormat = "02/01/2006 15:04:05"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	testCases := []struct {
+		name          string
+		requestId     int
+		mockData      []models.FineRepository
+		expectSuccess models.FineListResponse
+		expectError   error
+	}{
+		{
+			name:      "TestGetOutstandingFinesSuccess",
+			requestId: 1,
+			mockData: []models.FineRepository{
+				{ID: 1, LoanID: 1, MemberID: 1, BookID: 1, Amount: 10.5, DaysLate: 3, CreateAt: now},
+				{ID: 2, LoanID: 2, MemberID: 1, BookID: 2, Amount: 5, DaysLate: 1, CreateAt: now},
+			},
+			expectSuccess: models.FineListResponse{
+				Message: constant.FineGetSuccessMessage,
+				Total:   15.5,
+				Data: []models.FineData{
+					{ID: 1, LoanID: 1, MemberID: 1, BookID: 1, Amount: 10.5, DaysLate: 3, CreateAt: now.Format(dateTimeFormat)},
+					{ID: 2, LoanID: 2, MemberID: 1, BookID: 2, Amount: 5, DaysLate: 1, CreateAt: now.Format(dateTimeFormat)},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetOutstandingFinesMemberFindNotFound",
+			requestId:   1,
+			mockData:    []models.FineRepository{},
+			expectError: errors.New(constant.MemberErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestGetOutstandingFinesErrorInternalServerError",
+			requestId:   1,
+			mockData:    []models.FineRepository{},
+			expectError: errors.New(constant.FineErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			fineRepo := db.NewFineRepositoryMock()
+			memberRepo := db.NewMemberRepositoryMock()
+
+			switch tC.name {
+			case "TestGetOutstandingFinesMemberFindNotFound":
+				memberRepo.On("FindByID").Return(models.MemberRepository{}, gorm.ErrRecordNotFound)
+				fineRepo.On("FindOutstandingByMemberID").Return(tC.mockData, nil)
+			case "TestGetOutstandingFinesErrorInternalServerError":
+				memberRepo.On("FindByID").Return(models.MemberRepository{ID: 1}, nil)
+				fineRepo.On("FindOutstandingByMemberID").Return(tC.mockData, errors.New(""))
+			default:
+				memberRepo.On("FindByID").Return(models.MemberRepository{ID: 1}, nil)
+				fineRepo.On("FindOutstandingByMemberID").Return(tC.mockData, nil)
+			}
+
+			fineSvc := services.NewFineService(fineRepo, memberRepo)
+			resp, err := fineSvc.GetOutstandingFines(tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestPayFine(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	paidAt := time.Now()
+	testCases := []struct {
+		name          string
+		requestId     int
+		mockData      models.FineRepository
+		expectSuccess models.FineResponse
+		expectError   error
+	}{
+		{
+			name:      "TestPayFineSuccess",
+			requestId: 1,
+			mockData:  models.FineRepository{ID: 1, LoanID: 1, MemberID: 1, BookID: 1, Amount: 10},
+			expectSuccess: models.FineResponse{
+				Message: constant.FinePaySuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestPayFineFindNotFound",
+			requestId:   1,
+			mockData:    models.FineRepository{},
+			expectError: errors.New(constant.FineErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestPayFinePaid",
+			requestId:   1,
+			mockData:    models.FineRepository{ID: 1, LoanID: 1, MemberID: 1, BookID: 1, Amount: 10, PaidAt: &paidAt},
+			expectError: errors.New(constant.FinePaidErrorMessage),
+		},
+		{
+			name:        "TestPayFinePaidMeanwhile",
+			requestId:   1,
+			mockData:    models.FineRepository{ID: 1, LoanID: 1, MemberID: 1, BookID: 1, Amount: 10},
+			expectError: errs.NewConflictError(constant.FinePaidErrorMessage),
+		},
+		{
+			name:        "TestPayFineErrorInternalServerError",
+			requestId:   1,
+			mockData:    models.FineRepository{ID: 1, LoanID: 1, MemberID: 1, BookID: 1, Amount: 10},
+			expectError: errors.New(constant.FineErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			fineRepo := db.NewFineRepositoryMock()
+
+			switch tC.name {
+			case "TestPayFineFindNotFound":
+				fineRepo.On("FindByID").Return(tC.mockData, gorm.ErrRecordNotFound)
+				fineRepo.On("Pay").Return(nil)
+			case "TestPayFineErrorInternalServerError":
+				fineRepo.On("FindByID").Return(tC.mockData, nil)
+				fineRepo.On("Pay").Return(errors.New(""))
+			case "TestPayFinePaidMeanwhile":
+				fineRepo.On("FindByID").Return(tC.mockData, nil)
+				fineRepo.On("Pay").Return(db.ErrFinePaid)
+			default:
+				fineRepo.On("FindByID").Return(tC.mockData, nil)
+				fineRepo.On("Pay").Return(nil)
+			}
+
+			fineSvc := services.NewFineService(fineRepo, db.NewMemberRepositoryMock())
+			resp, err := fineSvc.PayFine(tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
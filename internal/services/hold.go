@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type holdService struct {
+	repo       db.HoldRepository
+	bookRepo   db.BookRepository
+	memberRepo db.MemberRepository
+	loanCfg    config.Loan
+}
+
+// PlaceHold implements HoldService.
+func (h holdService) PlaceHold(bookID, memberID int) (models.HoldResponse, error) {
+	member, err := h.memberRepo.FindByID(memberID)
+	if err != nil {
+		loggers.Error("Error FindByID member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", memberID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.HoldResponse{}, errs.NewNotFoundError(constant.MemberErrorsMessageFindNotFound)
+		} else {
+			return models.HoldResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+		}
+	}
+	if member.IsSuspended {
+		return models.HoldResponse{}, errs.NewBadRequest(constant.MemberSuspendedErrorMessage)
+	}
+	now := time.Now()
+	err = h.repo.AdvanceQueue(bookID, now, now.AddDate(0, 0, h.loanCfg.HoldPickupDays))
+	if err != nil {
+		loggers.Error("Error AdvanceQueue hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		return models.HoldResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+	}
+	book, err := h.bookRepo.FindByID(bookID)
+	if err != nil {
+		loggers.Error("Error FindByID book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.HoldResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindNotFound)
+		} else {
+			return models.HoldResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+		}
+	}
+	if book.AvailableCopies > 0 {
+		return models.HoldResponse{}, errs.NewBadRequest(constant.HoldBookAvailableErrorMessage)
+	}
+	_, err = h.repo.FindActiveByMember(bookID, memberID)
+	if err == nil {
+		return models.HoldResponse{}, errs.NewBadRequest(constant.HoldExistsErrorMessage)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		loggers.Error("Error FindActiveByMember hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID),
+			zap.Int("member_id", memberID))
+		return models.HoldResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+	}
+	holdDataCreate := models.HoldRepository{
+		BookID:   bookID,
+		MemberID: memberID,
+		Status:   models.HoldStatusWaiting,
+	}
+	err = h.repo.Create(holdDataCreate)
+	if err != nil {
+		loggers.Error("Error Create hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("request", holdDataCreate))
+		return models.HoldResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+	}
+	return models.HoldResponse{
+		Message: constant.HoldCreateSuccessMessage,
+	}, nil
+}
+
+// CancelHold implements HoldService.
+func (h holdService) CancelHold(bookID, memberID int) (models.HoldResponse, error) {
+	hold, err := h.repo.FindActiveByMember(bookID, memberID)
+	if err != nil {
+		loggers.Error("Error FindActiveByMember hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID),
+			zap.Int("member_id", memberID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.HoldResponse{}, errs.NewNotFoundError(constant.HoldErrorsMessageFindNotFound)
+		} else {
+			return models.HoldResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+		}
+	}
+	now := time.Now()
+	err = h.repo.Cancel(hold, now, now.AddDate(0, 0, h.loanCfg.HoldPickupDays))
+	if err != nil {
+		loggers.Error("Error Cancel hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("hold_id", hold.ID))
+		return models.HoldResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+	}
+	return models.HoldResponse{
+		Message: constant.HoldCancelSuccessMessage,
+	}, nil
+}
+
+// GetHolds implements HoldService.
+func (h holdService) GetHolds(bookID int) (models.HoldListResponse, error) {
+	_, err := h.bookRepo.FindByID(bookID)
+	if err != nil {
+		loggers.Error("Error FindByID book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.HoldListResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageFindNotFound)
+		} else {
+			return models.HoldListResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+		}
+	}
+	now := time.Now()
+	err = h.repo.AdvanceQueue(bookID, now, now.AddDate(0, 0, h.loanCfg.HoldPickupDays))
+	if err != nil {
+		loggers.Error("Error AdvanceQueue hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		return models.HoldListResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+	}
+	holds, err := h.repo.FindActiveByBookID(bookID)
+	if err != nil {
+		loggers.Error("Error FindActiveByBookID hold",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", bookID))
+		return models.HoldListResponse{}, errs.NewInternalServerError(constant.HoldErrorMessageInternalServerError)
+	}
+	holdList := []models.HoldData{}
+	for i, hold := range holds {
+		holdData := models.HoldData{
+			ID:       hold.ID,
+			BookID:   hold.BookID,
+			MemberID: hold.MemberID,
+			Position: i + 1,
+			Status:   hold.Status,
+			CopyID:   hold.CopyID,
+			CreateAt: hold.CreateAt.Format(dateTimeFormat),
+		}
+		if hold.ExpireAt != nil {
+			holdData.ExpireAt = hold.ExpireAt.Format(dateTimeFormat)
+		}
+		holdList = append(holdList, holdData)
+	}
+	return models.HoldListResponse{
+		Message: constant.HoldGetSuccessMessage,
+		Data:    holdList,
+	}, nil
+}
+
+func NewHoldService(repo db.HoldRepository, bookRepo db.BookRepository, memberRepo db.MemberRepository, loanCfg config.Loan) HoldService {
+	return holdService{repo: repo, bookRepo: bookRepo, memberRepo: memberRepo, loanCfg: loanCfg}
+}
@@ -0,0 +1,204 @@
+package services_test
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestPlaceHold(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		bookId        int
+		memberId      int
+		expectSuccess models.HoldResponse
+		expectError   error
+	}{
+		{
+			name:     "TestPlaceHoldSuccess",
+			bookId:   1,
+			memberId: 2,
+			expectSuccess: models.HoldResponse{
+				Message: constant.HoldCreateSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestPlaceHoldMemberFindNotFound",
+			bookId:      1,
+			memberId:    2,
+			expectError: errors.New(constant.MemberErrorsMessageFindNotFound),
+		},
+		{
+			name:        "TestPlaceHoldBookAvailable",
+			bookId:      1,
+			memberId:    2,
+			expectError: errors.New(constant.HoldBookAvailableErrorMessage),
+		},
+		{
+			name:        "TestPlaceHoldExists",
+			bookId:      1,
+			memberId:    2,
+			expectError: errors.New(constant.HoldExistsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			holdRepo := db.NewHoldRepositoryMock()
+			bookRepo := db.NewBookRepositoryMock()
+			memberRepo := db.NewMemberRepositoryMock()
+
+			holdRepo.On("AdvanceQueue").Return(nil)
+			holdRepo.On("Create").Return(nil)
+			switch tC.name {
+			case "TestPlaceHoldMemberFindNotFound":
+				memberRepo.On("FindByID").Return(models.MemberRepository{}, gorm.ErrRecordNotFound)
+			case "TestPlaceHoldBookAvailable":
+				memberRepo.On("FindByID").Return(models.MemberRepository{ID: 2}, nil)
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1, TotalCopies: 1, AvailableCopies: 1}, nil)
+			case "TestPlaceHoldExists":
+				memberRepo.On("FindByID").Return(models.MemberRepository{ID: 2}, nil)
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1, TotalCopies: 1}, nil)
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{ID: 1, BookID: 1, MemberID: 2, Status: models.HoldStatusWaiting}, nil)
+			default:
+				memberRepo.On("FindByID").Return(models.MemberRepository{ID: 2}, nil)
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1, TotalCopies: 1}, nil)
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{}, gorm.ErrRecordNotFound)
+			}
+
+			holdSvc := services.NewHoldService(holdRepo, bookRepo, memberRepo, config.Loan{HoldPickupDays: 3})
+			resp, err := holdSvc.PlaceHold(tC.bookId, tC.memberId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestCancelHold(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		bookId        int
+		memberId      int
+		expectSuccess models.HoldResponse
+		expectError   error
+	}{
+		{
+			name:     "TestCancelHoldSuccess",
+			bookId:   1,
+			memberId: 2,
+			expectSuccess: models.HoldResponse{
+				Message: constant.HoldCancelSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestCancelHoldFindNotFound",
+			bookId:      1,
+			memberId:    2,
+			expectError: errors.New(constant.HoldErrorsMessageFindNotFound),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			holdRepo := db.NewHoldRepositoryMock()
+
+			holdRepo.On("Cancel").Return(nil)
+			switch tC.name {
+			case "TestCancelHoldFindNotFound":
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{}, gorm.ErrRecordNotFound)
+			default:
+				holdRepo.On("FindActiveByMember").Return(models.HoldRepository{ID: 1, BookID: 1, MemberID: 2, Status: models.HoldStatusWaiting}, nil)
+			}
+
+			holdSvc := services.NewHoldService(holdRepo, db.NewBookRepositoryMock(), db.NewMemberRepositoryMock(), config.Loan{HoldPickupDays: 3})
+			resp, err := holdSvc.CancelHold(tC.bookId, tC.memberId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestGetHolds(t *testing.T) {
+	const dateTimeFormat = "02/01/2006 15:04:05"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	expireAt := now.AddDate(0, 0, 3)
+	testCases := []struct {
+		name          string
+		bookId        int
+		mockData      []models.HoldRepository
+		expectSuccess models.HoldListResponse
+		expectError   error
+	}{
+		{
+			name:   "TestGetHoldsSuccess",
+			bookId: 1,
+			mockData: []models.HoldRepository{
+				{ID: 1, BookID: 1, MemberID: 2, Status: models.HoldStatusReady, CopyID: 4, ReadyAt: &now, ExpireAt: &expireAt, CreateAt: now},
+				{ID: 2, BookID: 1, MemberID: 3, Status: models.HoldStatusWaiting, CreateAt: now},
+			},
+			expectSuccess: models.HoldListResponse{
+				Message: constant.HoldGetSuccessMessage,
+				Data: []models.HoldData{
+					{ID: 1, BookID: 1, MemberID: 2, Position: 1, Status: models.HoldStatusReady, CopyID: 4, ExpireAt: expireAt.Format(dateTimeFormat), CreateAt: now.Format(dateTimeFormat)},
+					{ID: 2, BookID: 1, MemberID: 3, Position: 2, Status: models.HoldStatusWaiting, CreateAt: now.Format(dateTimeFormat)},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetHoldsBookFindNotFound",
+			bookId:      1,
+			mockData:    []models.HoldRepository{},
+			expectError: errors.New(constant.BookErrorsMessageFindNotFound),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			holdRepo := db.NewHoldRepositoryMock()
+			bookRepo := db.NewBookRepositoryMock()
+
+			holdRepo.On("AdvanceQueue").Return(nil)
+			holdRepo.On("FindActiveByBookID").Return(tC.mockData, nil)
+			switch tC.name {
+			case "TestGetHoldsBookFindNotFound":
+				bookRepo.On("FindByID").Return(models.BookRepository{}, gorm.ErrRecordNotFound)
+			default:
+				bookRepo.On("FindByID").Return(models.BookRepository{ID: 1}, nil)
+			}
+
+			holdSvc := services.NewHoldService(holdRepo, bookRepo, db.NewMemberRepositoryMock(), config.Loan{HoldPickupDays: 3})
+			resp, err := holdSvc.GetHolds(tC.bookId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
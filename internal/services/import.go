@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/isbn"
+	"test-exam-forviz/loggers"
+
+	"go.uber.org/zap"
+)
+
+// importBatchSize rows are written per transaction, so a failing batch does
+// not roll back the rows imported before it.
+const importBatchSize = 500
+
+// ImportBooks implements BookService.
+// Rows are read and written importBatchSize at a time, so the file is never
+// held whole. A row is skipped when its ISBN belongs to a book already, or to
+// an earlier row of the same import. With dryRun nothing is written, but the
+// report is the same as for a real import. A file that cannot be read
+// within its first batch is an error; later, the batches before stay and the
+// report ends with a failed row where reading stopped.
+func (b bookService) ImportBooks(actor models.Actor, rows BookImportReader, dryRun bool) (models.BookImportResponse, error) {
+	resp := models.BookImportResponse{
+		Message: constant.BookImportSuccessMessage,
+		DryRun:  dryRun,
+		Rows:    []models.BookImportResult{},
+	}
+	if dryRun {
+		resp.Message = constant.BookImportDryRunSuccessMessage
+	}
+	seen := map[string]bool{}
+	batch := make([]models.BookImportRow, 0, importBatchSize)
+	for {
+		batch = batch[:0]
+		var readErr error
+		for len(batch) < importBatchSize {
+			row, err := rows.Read()
+			if err != nil {
+				readErr = err
+				break
+			}
+			batch = append(batch, row)
+		}
+		stopped := readErr != nil && !errors.Is(readErr, io.EOF)
+		if stopped && len(resp.Rows) == 0 {
+			return models.BookImportResponse{}, readErr
+		}
+		if len(batch) > 0 {
+			results, err := b.importBatch(actor, batch, seen, dryRun)
+			if err != nil {
+				return models.BookImportResponse{}, err
+			}
+			resp.Rows = append(resp.Rows, results...)
+		}
+		if stopped {
+			line := resp.Rows[len(resp.Rows)-1].Line + 1
+			resp.Rows = append(resp.Rows, models.BookImportResult{Line: line, Status: models.ImportFailed, Error: readErr.Error()})
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	for _, row := range resp.Rows {
+		for _, field := range row.Unsupported {
+			if resp.Unsupported == nil {
+				resp.Unsupported = map[string]int{}
+			}
+			resp.Unsupported[field]++
+		}
+		switch row.Status {
+		case models.ImportCreated:
+			resp.Created++
+		case models.ImportSkipped:
+			resp.Skipped++
+		default:
+			resp.Failed++
+		}
+	}
+	return resp, nil
+}
+
+func (b bookService) importBatch(actor models.Actor, batch []models.BookImportRow, seen map[string]bool, dryRun bool) ([]models.BookImportResult, error) {
+	results := make([]models.BookImportResult, len(batch))
+	isbns := []string{}
+	isbnByRow := make([]*string, len(batch))
+	for i, row := range batch {
+		results[i] = models.BookImportResult{Line: row.Line, Title: row.Book.Title, Unsupported: row.Unsupported}
+		if row.Error != "" || row.Book.ISBN == nil || *row.Book.ISBN == "" {
+			continue
+		}
+		isbn13, err := isbn.Normalize(*row.Book.ISBN)
+		if err != nil {
+			results[i].Status, results[i].Error = models.ImportFailed, constant.BookISBNInvalidErrorMessage
+			continue
+		}
+		isbnByRow[i] = &isbn13
+		isbns = append(isbns, isbn13)
+	}
+	existing, err := b.repo.FindExistingISBNs(isbns)
+	if err != nil {
+		loggers.Error("Error FindExistingISBNs book",
+			zap.String("type", "repo"),
+			zap.Error(err))
+		return nil, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	for _, isbn13 := range existing {
+		seen[isbn13] = true
+	}
+
+	books := []models.BookRepository{}
+	created := []int{}
+	for i, row := range batch {
+		switch {
+		case row.Error != "":
+			results[i].Status, results[i].Error = models.ImportFailed, row.Error
+		case results[i].Status == models.ImportFailed:
+		case isbnByRow[i] != nil && seen[*isbnByRow[i]]:
+			results[i].Status, results[i].Error = models.ImportSkipped, constant.BookISBNExistsErrorMessage
+		default:
+			if isbnByRow[i] != nil {
+				seen[*isbnByRow[i]] = true
+			}
+			results[i].Status = models.ImportCreated
+			books = append(books, models.BookRepository{
+				Title:    row.Book.Title,
+				Author:   row.Book.Author,
+				Category: row.Book.Category,
+				ISBN:     isbnByRow[i],
+				Version:  1,
+			})
+			created = append(created, i)
+		}
+	}
+	if dryRun || len(books) == 0 {
+		return results, nil
+	}
+	err = b.repo.CreateBatch(books, auditCreate(actor, models.AuditActionImport))
+	if err != nil {
+		loggers.Error("Error CreateBatch book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("first_line", batch[0].Line))
+		for _, i := range created {
+			results[i].Status, results[i].Error = models.ImportFailed, constant.BookErrorMessageInternalServerError
+			if isbnByRow[i] != nil {
+				delete(seen, *isbnByRow[i])
+			}
+		}
+		return results, nil
+	}
+	for n, i := range created {
+		results[i].ID = books[n].ID
+	}
+	return results, nil
+}
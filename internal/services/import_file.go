@@ -0,0 +1,210 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/marc"
+
+	"github.com/go-playground/validator"
+)
+
+// import formats
+const (
+	ImportFormatCSV     = "csv"
+	ImportFormatNDJSON  = "ndjson"
+	ImportFormatMARC21  = "marc21"
+	ImportFormatMARCXML = "marcxml"
+)
+
+// ImportFormatOf is the import format of a file name by its extension, empty
+// when the extension is not one of them.
+func ImportFormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return ImportFormatNDJSON
+	case ".mrc", ".marc":
+		return ImportFormatMARC21
+	case ".xml":
+		return ImportFormatMARCXML
+	}
+	return ""
+}
+
+// BookImportReader reads the rows of an import file one at a time and checks
+// each with the rules of models.BookRequest. A bad row comes back with its
+// Error set; only a file that cannot be read on is an error. Read returns
+// io.EOF after the last row.
+type BookImportReader interface {
+	Read() (models.BookImportRow, error)
+}
+
+// NewBookImportReader reads r in format. A CSV header is read here, so a file
+// without the required columns fails before any row.
+func NewBookImportReader(r io.Reader, format string) (BookImportReader, error) {
+	validate := NewBookValidator()
+	switch format {
+	case ImportFormatCSV:
+		return newBookCSVReader(r, validate)
+	case ImportFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		return &bookNDJSONReader{scanner: scanner, validate: validate}, nil
+	case ImportFormatMARC21:
+		return &bookMARCReader{reader: marc.NewReader(r), validate: validate}, nil
+	case ImportFormatMARCXML:
+		return &bookMARCReader{reader: marc.NewXMLReader(r), validate: validate}, nil
+	}
+	return nil, errs.NewBadRequest(constant.BookImportFormatErrorMessage)
+}
+
+// importReadError is the error of a file that could not be read on: 413 when
+// it is over the size limit of the request, otherwise message as a 400.
+func importReadError(err error, message string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errs.NewRequestEntityTooLargeError(constant.BookImportTooLargeErrorMessage)
+	}
+	return errs.NewBadRequest(message)
+}
+
+// bookCSVReader expects a header row naming the title, author, category and
+// optional isbn columns in any order.
+type bookCSVReader struct {
+	reader   *csv.Reader
+	columns  map[string]int
+	validate *validator.Validate
+}
+
+func newBookCSVReader(r io.Reader, validate *validator.Validate) (*bookCSVReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, importReadError(err, constant.BookImportHeaderErrorMessage)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"title", "author", "category"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errs.NewBadRequest(constant.BookImportHeaderErrorMessage)
+		}
+	}
+	return &bookCSVReader{reader: reader, columns: columns, validate: validate}, nil
+}
+
+func (c *bookCSVReader) field(record []string, name string) string {
+	i, ok := c.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// Read implements BookImportReader.
+func (c *bookCSVReader) Read() (models.BookImportRow, error) {
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return models.BookImportRow{}, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if !errors.As(err, &parseErr) {
+			return models.BookImportRow{}, importReadError(err, err.Error())
+		}
+		return models.BookImportRow{Line: parseErr.Line, Error: parseErr.Err.Error()}, nil
+	}
+	line, _ := c.reader.FieldPos(0)
+	row := models.BookImportRow{Line: line}
+	row.Book = models.BookRequest{
+		Title:    c.field(record, "title"),
+		Author:   c.field(record, "author"),
+		Category: c.field(record, "category"),
+	}
+	if isbnCode := c.field(record, "isbn"); isbnCode != "" {
+		row.Book.ISBN = &isbnCode
+	}
+	if err := c.validate.Struct(row.Book); err != nil {
+		row.Error = err.Error()
+	}
+	return row, nil
+}
+
+// bookNDJSONReader expects one models.BookRequest JSON object per line.
+type bookNDJSONReader struct {
+	scanner  *bufio.Scanner
+	line     int
+	validate *validator.Validate
+}
+
+// Read implements BookImportReader.
+func (n *bookNDJSONReader) Read() (models.BookImportRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		text := strings.TrimSpace(n.scanner.Text())
+		if text == "" {
+			continue
+		}
+		row := models.BookImportRow{Line: n.line}
+		if err := json.Unmarshal([]byte(text), &row.Book); err != nil {
+			row.Error = err.Error()
+		} else if err := n.validate.Struct(row.Book); err != nil {
+			row.Error = err.Error()
+		}
+		return row, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return models.BookImportRow{}, importReadError(err, err.Error())
+	}
+	return models.BookImportRow{}, io.EOF
+}
+
+// bookMARCReader maps each record with marc.ToBook; a record that cannot be
+// read becomes a failed row, while a broken file as a whole is an error.
+type bookMARCReader struct {
+	reader   marc.RecordReader
+	number   int
+	validate *validator.Validate
+}
+
+// Read implements BookImportReader.
+func (m *bookMARCReader) Read() (models.BookImportRow, error) {
+	record, err := m.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return models.BookImportRow{}, io.EOF
+	}
+	m.number++
+	row := models.BookImportRow{Line: m.number}
+	if errors.Is(err, marc.ErrMalformed) {
+		row.Error = err.Error()
+		return row, nil
+	}
+	if err != nil {
+		return models.BookImportRow{}, importReadError(err, err.Error())
+	}
+	book, unsupported := marc.ToBook(record)
+	row.Book = models.BookRequest{
+		Title:    book.Title,
+		Author:   book.Author,
+		Category: book.Category,
+		ISBN:     book.ISBN,
+	}
+	row.Unsupported = unsupported
+	if err := m.validate.Struct(row.Book); err != nil {
+		row.Error = err.Error()
+	}
+	return row, nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/loggers"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type memberService struct {
+	repo db.MemberRepository
+}
+
+// CreateMember implements MemberService.
+func (m memberService) CreateMember(member models.MemberRequest) (models.MemberResponse, error) {
+	memberDataCreate := models.MemberRepository{
+		Name:  member.Name,
+		Email: member.Email,
+		Phone: member.Phone,
+	}
+	err := m.repo.Create(memberDataCreate)
+	if err != nil {
+		loggers.Error("Error Create member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("request", memberDataCreate))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.MemberResponse{}, errs.NewBadRequest(constant.MemberEmailExistsErrorMessage)
+		} else {
+			return models.MemberResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+		}
+	}
+	return models.MemberResponse{
+		Message: constant.MemberCreateSuccessMessage,
+	}, nil
+}
+
+// UpdateMember implements MemberService.
+func (m memberService) UpdateMember(id int, member models.MemberRequest) (models.MemberResponse, error) {
+	memberRepo, err := m.repo.FindByID(id)
+	if err != nil {
+		loggers.Error("Error FindByID member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.MemberResponse{}, errs.NewNotFoundError(constant.MemberErrorsMessageFindNotFound)
+		} else {
+			return models.MemberResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+		}
+	}
+	memberDataUpdate := models.MemberRepository{
+		ID:          id,
+		Name:        member.Name,
+		Email:       member.Email,
+		Phone:       member.Phone,
+		IsSuspended: memberRepo.IsSuspended,
+	}
+	err = m.repo.Update(memberDataUpdate)
+	if err != nil {
+		loggers.Error("Error Update member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Any("request", memberDataUpdate))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.MemberResponse{}, errs.NewBadRequest(constant.MemberEmailExistsErrorMessage)
+		} else {
+			return models.MemberResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+		}
+	}
+	return models.MemberResponse{
+		Message: constant.MemberUpdateSuccessMessage,
+	}, nil
+}
+
+// SuspendMember implements MemberService.
+func (m memberService) SuspendMember(id int) (models.MemberResponse, error) {
+	member, err := m.repo.FindByID(id)
+	if err != nil {
+		loggers.Error("Error FindByID member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.MemberResponse{}, errs.NewNotFoundError(constant.MemberErrorsMessageFindNotFound)
+		} else {
+			return models.MemberResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+		}
+	}
+	if member.IsSuspended {
+		return models.MemberResponse{}, errs.NewBadRequest(constant.MemberSuspendedErrorMessage)
+	}
+	err = m.repo.Suspend(id)
+	if err != nil {
+		loggers.Error("Error Suspend member",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("member_id", id))
+		return models.MemberResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+	}
+	return models.MemberResponse{
+		Message: constant.MemberSuspendSuccessMessage,
+	}, nil
+}
+
+// GetMembers implements MemberService.
+func (m memberService) GetMembers() (models.MemberListResponse, error) {
+	members, err := m.repo.FindAll()
+	if err != nil {
+		loggers.Error("Error FindAll member",
+			zap.String("type", "repo"),
+			zap.Error(err))
+		return models.MemberListResponse{}, errs.NewInternalServerError(constant.MemberErrorMessageInternalServerError)
+	}
+	memberList := []models.MemberData{}
+	for _, member := range members {
+		memberData := models.MemberData{
+			ID:          member.ID,
+			Name:        member.Name,
+			Email:       member.Email,
+			Phone:       member.Phone,
+			IsSuspended: member.IsSuspended,
+			CreateAt:    member.CreateAt.Format(dateFormat),
+			UpdateAt:    member.UpdateAt.Format(dateFormat),
+		}
+		memberList = append(memberList, memberData)
+	}
+	return models.MemberListResponse{
+		Message: constant.MemberGetSuccessMessage,
+		Data:    memberList,
+	}, nil
+}
+
+func NewMemberService(repo db.MemberRepository) MemberService {
+	return memberService{repo: repo}
+}
@@ -0,0 +1,326 @@
+package services_test
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCreateMember(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		request       models.MemberRequest
+		expectSuccess models.MemberResponse
+		expectError   error
+	}{
+		{
+			name: "createMemberSuccess",
+			request: models.MemberRequest{
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectSuccess: models.MemberResponse{
+				Message: constant.MemberCreateSuccessMessage,
+				Data:    nil,
+			},
+			expectError: nil,
+		},
+		{
+			name: "createMemberError",
+			request: models.MemberRequest{
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectSuccess: models.MemberResponse{
+				Message: constant.MemberCreateSuccessMessage,
+				Data:    nil,
+			},
+			expectError: errors.New(constant.MemberErrorMessageInternalServerError),
+		},
+		{
+			name: "createMemberEmailExists",
+			request: models.MemberRequest{
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectError: errors.New(constant.MemberEmailExistsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+
+			memberRepo := db.NewMemberRepositoryMock()
+			switch tC.name {
+			case "createMemberError":
+				memberRepo.On("Create").Return(errors.New(constant.MemberErrorMessageInternalServerError))
+			case "createMemberEmailExists":
+				memberRepo.On("Create").Return(gorm.ErrDuplicatedKey)
+			default:
+				memberRepo.On("Create").Return(nil)
+			}
+
+			memberSvc := services.NewMemberService(memberRepo)
+			resp, err := memberSvc.CreateMember(tC.request)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestUpdateMember(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		requestId     int
+		requestBody   models.MemberRequest
+		mockData      models.MemberRepository
+		expectSuccess models.MemberResponse
+		expectError   error
+	}{
+		{
+			name:      "TestUpdateMemberSuccess",
+			requestId: 1,
+			requestBody: models.MemberRequest{
+				Name:  "member update",
+				Email: "member@test.com",
+			},
+			mockData: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectSuccess: models.MemberResponse{
+				Message: constant.MemberUpdateSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:      "TestUpdateMemberFindNotFound",
+			requestId: 1,
+			requestBody: models.MemberRequest{
+				Name:  "member update",
+				Email: "member@test.com",
+			},
+			mockData:    models.MemberRepository{},
+			expectError: errors.New(constant.MemberErrorsMessageFindNotFound),
+		},
+		{
+			name:      "TestUpdateMemberErrorInternalServerError",
+			requestId: 1,
+			requestBody: models.MemberRequest{
+				Name:  "member update",
+				Email: "member@test.com",
+			},
+			mockData: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectError: errors.New(constant.MemberErrorMessageInternalServerError),
+		},
+		{
+			name:      "TestUpdateMemberEmailExists",
+			requestId: 1,
+			requestBody: models.MemberRequest{
+				Name:  "member update",
+				Email: "taken@test.com",
+			},
+			mockData: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectError: errors.New(constant.MemberEmailExistsErrorMessage),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			memberRepo := db.NewMemberRepositoryMock()
+
+			switch tC.name {
+			case "TestUpdateMemberFindNotFound":
+				memberRepo.On("FindByID").Return(tC.mockData, gorm.ErrRecordNotFound)
+				memberRepo.On("Update").Return(nil)
+			case "TestUpdateMemberErrorInternalServerError":
+				memberRepo.On("FindByID").Return(tC.mockData, nil)
+				memberRepo.On("Update").Return(tC.expectError)
+			case "TestUpdateMemberEmailExists":
+				memberRepo.On("FindByID").Return(tC.mockData, nil)
+				memberRepo.On("Update").Return(gorm.ErrDuplicatedKey)
+			default:
+				memberRepo.On("FindByID").Return(tC.mockData, nil)
+				memberRepo.On("Update").Return(nil)
+			}
+
+			memberSvc := services.NewMemberService(memberRepo)
+			resp, err := memberSvc.UpdateMember(tC.requestId, tC.requestBody)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestSuspendMember(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		requestId     int
+		mockData      models.MemberRepository
+		expectSuccess models.MemberResponse
+		expectError   error
+	}{
+		{
+			name:      "TestSuspendMemberSuccess",
+			requestId: 1,
+			mockData: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectSuccess: models.MemberResponse{
+				Message: constant.MemberSuspendSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestSuspendMemberFindNotFound",
+			requestId:   1,
+			mockData:    models.MemberRepository{},
+			expectError: errors.New(constant.MemberErrorsMessageFindNotFound),
+		},
+		{
+			name:      "TestSuspendMemberSuspended",
+			requestId: 1,
+			mockData: models.MemberRepository{
+				ID:          1,
+				Name:        "member test",
+				Email:       "member@test.com",
+				IsSuspended: true,
+			},
+			expectError: errors.New(constant.MemberSuspendedErrorMessage),
+		},
+		{
+			name:      "TestSuspendMemberErrorInternalServerError",
+			requestId: 1,
+			mockData: models.MemberRepository{
+				ID:    1,
+				Name:  "member test",
+				Email: "member@test.com",
+			},
+			expectError: errors.New(constant.MemberErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			memberRepo := db.NewMemberRepositoryMock()
+
+			switch tC.name {
+			case "TestSuspendMemberFindNotFound":
+				memberRepo.On("FindByID").Return(tC.mockData, gorm.ErrRecordNotFound)
+				memberRepo.On("Suspend").Return(nil)
+			case "TestSuspendMemberErrorInternalServerError":
+				memberRepo.On("FindByID").Return(tC.mockData, nil)
+				memberRepo.On("Suspend").Return(tC.expectError)
+			default:
+				memberRepo.On("FindByID").Return(tC.mockData, nil)
+				memberRepo.On("Suspend").Return(nil)
+			}
+
+			memberSvc := services.NewMemberService(memberRepo)
+			resp, err := memberSvc.SuspendMember(tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestGetMembers(t *testing.T) {
+	const dateFormat = "02/01/2006"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	testCases := []struct {
+		name          string
+		mockData      []models.MemberRepository
+		expectSuccess models.MemberListResponse
+		expectError   error
+	}{
+		{
+			name: "TestGetMembersSuccess",
+			mockData: []models.MemberRepository{
+				{
+					ID:       1,
+					Name:     "member test",
+					Email:    "member@test.com",
+					CreateAt: now,
+					UpdateAt: now,
+				},
+			},
+			expectSuccess: models.MemberListResponse{
+				Message: constant.MemberGetSuccessMessage,
+				Data: []models.MemberData{
+					{
+						ID:       1,
+						Name:     "member test",
+						Email:    "member@test.com",
+						CreateAt: now.Format(dateFormat),
+						UpdateAt: now.Format(dateFormat),
+					},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetMembersErrorInternalServerError",
+			mockData:    []models.MemberRepository{},
+			expectError: errors.New(constant.MemberErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			memberRepo := db.NewMemberRepositoryMock()
+			if tC.expectError != nil {
+				memberRepo.On("FindAll").Return(tC.mockData, errors.New(""))
+			} else {
+				memberRepo.On("FindAll").Return(tC.mockData, nil)
+			}
+
+			memberSvc := services.NewMemberService(memberRepo)
+			resp, err := memberSvc.GetMembers()
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
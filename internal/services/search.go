@@ -0,0 +1,408 @@
+package services
+
+import (
+	"cmp"
+	_ "embed"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchModeFuzzy scores books in-process instead of asking the index, so a
+// query still matches through typos, accents and unspaced Thai text.
+const SearchModeFuzzy = "fuzzy"
+
+// fuzzyCandidates caps the books one fuzzy search fetches and scores. The
+// repository ranks them by the query fragments they hold, so the ones left
+// out are the least likely to match.
+const fuzzyCandidates = 1000
+
+func isFuzzySearch(search models.BookSearchRequest) bool {
+	return search.Mode == SearchModeFuzzy && strings.TrimSpace(search.Query) != ""
+}
+
+// field weights: a hit in the title counts more than one in the category
+const (
+	titleWeight    = 1.0
+	authorWeight   = 0.8
+	categoryWeight = 0.6
+)
+
+// normalizeText folds case and strips Latin accents ("Café" -> "cafe").
+// Thai vowel and tone marks are kept: they change the word.
+func normalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Thai, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return norm.NFC.String(b.String())
+}
+
+// searchWord is a word cut into the units edits are counted in: runes for
+// most scripts, character clusters for Thai.
+type searchWord struct {
+	units []string
+	thai  bool
+}
+
+// tokenize splits normalized text into words. Thai is written without spaces
+// between words, so a Thai run is cut into character clusters (a consonant
+// with its leading vowel and marks) and then into the words of thaiWords;
+// see segmentThai.
+func tokenize(s string) []searchWord {
+	words := []searchWord{}
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if unicode.Is(unicode.Thai, word[0]) {
+			for _, units := range segmentThai(thaiClusters(word)) {
+				words = append(words, searchWord{units: units, thai: true})
+			}
+		} else {
+			units := []string{}
+			for _, r := range word {
+				units = append(units, string(r))
+			}
+			words = append(words, searchWord{units: units})
+		}
+		word = word[:0]
+	}
+	for _, r := range normalizeText(s) {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r):
+			flush()
+		case len(word) > 0 && unicode.Is(unicode.Thai, r) != unicode.Is(unicode.Thai, word[0]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// thaiClusters groups Thai runes into units that cannot be split inside a word.
+func thaiClusters(word []rune) []string {
+	clusters := []string{}
+	cluster := []rune{}
+	for _, r := range word {
+		attach := len(cluster) > 0 &&
+			(unicode.Is(unicode.Mn, r) || // above/below vowels and tone marks
+				r == 'ะ' || r == 'า' || r == 'ำ' || // trailing vowels
+				isThaiLeadingVowel(cluster[len(cluster)-1]))
+		if !attach {
+			if len(cluster) > 0 {
+				clusters = append(clusters, string(cluster))
+			}
+			cluster = cluster[:0]
+		}
+		cluster = append(cluster, r)
+	}
+	if len(cluster) > 0 {
+		clusters = append(clusters, string(cluster))
+	}
+	return clusters
+}
+
+func isThaiLeadingVowel(r rune) bool {
+	return r >= 'เ' && r <= 'ไ'
+}
+
+//go:embed thai_words.txt
+var thaiWordList string
+
+// thaiWords is the dictionary segmentThai splits Thai runs with, and
+// maxThaiWord the most clusters a word of it has.
+var thaiWords, maxThaiWord = func() (map[string]bool, int) {
+	words, longest := map[string]bool{}, 0
+	for _, line := range strings.Split(thaiWordList, "\n") {
+		word := normalizeText(strings.TrimSpace(line))
+		if word == "" {
+			continue
+		}
+		words[word] = true
+		longest = max(longest, len(thaiClusters([]rune(word))))
+	}
+	return words, longest
+}()
+
+// segmentThai splits the clusters of a Thai run into dictionary words by
+// maximal matching: the split leaving the fewest clusters outside any word,
+// then the one with the fewest words. Clusters outside the dictionary, like
+// transliterated names, stay together as one word.
+func segmentThai(clusters []string) [][]string {
+	type split struct {
+		unknown, words int
+		start          int
+		known          bool
+	}
+	best := make([]split, len(clusters)+1)
+	for i := 1; i < len(best); i++ {
+		best[i] = split{unknown: math.MaxInt, words: math.MaxInt}
+	}
+	relax := func(end int, next split) {
+		if next.unknown < best[end].unknown || next.unknown == best[end].unknown && next.words < best[end].words {
+			best[end] = next
+		}
+	}
+	for i := range clusters {
+		from := best[i]
+		relax(i+1, split{unknown: from.unknown + 1, words: from.words + 1, start: i})
+		word := ""
+		for j := i; j < len(clusters) && j-i < maxThaiWord; j++ {
+			word += clusters[j]
+			if thaiWords[word] {
+				relax(j+1, split{unknown: from.unknown, words: from.words + 1, start: i, known: true})
+			}
+		}
+	}
+	words := [][]string{}
+	unknownEnd := -1
+	for end := len(clusters); end > 0; end = best[end].start {
+		switch {
+		case best[end].known:
+			words = append(words, clusters[best[end].start:end])
+			unknownEnd = -1
+		case unknownEnd < 0:
+			words = append(words, clusters[best[end].start:end])
+			unknownEnd = end
+		default:
+			// run on the unknown word after this cluster
+			words[len(words)-1] = clusters[best[end].start:unknownEnd]
+		}
+	}
+	slices.Reverse(words)
+	return words
+}
+
+// editDistance is the Levenshtein distance between a and b. With anywhere set
+// a may start and end at any unit of b, which finds a inside unspaced text.
+func editDistance(a, b []string, anywhere bool) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		if !anywhere {
+			prev[j] = j
+		}
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	if anywhere {
+		return slices.Min(prev)
+	}
+	return prev[len(b)]
+}
+
+// maxTypos grows with the word so short words do not match everything.
+// Thai clusters carry more than a rune, so fewer of them allow a typo.
+func maxTypos(term searchWord) int {
+	length := len(term.units)
+	if term.thai {
+		length *= 2
+	}
+	switch {
+	case length <= 3:
+		return 0
+	case length <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// termSimilarity rates how well a query term matches a document word, 0 for no
+// match. A term also matches the start of a longer word, so "harr" finds
+// "harry", and anywhere inside a Thai word.
+func termSimilarity(term, word searchWord) float64 {
+	t, w := term.units, word.units
+	if slices.Equal(t, w) {
+		return 1
+	}
+	var typos int
+	switch {
+	case term.thai && word.thai:
+		typos = editDistance(t, w, true)
+		if typos == 0 {
+			return 1
+		}
+	case term.thai != word.thai:
+		return 0
+	default:
+		if len(w) > len(t) && slices.Equal(t, w[:len(t)]) {
+			return 0.9
+		}
+		typos = editDistance(t, w, false)
+		if len(w) > len(t) {
+			typos = min(typos, editDistance(t, w[:len(t)], false))
+		}
+	}
+	if typos > maxTypos(term) {
+		return 0
+	}
+	return 0.8 * (1 - float64(typos)/float64(len(t)))
+}
+
+// fuzzyFragments are the fragments of terms a book needs in SQL before it is
+// worth scoring: per term its pairs of neighbouring units, or its single units
+// when a typo could break every pair (an edit breaks at most two), or the term
+// itself when it allows no typo. Every typo maxTypos allows leaves one of them
+// intact. Accents are only folded as far as the database folds them, so a word
+// whose every fragment holds an accented letter is missed.
+func fuzzyFragments(terms []searchWord) [][]string {
+	fragments := [][]string{}
+	for _, term := range terms {
+		typos := maxTypos(term)
+		size := 1
+		switch {
+		case typos == 0:
+			size = len(term.units)
+		case len(term.units)-1 > 2*typos:
+			size = 2
+		}
+		seen := map[string]bool{}
+		group := []string{}
+		for i := 0; i+size <= len(term.units); i++ {
+			fragment := strings.Join(term.units[i:i+size], "")
+			if !seen[fragment] {
+				seen[fragment] = true
+				group = append(group, fragment)
+			}
+		}
+		fragments = append(fragments, group)
+	}
+	return fragments
+}
+
+func fieldSimilarity(term searchWord, words []searchWord) float64 {
+	best := 0.0
+	for _, word := range words {
+		best = math.Max(best, termSimilarity(term, word))
+	}
+	return best
+}
+
+// fuzzyScore rates book against the query terms between 0 and 1. Every term
+// has to match some field, otherwise the book scores 0.
+func fuzzyScore(terms []searchWord, book models.BookRepository) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	title, author, category := tokenize(book.Title), tokenize(book.Author), tokenize(book.Category)
+	total := 0.0
+	for _, term := range terms {
+		best := math.Max(titleWeight*fieldSimilarity(term, title),
+			math.Max(authorWeight*fieldSimilarity(term, author), categoryWeight*fieldSimilarity(term, category)))
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return math.Round(total/float64(len(terms))*1000) / 1000
+}
+
+// bookSortFields compare two books by a BookSearchRequest sort field.
+var bookSortFields = map[string]func(a, b models.BookRepository) int{
+	"id":               func(a, b models.BookRepository) int { return cmp.Compare(a.ID, b.ID) },
+	"title":            func(a, b models.BookRepository) int { return strings.Compare(a.Title, b.Title) },
+	"author":           func(a, b models.BookRepository) int { return strings.Compare(a.Author, b.Author) },
+	"category":         func(a, b models.BookRepository) int { return strings.Compare(a.Category, b.Category) },
+	"borrow_count":     func(a, b models.BookRepository) int { return cmp.Compare(a.BorrowCount, b.BorrowCount) },
+	"available_copies": func(a, b models.BookRepository) int { return cmp.Compare(a.AvailableCopies, b.AvailableCopies) },
+	"create_at":        func(a, b models.BookRepository) int { return a.CreateAt.Compare(b.CreateAt) },
+	"update_at":        func(a, b models.BookRepository) int { return a.UpdateAt.Compare(b.UpdateAt) },
+}
+
+// sortBooks orders books by field, ties by id. The fuzzy candidates come
+// ranked by fragment hits, so the requested sort is applied here.
+func sortBooks(books []models.BookRepository, field, order string) {
+	compare, ok := bookSortFields[field]
+	if !ok {
+		return
+	}
+	slices.SortStableFunc(books, func(a, b models.BookRepository) int {
+		c := compare(a, b)
+		if order == "desc" {
+			c = -c
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		return c
+	})
+}
+
+// searchBooksFuzzy keeps the books that match search.Query and pages them.
+// Without an explicit sort the best scores come first. The matches are only
+// known here, so their facets are counted here too rather than in SQL.
+func searchBooksFuzzy(books []models.BookRepository, search models.BookSearchRequest, page, pageSize int) models.BookListResponse {
+	sortBooks(books, search.Sort, search.Order)
+	terms := tokenize(search.Query)
+	matches := []models.BookData{}
+	for _, book := range books {
+		score := fuzzyScore(terms, book)
+		if score == 0 {
+			continue
+		}
+		bookData := toBookData(book)
+		bookData.Score = score
+		matches = append(matches, bookData)
+	}
+	if search.Sort == "" {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Score > matches[j].Score
+		})
+	}
+	counts := map[[2]string]int64{}
+	for _, match := range matches {
+		availability := models.BookAvailable
+		if match.IsBorrowed {
+			availability = models.BookBorrowed
+		}
+		counts[[2]string{models.BookFacetCategory, match.Category}]++
+		counts[[2]string{models.BookFacetAuthor, match.Author}]++
+		counts[[2]string{models.BookFacetAvailability, availability}]++
+	}
+	facetList := []models.BookFacetRepository{}
+	for key, count := range counts {
+		facetList = append(facetList, models.BookFacetRepository{Facet: key[0], Value: key[1], Count: count})
+	}
+	sort.Slice(facetList, func(i, j int) bool {
+		if facetList[i].Count != facetList[j].Count {
+			return facetList[i].Count > facetList[j].Count
+		}
+		return facetList[i].Value < facetList[j].Value
+	})
+	bookList := []models.BookData{}
+	if start := (page - 1) * pageSize; start < len(matches) {
+		bookList = matches[start:min(start+pageSize, len(matches))]
+	}
+	return models.BookListResponse{
+		Message:  constant.BookGetSuccessMessage,
+		Total:    int64(len(matches)),
+		Page:     page,
+		PageSize: pageSize,
+		Facets:   toBookFacets(facetList),
+		Data:     bookList,
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"strings"
+	"test-exam-forviz/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyFragments(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		expect [][]string
+	}{
+		{"short word whole", "Cat", [][]string{{"cat"}}},
+		{"pairs", "hary pottr", [][]string{{"ha", "ar", "ry"}, {"po", "ot", "tt", "tr"}}},
+		{"repeated pair once", "aaaa", [][]string{{"aa"}}},
+		{"accent folded", "Émile", [][]string{{"em", "mi", "il", "le"}}},
+		{"thai clusters", "ความศุข", [][]string{{"ค", "วา", "ม"}, {"ศุ", "ข"}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expect, fuzzyFragments(tokenize(tC.query)))
+		})
+	}
+}
+
+func TestSegmentThai(t *testing.T) {
+	testCases := []struct {
+		text   string
+		expect []string
+	}{
+		{"ความสุขของกะทิ", []string{"ความสุข", "ของ", "กะทิ"}},
+		{"แฮร์รี่พอตเตอร์กับศิลาอาถรรพ์", []string{"แฮร์รี่พอตเตอร์", "กับ", "ศิลา", "อาถรรพ์"}},
+		{"สุขของ", []string{"สุข", "ของ"}},
+		{"ความศุข", []string{"ความ", "ศุข"}},
+		{"ควมสุ", []string{"ควมสุ"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			words := []string{}
+			for _, word := range tokenize(tC.text) {
+				words = append(words, strings.Join(word.units, ""))
+			}
+			assert.Equal(t, tC.expect, words)
+		})
+	}
+}
+
+// every book fuzzyScore accepts keeps a fragment of each term, so narrowing
+// the candidates in SQL loses no match
+func TestFuzzyFragmentsKeepMatches(t *testing.T) {
+	testCases := []struct {
+		query string
+		title string
+	}{
+		{"harry", "Harry Potter"},
+		{"hxrry", "Harry Potter"},
+		{"potr", "Harry Potter"},
+		{"harrry", "Harry Potter"},
+		{"philosopner stome", "Harry Potter and the Philosopher's Stone"},
+		{"pottr", "Harry Potter"},
+		{"พอตเตอร์", "แฮร์รี่พอตเตอร์กับศิลาอาถรรพ์"},
+		{"ความศุข", "ความสุขของกะทิ"},
+		{"ควมสุ", "ความสุขของกะทิ"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.query, func(t *testing.T) {
+			terms := tokenize(tC.query)
+			assert.Greater(t, fuzzyScore(terms, models.BookRepository{Title: tC.title}), 0.0)
+			for _, group := range fuzzyFragments(terms) {
+				found := false
+				for _, fragment := range group {
+					found = found || strings.Contains(strings.ToLower(tC.title), fragment)
+				}
+				assert.True(t, found, "%v", group)
+			}
+		})
+	}
+}
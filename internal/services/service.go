@@ -1,16 +1,69 @@
 package services
 
 import (
+	"io"
 	"test-exam-forviz/internal/models"
+	"time"
 )
 
 type BookService interface {
-	CreateBook(book models.BookRequest) (models.BookResponse, error)
-	UpdateBook(id int, book models.BookRequest) (models.BookResponse, error)
-	DeleteBook(id int) (models.BookResponse, error)
+	CreateBook(actor models.Actor, book models.BookRequest) (models.BookResponse, error)
+	UpdateBook(actor models.Actor, id, version int, book models.BookRequest) (models.BookResponse, error)
+	DeleteBook(actor models.Actor, id int) (models.BookResponse, error)
+	RestoreBook(actor models.Actor, id int) (models.BookResponse, error)
+	GetTrash(page, pageSize int) (models.BookListResponse, error)
+	PurgeTrash(retention time.Duration) (int64, error)
 	GetBookByID(id int) (models.BookResponse, error)
-	SearchBooks(title, author, category string) (models.BookListResponse, error)
+	GetBookByISBN(isbnCode string) (models.BookResponse, error)
+	SearchBooks(search models.BookSearchRequest) (models.BookListResponse, error)
+	ImportBooks(actor models.Actor, rows BookImportReader, dryRun bool) (models.BookImportResponse, error)
+	ExportBooks(search models.BookSearchRequest, format string, w io.Writer) error
 	GetMostBorrowedBooks() (models.BookListResponse, error)
-	BorrowBook(id int) (models.BookResponse, error)
-	ReturnBook(id int) (models.BookResponse, error)
+	GetBookLoans(id int) (models.LoanListResponse, error)
+	GetOverdueLoans() (models.LoanListResponse, error)
+	BorrowBook(actor models.Actor, id, version, memberID, copyID int) (models.BookResponse, error)
+	ReturnBook(actor models.Actor, id, version, copyID int) (models.BookResponse, error)
+	RenewBook(actor models.Actor, id, version, memberID, copyID int) (models.BookResponse, error)
+}
+
+type CopyService interface {
+	CreateCopy(bookID int, bookCopy models.CopyRequest) (models.CopyResponse, error)
+	UpdateCopy(id int, bookCopy models.CopyRequest) (models.CopyResponse, error)
+	GetCopies(bookID int) (models.CopyListResponse, error)
+}
+
+type MemberService interface {
+	CreateMember(member models.MemberRequest) (models.MemberResponse, error)
+	UpdateMember(id int, member models.MemberRequest) (models.MemberResponse, error)
+	SuspendMember(id int) (models.MemberResponse, error)
+	GetMembers() (models.MemberListResponse, error)
+}
+
+type FineService interface {
+	GetOutstandingFines(memberID int) (models.FineListResponse, error)
+	PayFine(id int) (models.FineResponse, error)
+}
+
+type HoldService interface {
+	PlaceHold(bookID, memberID int) (models.HoldResponse, error)
+	CancelHold(bookID, memberID int) (models.HoldResponse, error)
+	GetHolds(bookID int) (models.HoldListResponse, error)
+}
+
+type AuthService interface {
+	Login(username, password string) (models.TokenResponse, error)
+	CreateUser(user models.UserRequest) (models.UserResponse, error)
+	ParseToken(token string) (*AuthClaims, error)
+	EnsureAdmin() error
+}
+
+type AuditService interface {
+	GetAuditLogs(search models.AuditSearchRequest) (models.AuditListResponse, error)
+}
+
+type ApiKeyService interface {
+	CreateApiKey(apiKey models.ApiKeyRequest) (models.ApiKeyResponse, error)
+	RevokeApiKey(id int) (models.ApiKeyResponse, error)
+	GetApiKeys() (models.ApiKeyListResponse, error)
+	ParseKey(key string) (*AuthClaims, error)
 }
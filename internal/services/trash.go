@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/errs"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/loggers"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// deletedState is what the audit log keeps of a book leaving the trash.
+type deletedState struct {
+	Deleted bool `json:"deleted"`
+}
+
+// RestoreBook implements BookService.
+func (b bookService) RestoreBook(actor models.Actor, id int) (models.BookResponse, error) {
+	err := b.repo.Restore(id, auditChange(actor, models.AuditActionRestore, deletedState{Deleted: true}, deletedState{}))
+	if err != nil {
+		loggers.Error("Error Restore book",
+			zap.String("type", "repo"),
+			zap.Error(err),
+			zap.Int("book_id", id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.BookResponse{}, errs.NewNotFoundError(constant.BookErrorsMessageTrashNotFound)
+		} else {
+			return models.BookResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+		}
+	}
+	return models.BookResponse{
+		Message: constant.BookRestoreSuccessMessage,
+	}, nil
+}
+
+// GetTrash implements BookService.
+// Deleted books come a page at a time, the latest deleted first.
+func (b bookService) GetTrash(page, pageSize int) (models.BookListResponse, error) {
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	books, total, err := b.repo.FindDeleted(models.BookFilter{Offset: (page - 1) * pageSize, Limit: pageSize})
+	if err != nil {
+		loggers.Error("Error FindDeleted book",
+			zap.String("type", "repo"),
+			zap.Error(err))
+		return models.BookListResponse{}, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	bookList := []models.BookData{}
+	for _, book := range books {
+		bookList = append(bookList, toBookData(book))
+	}
+	return models.BookListResponse{
+		Message:  constant.BookGetSuccessMessage,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Data:     bookList,
+	}, nil
+}
+
+// PurgeTrash implements BookService.
+// Books deleted longer than retention ago are removed for good.
+func (b bookService) PurgeTrash(retention time.Duration) (int64, error) {
+	audit := auditChange(models.Actor{Name: models.ActorSystem}, models.AuditActionPurge, deletedState{Deleted: true}, nil)
+	purged, err := b.repo.Purge(time.Now().Add(-retention), audit)
+	if err != nil {
+		loggers.Error("Error Purge book",
+			zap.String("type", "repo"),
+			zap.Error(err))
+		return 0, errs.NewInternalServerError(constant.BookErrorMessageInternalServerError)
+	}
+	return int64(len(purged)), nil
+}
+
+// PurgeTrashEvery calls service.PurgeTrash with the configured retention
+// every cfg.PurgeIntervalMinutes until ctx is done.
+func PurgeTrashEvery(ctx context.Context, service BookService, cfg config.Trash) {
+	retention := time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	ticker := time.NewTicker(time.Duration(max(cfg.PurgeIntervalMinutes, 1)) * time.Minute)
+	defer ticker.Stop()
+	for {
+		purged, err := service.PurgeTrash(retention)
+		if err == nil && purged > 0 {
+			loggers.Info("purge trash successfully.", zap.Int64("books", purged))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
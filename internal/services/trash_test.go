@@ -0,0 +1,168 @@
+package services_test
+
+import (
+	"errors"
+	"test-exam-forviz/config"
+	"test-exam-forviz/constant"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/internal/repositories/db"
+	"test-exam-forviz/internal/services"
+	"test-exam-forviz/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestRestoreBook(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		requestId     int
+		expectSuccess models.BookResponse
+		expectError   error
+	}{
+		{
+			name:      "TestRestoreBookSuccess",
+			requestId: 1,
+			expectSuccess: models.BookResponse{
+				Message: constant.BookRestoreSuccessMessage,
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestRestoreBookFindNotFound",
+			requestId:   1,
+			expectError: errors.New(constant.BookErrorsMessageTrashNotFound),
+		},
+		{
+			name:        "TestRestoreBookErrorInternalServerError",
+			requestId:   1,
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+
+			switch tC.name {
+			case "TestRestoreBookFindNotFound":
+				bookRepo.On("Restore").Return(gorm.ErrRecordNotFound)
+			case "TestRestoreBookErrorInternalServerError":
+				bookRepo.On("Restore").Return(errors.New(""))
+			default:
+				bookRepo.On("Restore").Return(nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			resp, err := bookSvc.RestoreBook(testActor, tC.requestId)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestGetTrash(t *testing.T) {
+	const dateFormat = "02/01/2006"
+	const dateTimeFormat = "02/01/2006 15:04:05"
+	loggers.InitLogger(config.App{Env: "dev"})
+	now := time.Now()
+	testCases := []struct {
+		name          string
+		mockData      []models.BookRepository
+		expectSuccess models.BookListResponse
+		expectError   error
+	}{
+		{
+			name: "TestGetTrashSuccess",
+			mockData: []models.BookRepository{
+				{ID: 2, Title: "title test2", Author: "author test2", Category: "category test2", TotalCopies: 1, AvailableCopies: 1, CreateAt: now, UpdateAt: now, DeletedAt: gorm.DeletedAt{Time: now, Valid: true}},
+			},
+			expectSuccess: models.BookListResponse{
+				Message:  constant.BookGetSuccessMessage,
+				Total:    1,
+				Page:     1,
+				PageSize: 20,
+				Data: []models.BookData{
+					{ID: 2, Title: "title test2", Author: "author test2", Category: "category test2", TotalCopies: 1, AvailableCopies: 1, CreateAt: now.Format(dateFormat), UpdateAt: now.Format(dateFormat), DeletedAt: now.Format(dateTimeFormat)},
+				},
+			},
+			expectError: nil,
+		},
+		{
+			name:        "TestGetTrashErrorInternalServerError",
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+
+			switch tC.name {
+			case "TestGetTrashErrorInternalServerError":
+				bookRepo.On("FindDeleted").Return([]models.BookRepository{}, int64(0), errors.New(""))
+			default:
+				bookRepo.On("FindDeleted").Return(tC.mockData, int64(len(tC.mockData)), nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			resp, err := bookSvc.GetTrash(0, 0)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, resp)
+			}
+
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+
+	loggers.InitLogger(config.App{Env: "dev"})
+	testCases := []struct {
+		name          string
+		expectSuccess int64
+		expectError   error
+	}{
+		{
+			name:          "TestPurgeTrashSuccess",
+			expectSuccess: 3,
+			expectError:   nil,
+		},
+		{
+			name:        "TestPurgeTrashErrorInternalServerError",
+			expectError: errors.New(constant.BookErrorMessageInternalServerError),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			bookRepo := db.NewBookRepositoryMock()
+
+			switch tC.name {
+			case "TestPurgeTrashErrorInternalServerError":
+				bookRepo.On("Purge").Return([]int{}, errors.New(""))
+			default:
+				bookRepo.On("Purge").Return([]int{4, 5, 6}, nil)
+			}
+
+			bookSvc := services.NewBookService(bookRepo, db.NewMemberRepositoryMock(), db.NewCopyRepositoryMock(), db.NewLoanRepositoryMock(), db.NewFineRepositoryMock(), db.NewHoldRepositoryMock(), config.Loan{PeriodDays: 14}, config.Fine{})
+			purged, err := bookSvc.PurgeTrash(30 * 24 * time.Hour)
+			if tC.expectError != nil {
+				assert.EqualError(t, err, tC.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectSuccess, purged)
+			}
+
+		})
+	}
+}
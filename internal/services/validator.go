@@ -0,0 +1,17 @@
+package services
+
+import (
+	"test-exam-forviz/isbn"
+
+	"github.com/go-playground/validator"
+)
+
+// NewBookValidator is validator.New with the isbn_checksum tag of
+// models.BookRequest registered. An empty ISBN passes: it means none.
+func NewBookValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterValidation("isbn_checksum", func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == "" || isbn.Valid(fl.Field().String())
+	})
+	return validate
+}
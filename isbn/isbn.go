@@ -0,0 +1,95 @@
+// Package isbn checks and converts ISBN-10 and ISBN-13 book numbers.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid isbn")
+
+// clean drops the hyphens and spaces ISBNs are usually printed with.
+func clean(s string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s)))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDigit10 returns the ISBN-10 check character for the first nine digits.
+func checkDigit10(digits string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(digits[i]-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// checkDigit13 returns the ISBN-13 check digit for the first twelve digits.
+func checkDigit13(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(digits[i]-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// Valid reports whether s is an ISBN-10 or ISBN-13 with a correct check digit.
+func Valid(s string) bool {
+	_, err := Normalize(s)
+	return err == nil
+}
+
+// Normalize checks s and returns it as a bare ISBN-13, the form books are
+// stored and looked up by.
+func Normalize(s string) (string, error) {
+	s = clean(s)
+	switch len(s) {
+	case 10:
+		if !isDigits(s[:9]) || checkDigit10(s) != s[9] {
+			return "", ErrInvalid
+		}
+		return To13(s)
+	case 13:
+		if !isDigits(s) || checkDigit13(s) != s[12] {
+			return "", ErrInvalid
+		}
+		return s, nil
+	}
+	return "", ErrInvalid
+}
+
+// To13 converts an ISBN-10 to its 978-prefixed ISBN-13.
+func To13(isbn10 string) (string, error) {
+	s := clean(isbn10)
+	if len(s) != 10 || !isDigits(s[:9]) || checkDigit10(s) != s[9] {
+		return "", ErrInvalid
+	}
+	s = "978" + s[:9]
+	return s + string(checkDigit13(s)), nil
+}
+
+// To10 converts an ISBN-13 back to ISBN-10. Only 978-prefixed numbers have
+// an ISBN-10 form.
+func To10(isbn13 string) (string, error) {
+	s := clean(isbn13)
+	if len(s) != 13 || !isDigits(s) || checkDigit13(s) != s[12] || !strings.HasPrefix(s, "978") {
+		return "", ErrInvalid
+	}
+	s = s[3:12]
+	return s + string(checkDigit10(s)), nil
+}
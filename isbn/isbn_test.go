@@ -0,0 +1,98 @@
+package isbn_test
+
+import (
+	"test-exam-forviz/isbn"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect string
+		valid  bool
+	}{
+		{"isbn-10", "0306406152", "9780306406157", true},
+		{"isbn-10 hyphens", "0-306-40615-2", "9780306406157", true},
+		{"isbn-10 x check digit", "0-8044-2957-X", "9780804429573", true},
+		{"isbn-10 lower x check digit", "080442957x", "9780804429573", true},
+		{"isbn-13 hyphens", "978-0-306-40615-7", "9780306406157", true},
+		{"isbn-13 spaces", " 978 0 306 40615 7 ", "9780306406157", true},
+		{"isbn-13 979", "979-10-90636-07-1", "9791090636071", true},
+		{"isbn-10 bad check digit", "0-306-40615-3", "", false},
+		{"isbn-10 x not last", "X-306-40615-2", "", false},
+		{"isbn-10 wrong x", "0-306-40615-X", "", false},
+		{"isbn-13 bad check digit", "978-0-306-40615-8", "", false},
+		{"isbn-13 x check digit", "978-0-306-40615-X", "", false},
+		{"isbn-13 letters", "978-0-306-4O615-7", "", false},
+		{"too short", "12345", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			normalized, err := isbn.Normalize(tC.input)
+			if tC.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, isbn.ErrInvalid)
+			}
+			assert.Equal(t, tC.expect, normalized)
+			assert.Equal(t, tC.valid, isbn.Valid(tC.input))
+		})
+	}
+}
+
+func TestTo13(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect string
+		valid  bool
+	}{
+		{"digits", "0306406152", "9780306406157", true},
+		{"hyphens", "0-306-40615-2", "9780306406157", true},
+		{"x check digit", "1-55404-295-X", "9781554042951", true},
+		{"bad check digit", "0306406153", "", false},
+		{"already isbn-13", "9780306406157", "", false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			isbn13, err := isbn.To13(tC.input)
+			if tC.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, isbn.ErrInvalid)
+			}
+			assert.Equal(t, tC.expect, isbn13)
+		})
+	}
+}
+
+func TestTo10(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect string
+		valid  bool
+	}{
+		{"digits", "9780306406157", "0306406152", true},
+		{"hyphens and spaces", "978-0 306-40615 7", "0306406152", true},
+		{"x check digit", "978-1-55404-295-1", "155404295X", true},
+		{"979 has no isbn-10", "979-10-90636-07-1", "", false},
+		{"bad check digit", "9780306406158", "", false},
+		{"already isbn-10", "0306406152", "", false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			isbn10, err := isbn.To10(tC.input)
+			if tC.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, isbn.ErrInvalid)
+			}
+			assert.Equal(t, tC.expect, isbn10)
+		})
+	}
+}
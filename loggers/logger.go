@@ -37,3 +37,9 @@ func Error(msg string, field ...zapcore.Field) {
 func Fatal(msg string, field ...zapcore.Field) {
 	logger.Fatal(msg, field...)
 }
+
+// Sync flushes buffered entries; call it before the process exits. Syncing
+// a terminal fails on some systems, so the error is dropped.
+func Sync() {
+	logger.Sync()
+}
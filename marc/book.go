@@ -0,0 +1,115 @@
+package marc
+
+import (
+	"strconv"
+	"strings"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/isbn"
+	"unicode/utf8"
+)
+
+// Tags of the fields mapped to and from models.BookRepository.
+const (
+	TagControlNumber = "001"
+	TagISBN          = "020"
+	TagAuthor        = "100"
+	TagTitle         = "245"
+	TagSubject       = "650"
+)
+
+// FromBook builds the bibliographic record of book: its id as control
+// number, ISBN, main author, title and category as a topical subject.
+func FromBook(book models.BookRepository) Record {
+	record := Record{Leader: defaultLeader}
+	record.Fields = append(record.Fields, Field{Tag: TagControlNumber, Value: strconv.Itoa(book.ID)})
+	if book.ISBN != nil {
+		record.Fields = append(record.Fields, Field{Tag: TagISBN, Indicators: [2]byte{' ', ' '}, Subfields: []Subfield{{Code: 'a', Value: *book.ISBN}}})
+	}
+	// the first title indicator tells whether a 1XX main entry exists
+	titleIndicator := byte('0')
+	if book.Author != "" {
+		titleIndicator = '1'
+		record.Fields = append(record.Fields, Field{Tag: TagAuthor, Indicators: [2]byte{'1', ' '}, Subfields: []Subfield{{Code: 'a', Value: book.Author}}})
+	}
+	record.Fields = append(record.Fields, Field{Tag: TagTitle, Indicators: [2]byte{titleIndicator, '0'}, Subfields: []Subfield{{Code: 'a', Value: book.Title}}})
+	if book.Category != "" {
+		record.Fields = append(record.Fields, Field{Tag: TagSubject, Indicators: [2]byte{' ', '4'}, Subfields: []Subfield{{Code: 'a', Value: book.Category}}})
+	}
+	return record
+}
+
+// ToBook maps record onto a book: 245 $a and $b as the title, 100 $a as the
+// author, the first 650 $a as the category and the first valid 020 $a as the
+// ISBN. It also returns, in record order, every field ("500") and subfield
+// of a mapped field ("245$c") that the book has no place for.
+func ToBook(record Record) (models.BookRepository, []string) {
+	book := models.BookRepository{}
+	unsupported := []string{}
+	seen := map[string]bool{}
+	report := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			unsupported = append(unsupported, name)
+		}
+	}
+	reportSubfields := func(field Field, mapped string) {
+		for _, subfield := range field.Subfields {
+			if !strings.ContainsRune(mapped, rune(subfield.Code)) {
+				report(field.Tag + "$" + string(subfield.Code))
+			}
+		}
+	}
+	var isbnFound, authorFound, titleFound, subjectFound bool
+	for _, field := range record.Fields {
+		switch {
+		case field.Tag == TagISBN && !isbnFound:
+			// $a may carry a qualifier after the number, as in "0306406152 (pbk.)"
+			if isbn13, err := isbn.Normalize(firstWord(field.SubfieldValue('a'))); err == nil {
+				isbnFound = true
+				book.ISBN = &isbn13
+				reportSubfields(field, "aq")
+				continue
+			}
+			reportSubfields(field, "")
+		case field.Tag == TagAuthor && !authorFound:
+			authorFound = true
+			book.Author = trimPunctuation(field.SubfieldValue('a'))
+			reportSubfields(field, "a")
+		case field.Tag == TagTitle && !titleFound:
+			titleFound = true
+			book.Title = trimPunctuation(field.SubfieldValue('a'))
+			if subtitle := trimPunctuation(field.SubfieldValue('b')); subtitle != "" {
+				book.Title += ": " + subtitle
+			}
+			reportSubfields(field, "ab")
+		case field.Tag == TagSubject && !subjectFound:
+			subjectFound = true
+			book.Category = trimPunctuation(field.SubfieldValue('a'))
+			reportSubfields(field, "a")
+		default:
+			// a book has one ISBN and one category, so repeats are lost too
+			report(field.Tag)
+		}
+	}
+	return book, unsupported
+}
+
+func firstWord(s string) string {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// trimPunctuation drops the ISBD punctuation cataloguers end subfields with,
+// keeping the full stop of a trailing initial such as "Smith, J.".
+func trimPunctuation(s string) string {
+	s = strings.TrimRight(strings.TrimSpace(s), " ,:;/=")
+	if strings.HasSuffix(s, ".") {
+		words := strings.Fields(s)
+		if last := words[len(words)-1]; utf8.RuneCountInString(last) > 2 {
+			s = strings.TrimSuffix(s, ".")
+		}
+	}
+	return s
+}
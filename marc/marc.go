@@ -0,0 +1,144 @@
+// Package marc reads and writes bibliographic records in MARC 21.
+package marc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ISO 2709 delimiters
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+const (
+	leaderLength    = 24
+	directoryLength = 12
+	// defaultLeader describes a Unicode monograph; its lengths are filled in
+	// by Marshal.
+	defaultLeader = "00000nam a2200000 u 4500"
+)
+
+type Record struct {
+	Leader string
+	Fields []Field
+}
+
+// Field is a control field (tags 001-009) holding Value, or a data field
+// holding Indicators and Subfields.
+type Field struct {
+	Tag        string
+	Value      string
+	Indicators [2]byte
+	Subfields  []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+func (f Field) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// SubfieldValue returns the first value of subfield code, or "".
+func (f Field) SubfieldValue(code byte) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+	return ""
+}
+
+// FieldsByTag returns the fields of r tagged tag, in record order.
+func (r Record) FieldsByTag(tag string) []Field {
+	fields := []Field{}
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (f Field) data() string {
+	var b strings.Builder
+	if f.IsControl() {
+		b.WriteString(f.Value)
+	} else {
+		for _, indicator := range f.Indicators {
+			if indicator == 0 {
+				indicator = ' '
+			}
+			b.WriteByte(indicator)
+		}
+		for _, subfield := range f.Subfields {
+			b.WriteByte(subfieldDelimiter)
+			b.WriteByte(subfield.Code)
+			b.WriteString(subfield.Value)
+		}
+	}
+	b.WriteByte(fieldTerminator)
+	return b.String()
+}
+
+// Marshal encodes r in the ISO 2709 exchange format. The record length and
+// base address in the leader are computed; the rest of r.Leader is kept.
+func (r Record) Marshal() ([]byte, error) {
+	leader := r.Leader
+	if len(leader) != leaderLength {
+		leader = defaultLeader
+	}
+	var directory, data strings.Builder
+	for _, field := range r.Fields {
+		if len(field.Tag) != 3 {
+			return nil, fmt.Errorf("marc: invalid tag %q", field.Tag)
+		}
+		fieldData := field.data()
+		if len(fieldData) > 9999 || data.Len() > 99999 {
+			return nil, fmt.Errorf("marc: field %s too long", field.Tag)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", field.Tag, len(fieldData), data.Len())
+		data.WriteString(fieldData)
+	}
+	directory.WriteByte(fieldTerminator)
+	baseAddress := leaderLength + directory.Len()
+	recordLength := baseAddress + data.Len() + 1
+	if recordLength > 99999 {
+		return nil, fmt.Errorf("marc: record too long")
+	}
+	record := make([]byte, 0, recordLength)
+	record = fmt.Appendf(record, "%05d%s%05d%s", recordLength, leader[5:12], baseAddress, leader[17:])
+	record = append(record, directory.String()...)
+	record = append(record, data.String()...)
+	record = append(record, recordTerminator)
+	return record, nil
+}
+
+// Writer writes records one after another, as MARC 21 files hold them.
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Write(r Record) error {
+	record, err := r.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(record)
+	return err
+}
+
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
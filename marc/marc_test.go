@@ -0,0 +1,159 @@
+package marc_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"test-exam-forviz/internal/models"
+	"test-exam-forviz/marc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBook() models.BookRepository {
+	isbn := "9780441013593"
+	return models.BookRepository{ID: 7, Title: "title test1", Author: "author test1", Category: "category test1", ISBN: &isbn}
+}
+
+// marshal is the ISO 2709 encoding of the record of testBook.
+func marshal(t *testing.T) []byte {
+	t.Helper()
+	data, err := marc.FromBook(testBook()).Marshal()
+	require.NoError(t, err)
+	return data
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writer := marc.NewWriter(&buf)
+	record := marc.FromBook(testBook())
+	require.NoError(t, writer.Write(record))
+	require.NoError(t, writer.Write(record))
+	require.NoError(t, writer.Flush())
+
+	reader := marc.NewReader(&buf)
+	for i := 0; i < 2; i++ {
+		read, err := reader.Read()
+		require.NoError(t, err)
+		assert.Equal(t, record.Fields, read.Fields)
+		book, unsupported := marc.ToBook(read)
+		assert.Equal(t, "title test1", book.Title)
+		assert.Equal(t, "author test1", book.Author)
+		assert.Equal(t, "category test1", book.Category)
+		assert.Equal(t, "9780441013593", *book.ISBN)
+		assert.Equal(t, []string{marc.TagControlNumber}, unsupported)
+	}
+	_, err := reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestXMLReaderRoundTrip(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record>
+    <leader>00000nam a2200000 u 4500</leader>
+    <controlfield tag="001">7</controlfield>
+    <datafield tag="020" ind1=" " ind2=" "><subfield code="a">9780441013593</subfield></datafield>
+    <datafield tag="100" ind1="1" ind2=" "><subfield code="a">author test1</subfield></datafield>
+    <datafield tag="245" ind1="1" ind2="0"><subfield code="a">title test1</subfield></datafield>
+    <datafield tag="650" ind1=" " ind2="4"><subfield code="a">category test1</subfield></datafield>
+  </record>
+</collection>`
+	record, err := marc.NewXMLReader(strings.NewReader(document)).Read()
+	require.NoError(t, err)
+	assert.Equal(t, marc.FromBook(testBook()).Fields, record.Fields)
+
+	// through ISO 2709 and back again
+	data, err := record.Marshal()
+	require.NoError(t, err)
+	read, err := marc.NewReader(bytes.NewReader(data)).Read()
+	require.NoError(t, err)
+	assert.Equal(t, record.Fields, read.Fields)
+}
+
+func TestReaderMalformed(t *testing.T) {
+	// patch overwrites the bytes of the record of testBook at offset
+	patch := func(offset int, value string) func([]byte) []byte {
+		return func(data []byte) []byte {
+			copy(data[offset:], value)
+			return data
+		}
+	}
+	// the first directory entry follows the 24 byte leader: tag, length, start
+	const entry = 24
+	testCases := []struct {
+		name   string
+		record func(data []byte) []byte
+	}{
+		{"truncated leader", func(data []byte) []byte { return append([]byte("00010nam"), 0x1D) }},
+		{"base address not a number", patch(12, "abcde")},
+		{"base address inside the leader", patch(12, "00010")},
+		{"base address past the record", patch(12, "99999")},
+		{"negative field start", patch(entry+7, "-0001")},
+		{"field start past the data", patch(entry+7, "99999")},
+		{"field length past the data", patch(entry+3, "9999")},
+		{"zero field length", patch(entry+3, "0000")},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			data := tC.record(marshal(t))
+			// a good record after the bad one is still read
+			reader := marc.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(marshal(t))))
+			_, err := reader.Read()
+			assert.True(t, errors.Is(err, marc.ErrMalformed), "%v", err)
+			_, err = reader.Read()
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("missing record terminator", func(t *testing.T) {
+		data := marshal(t)
+		_, err := marc.NewReader(bytes.NewReader(data[:len(data)-1])).Read()
+		assert.True(t, errors.Is(err, marc.ErrMalformed), "%v", err)
+	})
+}
+
+func TestToBookMissingFields(t *testing.T) {
+	testCases := []struct {
+		name        string
+		record      marc.Record
+		expectBook  models.BookRepository
+		unsupported []string
+	}{
+		{
+			name: "missing title",
+			record: marc.Record{Fields: []marc.Field{
+				{Tag: marc.TagAuthor, Subfields: []marc.Subfield{{Code: 'a', Value: "author test1,"}}},
+			}},
+			expectBook:  models.BookRepository{Author: "author test1"},
+			unsupported: []string{},
+		},
+		{
+			name: "missing author",
+			record: marc.Record{Fields: []marc.Field{
+				{Tag: marc.TagTitle, Subfields: []marc.Subfield{{Code: 'a', Value: "title test1 :"}, {Code: 'b', Value: "subtitle test1 /"}, {Code: 'c', Value: "by someone"}}},
+			}},
+			expectBook:  models.BookRepository{Title: "title test1: subtitle test1"},
+			unsupported: []string{"245$c"},
+		},
+		{
+			name: "invalid isbn",
+			record: marc.Record{Fields: []marc.Field{
+				{Tag: marc.TagISBN, Subfields: []marc.Subfield{{Code: 'a', Value: "123 (pbk.)"}}},
+				{Tag: "500", Subfields: []marc.Subfield{{Code: 'a', Value: "note"}}},
+			}},
+			expectBook:  models.BookRepository{},
+			unsupported: []string{"020$a", "500"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			book, unsupported := marc.ToBook(tC.record)
+			assert.Equal(t, tC.expectBook, book)
+			assert.Equal(t, tC.unsupported, unsupported)
+		})
+	}
+}
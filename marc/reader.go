@@ -0,0 +1,176 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrMalformed is wrapped by the errors of a record that could not be read.
+// The reader has moved past that record, so the next Read may still succeed.
+var ErrMalformed = errors.New("marc: malformed record")
+
+// RecordReader is implemented by Reader and XMLReader. Read returns io.EOF
+// after the last record.
+type RecordReader interface {
+	Read() (Record, error)
+}
+
+// Reader reads records in the ISO 2709 exchange format, one after another.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+func (r *Reader) Read() (Record, error) {
+	data, err := r.r.ReadBytes(recordTerminator)
+	// files are often written with a line break between records
+	data = bytes.TrimLeft(data, "\r\n")
+	if errors.Is(err, io.EOF) {
+		if len(bytes.TrimSpace(data)) == 0 {
+			return Record{}, io.EOF
+		}
+		return Record{}, fmt.Errorf("%w: missing record terminator", ErrMalformed)
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	return unmarshal(data[:len(data)-1])
+}
+
+// unmarshal decodes one record without its record terminator. Field
+// boundaries come from the directory; the record length in the leader is not
+// trusted, since it is often wrong once a file has been re-encoded.
+func unmarshal(data []byte) (Record, error) {
+	if len(data) < leaderLength {
+		return Record{}, fmt.Errorf("%w: record shorter than its leader", ErrMalformed)
+	}
+	if !utf8.Valid(data) {
+		return Record{}, fmt.Errorf("%w: record is not UTF-8", ErrMalformed)
+	}
+	record := Record{Leader: string(data[:leaderLength])}
+	baseAddress, err := strconv.Atoi(record.Leader[12:17])
+	if err != nil || baseAddress <= leaderLength || baseAddress > len(data) {
+		return Record{}, fmt.Errorf("%w: invalid base address %q", ErrMalformed, record.Leader[12:17])
+	}
+	directory := data[leaderLength : baseAddress-1]
+	if data[baseAddress-1] != fieldTerminator || len(directory)%directoryLength != 0 {
+		return Record{}, fmt.Errorf("%w: invalid directory", ErrMalformed)
+	}
+	fields := data[baseAddress:]
+	for i := 0; i < len(directory); i += directoryLength {
+		entry := string(directory[i : i+directoryLength])
+		length, lengthErr := strconv.Atoi(entry[3:7])
+		start, startErr := strconv.Atoi(entry[7:12])
+		if lengthErr != nil || startErr != nil || length < 1 || start < 0 || start+length > len(fields) {
+			return Record{}, fmt.Errorf("%w: invalid directory entry %q", ErrMalformed, entry)
+		}
+		fieldData := string(bytes.TrimSuffix(fields[start:start+length], []byte{fieldTerminator}))
+		record.Fields = append(record.Fields, parseField(entry[:3], fieldData))
+	}
+	return record, nil
+}
+
+func parseField(tag, data string) Field {
+	field := Field{Tag: tag}
+	if field.IsControl() {
+		field.Value = data
+		return field
+	}
+	if len(data) >= 2 {
+		field.Indicators = [2]byte{data[0], data[1]}
+		data = data[2:]
+	}
+	for _, subfield := range strings.Split(data, string(rune(subfieldDelimiter)))[1:] {
+		if subfield == "" {
+			continue
+		}
+		field.Subfields = append(field.Subfields, Subfield{Code: subfield[0], Value: subfield[1:]})
+	}
+	return field
+}
+
+// XMLReader reads the record elements of a MARCXML document, whether it is a
+// collection or a single record.
+type XMLReader struct {
+	d *xml.Decoder
+}
+
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{d: xml.NewDecoder(r)}
+}
+
+type xmlRecord struct {
+	Leader        string `xml:"leader"`
+	ControlFields []struct {
+		Tag   string `xml:"tag,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"controlfield"`
+	DataFields []struct {
+		Tag       string `xml:"tag,attr"`
+		Ind1      string `xml:"ind1,attr"`
+		Ind2      string `xml:"ind2,attr"`
+		Subfields []struct {
+			Code  string `xml:"code,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"subfield"`
+	} `xml:"datafield"`
+}
+
+func (r *XMLReader) Read() (Record, error) {
+	for {
+		token, err := r.d.Token()
+		if err != nil {
+			return Record{}, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var element xmlRecord
+		if err := r.d.DecodeElement(&element, &start); err != nil {
+			return Record{}, err
+		}
+		return element.record()
+	}
+}
+
+func (x xmlRecord) record() (Record, error) {
+	record := Record{Leader: x.Leader}
+	for _, control := range x.ControlFields {
+		if len(control.Tag) != 3 {
+			return Record{}, fmt.Errorf("%w: invalid tag %q", ErrMalformed, control.Tag)
+		}
+		record.Fields = append(record.Fields, Field{Tag: control.Tag, Value: control.Value})
+	}
+	for _, data := range x.DataFields {
+		if len(data.Tag) != 3 {
+			return Record{}, fmt.Errorf("%w: invalid tag %q", ErrMalformed, data.Tag)
+		}
+		field := Field{Tag: data.Tag, Indicators: [2]byte{indicator(data.Ind1), indicator(data.Ind2)}}
+		for _, subfield := range data.Subfields {
+			if len(subfield.Code) != 1 {
+				return Record{}, fmt.Errorf("%w: invalid subfield code %q in %s", ErrMalformed, subfield.Code, data.Tag)
+			}
+			field.Subfields = append(field.Subfields, Subfield{Code: subfield.Code[0], Value: subfield.Value})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record, nil
+}
+
+func indicator(s string) byte {
+	if len(s) != 1 {
+		return ' '
+	}
+	return s[0]
+}